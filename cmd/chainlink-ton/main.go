@@ -22,7 +22,8 @@ func main() {
 	s := loop.MustNewStartedServer(loggerName)
 	defer s.Stop()
 
-	p := &pluginRelayer{Plugin: loop.Plugin{Logger: s.Logger}}
+	// the data source is set when the host passes a database URL to the plugin
+	p := &pluginRelayer{Plugin: loop.Plugin{Logger: s.Logger}, ds: s.DataSource}
 	defer s.Logger.ErrorIfFn(p.Close, "failed to close")
 
 	s.MustRegister(p)
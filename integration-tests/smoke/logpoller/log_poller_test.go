@@ -138,6 +138,7 @@ func Test_LogPoller(t *testing.T) {
 		lp := logpoller.NewLogPoller(
 			logger.Test(t),
 			client,
+			nil,
 			cfg,
 		)
 
@@ -148,7 +149,7 @@ func Test_LogPoller(t *testing.T) {
 			EventName:  "CounterIncreased",
 			EventTopic: counter.TopicCountIncreased,
 		}
-		lp.RegisterFilter(t.Context(), filterA)
+		require.NoError(t, lp.RegisterFilter(t.Context(), filterA))
 
 		filterB := types.Filter{
 			Name:       "FilterB",
@@ -156,7 +157,7 @@ func Test_LogPoller(t *testing.T) {
 			EventName:  "CounterIncreased",
 			EventTopic: counter.TopicCountIncreased,
 		}
-		lp.RegisterFilter(t.Context(), filterB)
+		require.NoError(t, lp.RegisterFilter(t.Context(), filterB))
 
 		// start listening for logs
 		require.NoError(t, lp.Start(t.Context()))
@@ -207,8 +208,16 @@ func Test_LogPoller(t *testing.T) {
 			}
 
 			// Check log poller has ingested events from both
-			logsA := lp.GetLogs(emitterA.ContractAddress())
-			logsB := lp.GetLogs(emitterB.ContractAddress())
+			logsA, err := lp.GetLogs(t.Context(), emitterA.ContractAddress(), logpoller.ConfidenceLatest)
+			if err != nil {
+				t.Logf("Failed to get logs for emitterA, retrying: %v", err)
+				return false
+			}
+			logsB, err := lp.GetLogs(t.Context(), emitterB.ContractAddress(), logpoller.ConfidenceLatest)
+			if err != nil {
+				t.Logf("Failed to get logs for emitterB, retrying: %v", err)
+				return false
+			}
 
 			t.Logf("Log poller has %d logs for emitter A, %d logs for emitter B", len(logsA), len(logsB))
 
@@ -303,7 +312,7 @@ func Test_LogPoller(t *testing.T) {
 
 				options := logpoller.QueryOptions{} // Default options (no sorting, no pagination)
 
-				result, err := lp.FilteredLogs(emitterA.ContractAddress(), counter.TopicCountIncreased, queries, options)
+				result, err := lp.FilteredLogs(t.Context(), emitterA.ContractAddress(), counter.TopicCountIncreased, queries, options)
 				require.NoError(t, err)
 
 				require.Len(t, result.Logs, 5, "expected exactly 5 logs for the range 6-10")
@@ -339,7 +348,7 @@ func Test_LogPoller(t *testing.T) {
 
 				options := logpoller.QueryOptions{} // Default options
 
-				result, err := lp.FilteredLogs(emitterB.ContractAddress(), counter.TopicCountIncreased, queries, options)
+				result, err := lp.FilteredLogs(t.Context(), emitterB.ContractAddress(), counter.TopicCountIncreased, queries, options)
 				require.NoError(t, err)
 
 				require.Len(t, result.Logs, 3, "expected exactly 3 logs for the range 1-3")
@@ -371,7 +380,7 @@ func Test_LogPoller(t *testing.T) {
 
 				options := logpoller.QueryOptions{} // Default options
 
-				result, err := lp.FilteredLogs(emitterB.ContractAddress(), counter.TopicCountIncreased, queries, options)
+				result, err := lp.FilteredLogs(t.Context(), emitterB.ContractAddress(), counter.TopicCountIncreased, queries, options)
 				require.NoError(t, err)
 
 				require.Len(t, result.Logs, targetCounter, "expected exactly %d logs for the emitter B", targetCounter)
@@ -407,7 +416,7 @@ func Test_LogPoller(t *testing.T) {
 					return test_utils.ParseEventFromCell[counter.CountIncreased](c)
 				}
 
-				res, err := lp.FilteredLogsWithParser(emitterB.ContractAddress(), counter.TopicCountIncreased, parser, nil)
+				res, err := lp.FilteredLogsWithParser(t.Context(), emitterB.ContractAddress(), counter.TopicCountIncreased, parser, nil, logpoller.ConfidenceLatest)
 				require.NoError(t, err)
 
 				require.Len(t, res, targetCounter, "expected exactly %d logs for the emitter B", targetCounter)
@@ -449,7 +458,7 @@ func Test_LogPoller(t *testing.T) {
 					return evt.Value >= uint32(from) && evt.Value <= uint32(to) //nolint:gosec // test code
 				}
 
-				res, err := lp.FilteredLogsWithParser(emitterB.ContractAddress(), counter.TopicCountIncreased, parser, filter)
+				res, err := lp.FilteredLogsWithParser(t.Context(), emitterB.ContractAddress(), counter.TopicCountIncreased, parser, filter, logpoller.ConfidenceLatest)
 				require.NoError(t, err)
 
 				require.Len(t, res, to-from+1, "expected exactly 10 logs for the range 1-10")
@@ -486,6 +495,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					[]logpoller.CellQuery{}, // No cell filters
@@ -512,6 +522,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					[]logpoller.CellQuery{},
@@ -540,6 +551,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					[]logpoller.CellQuery{},
@@ -569,6 +581,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					[]logpoller.CellQuery{},
@@ -586,6 +599,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				firstPageResult, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					[]logpoller.CellQuery{},
@@ -619,6 +633,7 @@ func Test_LogPoller(t *testing.T) {
 					}
 
 					result, err := lp.FilteredLogs(
+						t.Context(),
 						emitterA.ContractAddress(),
 						counter.TopicCountIncreased,
 						[]logpoller.CellQuery{},
@@ -685,6 +700,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					cellQueries,
@@ -731,6 +747,7 @@ func Test_LogPoller(t *testing.T) {
 					}
 
 					result, err := lp.FilteredLogs(
+						t.Context(),
 						emitterB.ContractAddress(),
 						counter.TopicCountIncreased,
 						[]logpoller.CellQuery{},
@@ -787,6 +804,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					cellQueries,
@@ -810,6 +828,7 @@ func Test_LogPoller(t *testing.T) {
 				}
 
 				result, err := lp.FilteredLogs(
+					t.Context(),
 					emitterA.ContractAddress(),
 					counter.TopicCountIncreased,
 					[]logpoller.CellQuery{},
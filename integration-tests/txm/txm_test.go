@@ -105,9 +105,9 @@ func runTxmTest(t *testing.T, logger logger.Logger, config txm.Config, tonChain
 		incrementBody, incErr := tlb.ToCell(counter.IncreaseCount{QueryID: queryID})
 		require.NoError(t, incErr)
 
-		incErr = tonTxm.Enqueue(txm.Request{
+		_, incErr = tonTxm.Enqueue(txm.Request{
 			Mode:            wallet.PayGasSeparately,
-			FromWallet:      *tonChain.Wallet,
+			From:            tonChain.Wallet.Address(),
 			ContractAddress: *counterAddr,
 			Amount:          tlb.MustFromTON("0.05"),
 			Bounce:          true,
@@ -120,9 +120,9 @@ func runTxmTest(t *testing.T, logger logger.Logger, config txm.Config, tonChain
 		setCountBody, incErr := tlb.ToCell(counter.SetCount{QueryID: queryID, NewCount: expected * 4})
 		require.NoError(t, incErr)
 
-		incErr = tonTxm.Enqueue(txm.Request{
+		_, incErr = tonTxm.Enqueue(txm.Request{
 			Mode:            wallet.PayGasSeparately,
-			FromWallet:      *tonChain.Wallet,
+			From:            tonChain.Wallet.Address(),
 			ContractAddress: *counterAddr,
 			Amount:          tlb.MustFromTON("0.05"),
 			Bounce:          true,
@@ -149,7 +149,8 @@ func waitForStableInflightCount(logger logger.Logger, txm *txm.Txm, duration tim
 	stabilityReached := false
 
 	for {
-		queueLen, unconfirmedLen := txm.InflightCount()
+		queueDepths, unconfirmedLen := txm.InflightCount()
+		queueLen := queueDepths.Total()
 
 		if queueLen == 0 && unconfirmedLen == 0 {
 			if !stabilityReached {
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/services"
+	commontypes "github.com/smartcontractkit/chainlink-common/pkg/types"
 	"github.com/smartcontractkit/chainlink-common/pkg/types/ccipocr3"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3types"
 	ocrtypes "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-ton/pkg/fees"
 	"github.com/smartcontractkit/chainlink-ton/pkg/txm"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -32,6 +36,26 @@ type ToEd25519CalldataFunc func(
 
 type RawReportContext3Func func(configDigest [32]byte, seqNr uint64) [2][32]byte
 
+// TransmitterConfig sets the value attached to the transmitted reports.
+type TransmitterConfig struct {
+	AutoAmount       bool   // Whether the Txm sizes the value attached to a report from its estimated fees
+	AmountNano       uint64 // Value attached to every report when AutoAmount is disabled
+	ReportBaseGas    uint64 // Gas the offramp is expected to use for any report, for AutoAmount
+	ReportGasPerCell uint64 // Gas the offramp is expected to use per cell of a report, for AutoAmount
+}
+
+// DefaultTransmitterConfig sizes the value of the reports from their fees. The expected gas is a conservative
+// bound rather than a measurement: the base covers verifying up to 32 signatures and updating the offramp
+// state, the part per cell covers loading every cell of the report and processing the messages and token
+// amounts it carries. The Txm adds its safety margin on top, and records the fees actually paid once a
+// report finalized, from which both can be tuned.
+var DefaultTransmitterConfig = TransmitterConfig{
+	AutoAmount:       true,
+	AmountNano:       50_000_000, // 0.05 TON
+	ReportBaseGas:    100_000,
+	ReportGasPerCell: 10_000,
+}
+
 var _ ocr3types.ContractTransmitter[[]byte] = &ccipTransmitter{}
 
 type ccipTransmitter struct {
@@ -41,19 +65,30 @@ type ccipTransmitter struct {
 	rawReportContextFn  RawReportContext3Func
 	extraDataCodec      ccipocr3.ExtraDataCodec
 	lggr                logger.Logger
+	cfg                 TransmitterConfig
+
+	// the outcome of the transmitted reports is tracked until stopCh is closed, wg waits for the trackers
+	stopCh services.StopChan
+	wg     *sync.WaitGroup
 }
 
 func NewCCIPTransmitter(
 	txm txm.TxManager,
 	lggr logger.Logger,
+	cfg TransmitterConfig,
+	stopCh services.StopChan,
+	wg *sync.WaitGroup,
 ) (ocr3types.ContractTransmitter[[]byte], error) {
-	if txm == nil || lggr == nil {
+	if txm == nil || lggr == nil || stopCh == nil || wg == nil {
 		return nil, errors.New("invalid transmitter args")
 	}
 
 	return &ccipTransmitter{
-		txm:  txm,
-		lggr: lggr,
+		txm:    txm,
+		lggr:   lggr,
+		cfg:    cfg,
+		stopCh: stopCh,
+		wg:     wg,
 	}, nil
 }
 
@@ -95,20 +130,84 @@ func (c *ccipTransmitter) Transmit(
 		return fmt.Errorf("expected args to be *cell.Cell, got %T", args)
 	}
 
+	// the offramp only accepts reports from the transmitter reported by FromAccount
 	w := c.txm.GetClient().Wallet
 	request := txm.Request{
 		Mode:            wallet.PayGasSeparately,
-		FromWallet:      w,
+		From:            w.Address(),
 		ContractAddress: *address.MustParseAddr(c.offrampAddress),
 		Body:            body,
-		Amount:          tlb.MustFromTON("0.05"),
+		// reports go stale, they are not held back by bulk sends of the same wallet
+		Priority: txm.PriorityHigh,
+		// OCR may retry transmitting the same report, the key makes the retry a no-op unless the
+		// transaction of the report errored, expired or failed on-chain
+		IdempotencyKey: fmt.Sprintf("%s-%x-%d", method, configDigest[:], seqNr),
+	}
+	if c.cfg.AutoAmount {
+		request.AutoAmount, request.ExpectedGas = true, c.reportExpectedGas(body)
+	} else {
+		request.Amount = tlb.FromNanoTONU(c.cfg.AmountNano)
 	}
 
 	c.lggr.Infow("Submitting transaction", "address", c.offrampAddress, "method", method)
 
-	if err := c.txm.Enqueue(request); err != nil {
+	txID, err := c.txm.Enqueue(request)
+	if err != nil {
 		return fmt.Errorf("failed to submit transaction via txm: %w", err)
 	}
 
+	c.lggr.Debugw("Transaction enqueued", "txID", txID, "seqNr", seqNr)
+
+	updates, unsubscribe, err := c.txm.Subscribe(ctx, txID)
+	if err != nil {
+		c.lggr.Warnw("Failed to subscribe to transaction status", "txID", txID, "err", err)
+		return nil
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer unsubscribe()
+		c.logOutcome(updates, txID, method, seqNr)
+	}()
+
 	return nil
 }
+
+// reportExpectedGas roughly bounds the gas used by the offramp to process a report, which grows with
+// the number of cells of the report: execute reports carry the messages and their token amounts.
+func (c *ccipTransmitter) reportExpectedGas(body *cell.Cell) uint64 {
+	_, cells := fees.MessageSize(body)
+	return c.cfg.ReportBaseGas + c.cfg.ReportGasPerCell*cells
+}
+
+// logOutcome logs the final status of a transmitted report, OCR retries reports that failed to land.
+func (c *ccipTransmitter) logOutcome(updates <-chan txm.TxStatusUpdate, txID string, method string, seqNr uint64) {
+	var last txm.TxStatusUpdate
+track:
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				break track
+			}
+			last = update
+		case <-c.stopCh:
+			break track
+		}
+	}
+
+	switch {
+	case !last.Terminal():
+		c.lggr.Debugw("Stopped tracking transaction before it completed", "txID", txID, "seqNr", seqNr, "state", last.State)
+	case last.Status == commontypes.Finalized:
+		c.lggr.Infow("Transaction finalized", "txID", txID, "method", method, "seqNr", seqNr,
+			"totalActionFees", last.TotalActionFees.String())
+	default:
+		reason := last.Reason
+		if last.Failure != nil {
+			reason = last.Failure.String()
+		}
+		c.lggr.Warnw("Transaction failed", "txID", txID, "method", method, "seqNr", seqNr, "state", last.State,
+			"exitCode", last.ExitCode, "reason", reason, "totalActionFees", last.TotalActionFees.String())
+	}
+}
@@ -24,20 +24,22 @@ type Provider struct {
 	ca   ccipocr3.ChainAccessor
 	ct   ocr3types.ContractTransmitter[[]byte]
 
-	wg sync.WaitGroup
+	stopCh services.StopChan
+	wg     sync.WaitGroup
 	services.StateMachine
 }
 
-func NewCCIPProvider(lggr logger.Logger, txm txm.TxManager) (*Provider, error) {
-	ct, err := ocr.NewCCIPTransmitter(txm, lggr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create a CCIP ContractTransmitter %w", err)
+func NewCCIPProvider(lggr logger.Logger, txm txm.TxManager, transmitterCfg ocr.TransmitterConfig) (*Provider, error) {
+	cp := &Provider{
+		lggr:   logger.Named(lggr, CCIPProviderName),
+		stopCh: make(services.StopChan),
 	}
 
-	cp := &Provider{
-		lggr: logger.Named(lggr, CCIPProviderName),
-		ct:   ct,
+	ct, err := ocr.NewCCIPTransmitter(txm, lggr, transmitterCfg, cp.stopCh, &cp.wg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a CCIP ContractTransmitter %w", err)
 	}
+	cp.ct = ct
 
 	return cp, nil
 }
@@ -59,6 +61,7 @@ func (cp *Provider) Start(ctx context.Context) error {
 
 func (cp *Provider) Close() error {
 	return cp.StopOnce(CCIPProviderName, func() error {
+		close(cp.stopCh)
 		cp.wg.Wait()
 		return nil
 	})
@@ -7,22 +7,53 @@ import (
 
 	"github.com/smartcontractkit/chainlink-common/pkg/config"
 
+	"github.com/smartcontractkit/chainlink-ton/pkg/ccip/ocr"
+	"github.com/smartcontractkit/chainlink-ton/pkg/fees"
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller"
+	"github.com/smartcontractkit/chainlink-ton/pkg/monitor"
 	"github.com/smartcontractkit/chainlink-ton/pkg/txm"
 )
 
 // Name of the chain family (e.g., "ethereum", "solana", "ton")
 const ChainFamilyName = "ton"
 
+// DefaultConfigSet holds the default settings of every component, configs are given copies of them.
 var DefaultConfigSet = Chain{
 	TransactionManager: &txm.DefaultConfigSet,
+	BalanceMonitor:     &monitor.DefaultConfigSet,
+	FeeEstimator:       &fees.DefaultConfigSet,
+	LogPoller:          &logpoller.DefaultConfigSet,
+	Transmitter:        &ocr.DefaultTransmitterConfig,
 	ClientTTL:          10 * time.Minute,
 }
 
 type Chain struct {
 	TransactionManager *txm.Config
+	BalanceMonitor     *monitor.Config
+	FeeEstimator       *fees.Config
+	LogPoller          *logpoller.Config
+	Transmitter        *ocr.TransmitterConfig
 	ClientTTL          time.Duration
 }
 
+// defaultChain returns the default settings with copies of every component, changing them leaves the
+// defaults of the components untouched.
+func defaultChain() Chain {
+	return Chain{
+		TransactionManager: copyOf(DefaultConfigSet.TransactionManager),
+		BalanceMonitor:     copyOf(DefaultConfigSet.BalanceMonitor),
+		FeeEstimator:       copyOf(DefaultConfigSet.FeeEstimator),
+		LogPoller:          copyOf(DefaultConfigSet.LogPoller),
+		Transmitter:        copyOf(DefaultConfigSet.Transmitter),
+		ClientTTL:          DefaultConfigSet.ClientTTL,
+	}
+}
+
+func copyOf[T any](v *T) *T {
+	c := *v
+	return &c
+}
+
 type Node struct {
 	Name *string
 	URL  *config.URL
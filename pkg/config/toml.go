@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/pelletier/go-toml/v2"
@@ -31,7 +32,8 @@ func NewDecodedTOMLConfig(rawConfig string) (*TOMLConfig, error) {
 	d := toml.NewDecoder(strings.NewReader(rawConfig))
 	d.DisallowUnknownFields()
 
-	var cfg TOMLConfig
+	// components are decoded onto the defaults, the settings left out keep their default
+	cfg := TOMLConfig{Chain: defaultChain()}
 	if err := d.Decode(&cfg); err != nil {
 		return &TOMLConfig{}, fmt.Errorf("failed to decode config toml: %w:\n\t%s", err, rawConfig)
 	}
@@ -49,8 +51,24 @@ func NewDecodedTOMLConfig(rawConfig string) (*TOMLConfig, error) {
 }
 
 func (c *TOMLConfig) SetDefaults() {
+	defaults := defaultChain()
 	if c.TransactionManager == nil {
-		c.TransactionManager = DefaultConfigSet.TransactionManager
+		c.TransactionManager = defaults.TransactionManager
+	}
+	if c.BalanceMonitor == nil {
+		c.BalanceMonitor = defaults.BalanceMonitor
+	}
+	if c.FeeEstimator == nil {
+		c.FeeEstimator = defaults.FeeEstimator
+	}
+	if c.LogPoller == nil {
+		c.LogPoller = defaults.LogPoller
+	}
+	if c.Transmitter == nil {
+		c.Transmitter = defaults.Transmitter
+	}
+	if c.ClientTTL == 0 {
+		c.ClientTTL = defaults.ClientTTL
 	}
 
 	// Set network name full defaults
@@ -87,9 +105,33 @@ func NodeStatus(n *Node, id string) (relaytypes.NodeStatus, error) {
 }
 
 func setFromChain(c, f *Chain) {
-	if f.TransactionManager != nil {
-		c.TransactionManager = f.TransactionManager
+	setFromComponent(&c.TransactionManager, f.TransactionManager, DefaultConfigSet.TransactionManager)
+	setFromComponent(&c.BalanceMonitor, f.BalanceMonitor, DefaultConfigSet.BalanceMonitor)
+	setFromComponent(&c.FeeEstimator, f.FeeEstimator, DefaultConfigSet.FeeEstimator)
+	setFromComponent(&c.LogPoller, f.LogPoller, DefaultConfigSet.LogPoller)
+	setFromComponent(&c.Transmitter, f.Transmitter, DefaultConfigSet.Transmitter)
+	if f.ClientTTL != 0 {
+		c.ClientTTL = f.ClientTTL
+	}
+}
+
+// setFromComponent sets the settings of a component that are set in f, not zero, on a copy of c, or of the
+// defaults when c is not set. c never shares its settings with f or the defaults.
+func setFromComponent[T any](c **T, f, defaults *T) {
+	if f == nil {
+		return
+	}
+	merged := *defaults
+	if *c != nil {
+		merged = **c
+	}
+	dst, src := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(f).Elem()
+	for i := range src.NumField() {
+		if !src.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
 	}
+	*c = &merged
 }
 
 func (c *TOMLConfig) ValidateConfig() (err error) {
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ccip/ocr"
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller"
+	"github.com/smartcontractkit/chainlink-ton/pkg/txm"
+)
+
+const testNodes = `
+ChainID = '-239'
+[[Nodes]]
+Name = 'primary'
+URL = 'http://localhost:8000/global.config.json'
+`
+
+func TestNewDecodedTOMLConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		txm         func(cfg txm.Config) txm.Config
+		logPoller   func(cfg logpoller.Config) logpoller.Config
+		transmitter func(cfg ocr.TransmitterConfig) ocr.TransmitterConfig
+	}{
+		{name: "no components"},
+		{
+			name: "settings left out keep their default",
+			raw:  "[TransactionManager]\nMaxBatchSize = 7\n[LogPoller]\nMaxBlockRange = 10\n",
+			txm:  func(cfg txm.Config) txm.Config { cfg.MaxBatchSize = 7; return cfg },
+			logPoller: func(cfg logpoller.Config) logpoller.Config {
+				cfg.MaxBlockRange = 10
+				return cfg
+			},
+		},
+		{
+			name: "zero settings override the default",
+			raw:  "[TransactionManager]\nAmountFallbackNano = 0\n[Transmitter]\nAutoAmount = false\n",
+			txm:  func(cfg txm.Config) txm.Config { cfg.AmountFallbackNano = 0; return cfg },
+			transmitter: func(cfg ocr.TransmitterConfig) ocr.TransmitterConfig {
+				cfg.AutoAmount = false
+				return cfg
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := NewDecodedTOMLConfig(testNodes + tc.raw)
+			require.NoError(t, err)
+
+			expectedTxm, expectedLogPoller, expectedTransmitter := txm.DefaultConfigSet, logpoller.DefaultConfigSet, ocr.DefaultTransmitterConfig
+			if tc.txm != nil {
+				expectedTxm = tc.txm(expectedTxm)
+			}
+			if tc.logPoller != nil {
+				expectedLogPoller = tc.logPoller(expectedLogPoller)
+			}
+			if tc.transmitter != nil {
+				expectedTransmitter = tc.transmitter(expectedTransmitter)
+			}
+			require.Equal(t, expectedTxm, *cfg.TransactionManager)
+			require.Equal(t, expectedLogPoller, *cfg.LogPoller)
+			require.Equal(t, expectedTransmitter, *cfg.Transmitter)
+			require.Equal(t, DefaultConfigSet.ClientTTL, cfg.ClientTTL)
+
+			// the defaults are copied, not shared
+			require.NotSame(t, DefaultConfigSet.TransactionManager, cfg.TransactionManager)
+			require.NotSame(t, DefaultConfigSet.FeeEstimator, cfg.FeeEstimator)
+			require.NotSame(t, DefaultConfigSet.BalanceMonitor, cfg.BalanceMonitor)
+		})
+	}
+}
+
+func TestTOMLConfig_SetFrom(t *testing.T) {
+	var c TOMLConfig
+	c.SetDefaults()
+	c.TransactionManager.MaxBatchSize = 7
+	require.Equal(t, uint(32), txm.DefaultConfigSet.MaxBatchSize)
+
+	f := TOMLConfig{Chain: Chain{
+		TransactionManager: &txm.Config{DrainTimeout: time.Minute},
+		LogPoller:          &logpoller.Config{PageSize: 5},
+		ClientTTL:          time.Hour,
+	}}
+	c.SetFrom(&f)
+
+	expectedTxm := txm.DefaultConfigSet
+	expectedTxm.MaxBatchSize, expectedTxm.DrainTimeout = 7, time.Minute
+	require.Equal(t, expectedTxm, *c.TransactionManager)
+	expectedLogPoller := logpoller.DefaultConfigSet
+	expectedLogPoller.PageSize = 5
+	require.Equal(t, expectedLogPoller, *c.LogPoller)
+	require.Equal(t, ocr.DefaultTransmitterConfig, *c.Transmitter)
+	require.Equal(t, time.Hour, c.ClientTTL)
+	require.NotSame(t, f.TransactionManager, c.TransactionManager)
+}
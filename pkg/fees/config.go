@@ -0,0 +1,13 @@
+package fees
+
+import (
+	"time"
+)
+
+type Config struct {
+	RefreshPeriod time.Duration // How often the fee prices are read from the masterchain config
+}
+
+var DefaultConfigSet = Config{
+	RefreshPeriod: 5 * time.Minute,
+}
@@ -1,9 +1,215 @@
 package fees
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/services"
+)
+
+var ErrPricesUnavailable = errors.New("fee prices not loaded yet")
 
 type Estimator interface {
 	Start(context.Context) error
 	Close() error
-	// TODO(NONEVM-1460): add remaining interface functions required for fee calculation
+	// Prices returns the latest fee prices of a workchain.
+	Prices(workchain int32) (Prices, error)
+	// Estimate returns the fees expected for sending and executing a message.
+	Estimate(msg Message) (Estimate, error)
+}
+
+// Prices are the fee prices of a workchain, read from the masterchain config.
+type Prices struct {
+	Gas     GasPrices
+	Forward ForwardPrices
+	Storage StoragePrices
+}
+
+// Message describes a message to estimate the fees for.
+type Message struct {
+	Workchain       int32         // workchain of the sender, forward fees are charged with its prices
+	DestWorkchain   int32         // workchain of the destination, compute fees are charged with its prices
+	Body            *cell.Cell    // Optional: body of the message
+	StateInit       *cell.Cell    // Optional: state init deployed by the message
+	GasUsed         uint64        // gas expected to be used by the destination, 0 charges the flat gas price
+	StorageDuration time.Duration // Optional: how long the storage fees of the state init are estimated for
+}
+
+// Estimate is the breakdown of the fees expected for a message, in nanotons.
+type Estimate struct {
+	ComputeFee tlb.Coins // compute phase of the destination
+	ForwardFee tlb.Coins // forwarding the message from the sender
+	StorageFee tlb.Coins // storing the state init for the storage duration
+}
+
+// Total returns the sum of the estimated fees.
+func (e Estimate) Total() tlb.Coins {
+	total := new(big.Int).Add(e.ComputeFee.Nano(), e.ForwardFee.Nano())
+	return tlb.FromNanoTON(total.Add(total, e.StorageFee.Nano()))
+}
+
+// MessageSize returns the number of bits and distinct cells of the given trees, as counted for fees.
+func MessageSize(roots ...*cell.Cell) (bits uint64, cells uint64) {
+	seen := map[string]struct{}{}
+	var visit func(c *cell.Cell)
+	visit = func(c *cell.Cell) {
+		key := string(c.Hash())
+		if _, exists := seen[key]; exists {
+			return
+		}
+		seen[key] = struct{}{}
+
+		bits += uint64(c.BitsSize())
+		cells++
+		for i := 0; i < int(c.RefsNum()); i++ { //nolint:gosec // a cell has at most 4 refs
+			visit(c.MustPeekRef(i))
+		}
+	}
+	for _, root := range roots {
+		if root != nil {
+			visit(root)
+		}
+	}
+	return bits, cells
+}
+
+var _ Estimator = (*ConfigEstimator)(nil)
+
+// ConfigEstimator estimates fees from the prices of the masterchain config params 18, 20, 21, 24 and 25,
+// which it refreshes periodically so that config changes are picked up.
+type ConfigEstimator struct {
+	services.Service
+	eng *services.Engine
+
+	lggr   logger.SugaredLogger
+	cfg    Config
+	client ton.APIClientWrapped
+
+	lock        sync.RWMutex
+	masterchain *Prices
+	basechain   *Prices
+}
+
+// NewEstimator creates a ConfigEstimator reading the config params through client.
+func NewEstimator(lggr logger.Logger, cfg Config, client ton.APIClientWrapped) *ConfigEstimator {
+	e := &ConfigEstimator{
+		lggr:   logger.Sugared(lggr),
+		cfg:    cfg,
+		client: client,
+	}
+	e.Service, e.eng = services.Config{
+		Name:  "TONFeeEstimator",
+		Start: e.start,
+	}.NewServiceEngine(lggr)
+	return e
+}
+
+func (e *ConfigEstimator) start(ctx context.Context) error {
+	// prices are retried on every refresh, the estimator starts without them if the first read fails
+	if err := e.refresh(ctx); err != nil {
+		e.lggr.Errorw("failed to load fee prices", "err", err)
+	}
+	e.eng.GoTick(services.NewTicker(e.cfg.RefreshPeriod), func(ctx context.Context) {
+		if err := e.refresh(ctx); err != nil {
+			e.lggr.Errorw("failed to refresh fee prices", "err", err)
+		}
+	})
+	return nil
+}
+
+func (e *ConfigEstimator) Prices(workchain int32) (Prices, error) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	prices := e.basechain
+	if workchain == -1 {
+		prices = e.masterchain
+	}
+	if prices == nil {
+		return Prices{}, ErrPricesUnavailable
+	}
+	return *prices, nil
+}
+
+func (e *ConfigEstimator) Estimate(msg Message) (Estimate, error) {
+	src, err := e.Prices(msg.Workchain)
+	if err != nil {
+		return Estimate{}, err
+	}
+	dst, err := e.Prices(msg.DestWorkchain)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	bits, cells := MessageSize(msg.Body, msg.StateInit)
+	estimate := Estimate{
+		ComputeFee: tlb.FromNanoTON(dst.Gas.ComputeFee(msg.GasUsed)),
+		ForwardFee: tlb.FromNanoTON(src.Forward.ForwardFee(bits, cells)),
+		StorageFee: tlb.ZeroCoins,
+	}
+	if msg.StateInit != nil && msg.StorageDuration > 0 {
+		initBits, initCells := MessageSize(msg.StateInit)
+		seconds := uint64(msg.StorageDuration / time.Second)
+		estimate.StorageFee = tlb.FromNanoTON(dst.Storage.StorageFee(msg.DestWorkchain, initBits, initCells, seconds))
+	}
+	return estimate, nil
+}
+
+// refresh reads the fee config params of the latest masterchain block.
+func (e *ConfigEstimator) refresh(ctx context.Context) error {
+	block, err := e.client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	config, err := e.client.GetBlockchainConfig(ctx, block,
+		ConfigParamStoragePrices,
+		ConfigParamMasterchainGasPrices, ConfigParamBasechainGasPrices,
+		ConfigParamMasterchainForwardPrices, ConfigParamBasechainForwardPrices)
+	if err != nil {
+		return fmt.Errorf("failed to get blockchain config: %w", err)
+	}
+
+	storage, err := ParseStoragePrices(config.Get(ConfigParamStoragePrices), uint32(time.Now().Unix())) //nolint:gosec // unix time fits until 2106
+	if err != nil {
+		return fmt.Errorf("failed to parse config param %d: %w", ConfigParamStoragePrices, err)
+	}
+	masterchain, err := parsePrices(config, ConfigParamMasterchainGasPrices, ConfigParamMasterchainForwardPrices, storage)
+	if err != nil {
+		return err
+	}
+	basechain, err := parsePrices(config, ConfigParamBasechainGasPrices, ConfigParamBasechainForwardPrices, storage)
+	if err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.basechain != nil && (*e.basechain != *basechain || *e.masterchain != *masterchain) {
+		e.lggr.Infow("fee prices changed", "masterchain", masterchain, "basechain", basechain)
+	}
+	e.masterchain, e.basechain = masterchain, basechain
+	return nil
+}
+
+func parsePrices(config *ton.BlockchainConfig, gasParam, forwardParam int32, storage StoragePrices) (*Prices, error) {
+	gas, err := ParseGasPrices(config.Get(gasParam))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", gasParam, err)
+	}
+	forward, err := ParseForwardPrices(config.Get(forwardParam))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", forwardParam, err)
+	}
+	return &Prices{Gas: gas, Forward: forward, Storage: storage}, nil
 }
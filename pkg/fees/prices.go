@@ -0,0 +1,226 @@
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Masterchain config params holding the fee prices.
+const (
+	ConfigParamStoragePrices            int32 = 18
+	ConfigParamMasterchainGasPrices     int32 = 20
+	ConfigParamBasechainGasPrices       int32 = 21
+	ConfigParamMasterchainForwardPrices int32 = 24
+	ConfigParamBasechainForwardPrices   int32 = 25
+)
+
+// config params prices are expressed in 1/2^16 of a nanoton
+const priceShift = 16
+
+// TL-B constructor tags of the fee prices
+const (
+	tagStoragePrices    = 0xcc
+	tagGasPrices        = 0xdd
+	tagGasPricesExt     = 0xde
+	tagGasFlatPfx       = 0xd1
+	tagMsgForwardPrices = 0xea
+)
+
+var ErrUnexpectedTag = errors.New("unexpected constructor tag")
+
+// GasPrices are the gas limits and prices of a workchain, config param 20 for the masterchain and 21 for the basechain.
+type GasPrices struct {
+	FlatGasLimit    uint64 // gas always charged FlatGasPrice, even when less is used
+	FlatGasPrice    uint64 // price of the flat gas, in nanotons
+	GasPrice        uint64 // price of a gas unit, in 1/2^16 nanotons
+	GasLimit        uint64 // max gas of a transaction
+	SpecialGasLimit uint64 // max gas of a transaction of a special account
+	GasCredit       uint64 // gas credited to external messages before they are accepted
+	BlockGasLimit   uint64
+	FreezeDueLimit  uint64
+	DeleteDueLimit  uint64
+}
+
+// ComputeFee returns the fee charged in nanotons for a compute phase using gasUsed gas.
+func (p GasPrices) ComputeFee(gasUsed uint64) *big.Int {
+	if gasUsed <= p.FlatGasLimit {
+		return new(big.Int).SetUint64(p.FlatGasPrice)
+	}
+	fee := new(big.Int).SetUint64(gasUsed - p.FlatGasLimit)
+	fee.Mul(fee, new(big.Int).SetUint64(p.GasPrice))
+	fee = shiftCeil(fee)
+	return fee.Add(fee, new(big.Int).SetUint64(p.FlatGasPrice))
+}
+
+// ParseGasPrices parses a GasLimitsPrices config param.
+func ParseGasPrices(c *cell.Cell) (GasPrices, error) {
+	var p GasPrices
+	s := c.BeginParse()
+
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return p, fmt.Errorf("failed to load gas prices tag: %w", err)
+	}
+	if tag == tagGasFlatPfx {
+		if p.FlatGasLimit, err = s.LoadUInt(64); err != nil {
+			return p, fmt.Errorf("failed to load flat gas limit: %w", err)
+		}
+		if p.FlatGasPrice, err = s.LoadUInt(64); err != nil {
+			return p, fmt.Errorf("failed to load flat gas price: %w", err)
+		}
+		if tag, err = s.LoadUInt(8); err != nil {
+			return p, fmt.Errorf("failed to load gas prices tag: %w", err)
+		}
+	}
+	if tag != tagGasPrices && tag != tagGasPricesExt {
+		return p, fmt.Errorf("%w for gas prices: %#x", ErrUnexpectedTag, tag)
+	}
+
+	fields := []*uint64{&p.GasPrice, &p.GasLimit, &p.GasCredit, &p.BlockGasLimit, &p.FreezeDueLimit, &p.DeleteDueLimit}
+	if tag == tagGasPricesExt {
+		fields = []*uint64{&p.GasPrice, &p.GasLimit, &p.SpecialGasLimit, &p.GasCredit, &p.BlockGasLimit, &p.FreezeDueLimit, &p.DeleteDueLimit}
+	}
+	for _, field := range fields {
+		if *field, err = s.LoadUInt(64); err != nil {
+			return p, fmt.Errorf("failed to load gas prices: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// ForwardPrices are the prices of sending a message from a workchain, config param 24 for the
+// masterchain and 25 for the basechain.
+type ForwardPrices struct {
+	LumpPrice      uint64 // base price of a message, in nanotons
+	BitPrice       uint64 // price of a bit of the message, in 1/2^16 nanotons
+	CellPrice      uint64 // price of a cell of the message, in 1/2^16 nanotons
+	IHRPriceFactor uint32
+	FirstFrac      uint16 // share of the forward fee taken by the first transit, in 1/2^16
+	NextFrac       uint16
+}
+
+// ForwardFee returns the fee charged in nanotons to send a message whose body and state init
+// take bits and cells, the root cell of the message not counted.
+func (p ForwardPrices) ForwardFee(bits, cells uint64) *big.Int {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(p.BitPrice), new(big.Int).SetUint64(bits))
+	fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(p.CellPrice), new(big.Int).SetUint64(cells)))
+	fee = shiftCeil(fee)
+	return fee.Add(fee, new(big.Int).SetUint64(p.LumpPrice))
+}
+
+// ParseForwardPrices parses a MsgForwardPrices config param.
+func ParseForwardPrices(c *cell.Cell) (ForwardPrices, error) {
+	var p ForwardPrices
+	s := c.BeginParse()
+
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return p, fmt.Errorf("failed to load forward prices tag: %w", err)
+	}
+	if tag != tagMsgForwardPrices {
+		return p, fmt.Errorf("%w for forward prices: %#x", ErrUnexpectedTag, tag)
+	}
+
+	for _, field := range []*uint64{&p.LumpPrice, &p.BitPrice, &p.CellPrice} {
+		if *field, err = s.LoadUInt(64); err != nil {
+			return p, fmt.Errorf("failed to load forward prices: %w", err)
+		}
+	}
+	ihrPriceFactor, err := s.LoadUInt(32)
+	if err != nil {
+		return p, fmt.Errorf("failed to load ihr price factor: %w", err)
+	}
+	firstFrac, err := s.LoadUInt(16)
+	if err != nil {
+		return p, fmt.Errorf("failed to load first frac: %w", err)
+	}
+	nextFrac, err := s.LoadUInt(16)
+	if err != nil {
+		return p, fmt.Errorf("failed to load next frac: %w", err)
+	}
+	p.IHRPriceFactor = uint32(ihrPriceFactor) //nolint:gosec // loaded from 32 bits
+	p.FirstFrac = uint16(firstFrac)           //nolint:gosec // loaded from 16 bits
+	p.NextFrac = uint16(nextFrac)             //nolint:gosec // loaded from 16 bits
+	return p, nil
+}
+
+// StoragePrices are the prices of storing account state, in effect from UTimeSince, config param 18.
+type StoragePrices struct {
+	UTimeSince    uint32
+	BitPricePS    uint64 // price of storing a bit for a second on the basechain, in 1/2^16 nanotons
+	CellPricePS   uint64 // price of storing a cell for a second on the basechain, in 1/2^16 nanotons
+	MCBitPricePS  uint64 // price of storing a bit for a second on the masterchain, in 1/2^16 nanotons
+	MCCellPricePS uint64 // price of storing a cell for a second on the masterchain, in 1/2^16 nanotons
+}
+
+// StorageFee returns the fee charged in nanotons for storing bits and cells on a workchain for seconds.
+func (p StoragePrices) StorageFee(workchain int32, bits, cells, seconds uint64) *big.Int {
+	bitPrice, cellPrice := p.BitPricePS, p.CellPricePS
+	if workchain == -1 {
+		bitPrice, cellPrice = p.MCBitPricePS, p.MCCellPricePS
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(bitPrice), new(big.Int).SetUint64(bits))
+	fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(cellPrice), new(big.Int).SetUint64(cells)))
+	fee.Mul(fee, new(big.Int).SetUint64(seconds))
+	return shiftCeil(fee)
+}
+
+// ParseStoragePrices parses config param 18 and returns the prices currently in effect, the ones with
+// the latest UTimeSince not after now.
+func ParseStoragePrices(c *cell.Cell, now uint32) (StoragePrices, error) {
+	var current StoragePrices
+
+	dict := c.AsDict(32)
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return current, fmt.Errorf("failed to load storage prices dict: %w", err)
+	}
+
+	found := false
+	for _, kv := range kvs {
+		p, err := parseStoragePrices(kv.Value)
+		if err != nil {
+			return current, err
+		}
+		if p.UTimeSince <= now && (!found || p.UTimeSince > current.UTimeSince) {
+			current, found = p, true
+		}
+	}
+	if !found {
+		return current, errors.New("no storage prices in effect")
+	}
+	return current, nil
+}
+
+func parseStoragePrices(s *cell.Slice) (StoragePrices, error) {
+	var p StoragePrices
+
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return p, fmt.Errorf("failed to load storage prices tag: %w", err)
+	}
+	if tag != tagStoragePrices {
+		return p, fmt.Errorf("%w for storage prices: %#x", ErrUnexpectedTag, tag)
+	}
+	utimeSince, err := s.LoadUInt(32)
+	if err != nil {
+		return p, fmt.Errorf("failed to load storage prices utime: %w", err)
+	}
+	p.UTimeSince = uint32(utimeSince) //nolint:gosec // loaded from 32 bits
+
+	for _, field := range []*uint64{&p.BitPricePS, &p.CellPricePS, &p.MCBitPricePS, &p.MCCellPricePS} {
+		if *field, err = s.LoadUInt(64); err != nil {
+			return p, fmt.Errorf("failed to load storage prices: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// shiftCeil converts an amount in 1/2^16 nanotons to nanotons, rounding up.
+func shiftCeil(v *big.Int) *big.Int {
+	v.Add(v, big.NewInt(1<<priceShift-1))
+	return v.Rsh(v, priceShift)
+}
@@ -0,0 +1,120 @@
+package fees
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// basechain prices of the mainnet config
+func basechainGasPricesCell() *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(tagGasFlatPfx, 8).
+		MustStoreUInt(100, 64).   // flat_gas_limit
+		MustStoreUInt(40000, 64). // flat_gas_price
+		MustStoreUInt(tagGasPricesExt, 8).
+		MustStoreUInt(26214400, 64).   // gas_price, 400 nanotons per gas
+		MustStoreUInt(1000000, 64).    // gas_limit
+		MustStoreUInt(1000000, 64).    // special_gas_limit
+		MustStoreUInt(10000, 64).      // gas_credit
+		MustStoreUInt(10000000, 64).   // block_gas_limit
+		MustStoreUInt(100000000, 64).  // freeze_due_limit
+		MustStoreUInt(1000000000, 64). // delete_due_limit
+		EndCell()
+}
+
+func TestGasPrices(t *testing.T) {
+	prices, err := ParseGasPrices(basechainGasPricesCell())
+	require.NoError(t, err)
+	require.Equal(t, GasPrices{
+		FlatGasLimit:    100,
+		FlatGasPrice:    40000,
+		GasPrice:        26214400,
+		GasLimit:        1000000,
+		SpecialGasLimit: 1000000,
+		GasCredit:       10000,
+		BlockGasLimit:   10000000,
+		FreezeDueLimit:  100000000,
+		DeleteDueLimit:  1000000000,
+	}, prices)
+
+	testCases := []struct {
+		name     string
+		gasUsed  uint64
+		expected int64
+	}{
+		{name: "below flat gas limit", gasUsed: 50, expected: 40000},
+		{name: "flat gas limit", gasUsed: 100, expected: 40000},
+		{name: "above flat gas limit", gasUsed: 1000, expected: 40000 + 900*400},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, big.NewInt(tc.expected), prices.ComputeFee(tc.gasUsed))
+		})
+	}
+}
+
+func TestForwardPrices(t *testing.T) {
+	c := cell.BeginCell().
+		MustStoreUInt(tagMsgForwardPrices, 8).
+		MustStoreUInt(400000, 64).     // lump_price
+		MustStoreUInt(26214400, 64).   // bit_price
+		MustStoreUInt(2621440000, 64). // cell_price
+		MustStoreUInt(98304, 32).      // ihr_price_factor
+		MustStoreUInt(21845, 16).      // first_frac
+		MustStoreUInt(21845, 16).      // next_frac
+		EndCell()
+
+	prices, err := ParseForwardPrices(c)
+	require.NoError(t, err)
+	require.Equal(t, uint64(400000), prices.LumpPrice)
+	require.Equal(t, uint16(21845), prices.FirstFrac)
+
+	require.Equal(t, big.NewInt(400000), prices.ForwardFee(0, 0))
+	require.Equal(t, big.NewInt(400000+1023*400+40000), prices.ForwardFee(1023, 1))
+	// fractional nanotons are rounded up
+	require.Equal(t, big.NewInt(1), ForwardPrices{BitPrice: 1}.ForwardFee(1, 0))
+}
+
+func TestParseGasPricesUnexpectedTag(t *testing.T) {
+	_, err := ParseGasPrices(cell.BeginCell().MustStoreUInt(0xab, 8).EndCell())
+	require.ErrorIs(t, err, ErrUnexpectedTag)
+}
+
+func TestStoragePrices(t *testing.T) {
+	storagePrices := func(utimeSince uint64, bitPrice uint64) *cell.Cell {
+		return cell.BeginCell().
+			MustStoreUInt(tagStoragePrices, 8).
+			MustStoreUInt(utimeSince, 32).
+			MustStoreUInt(bitPrice, 64). // bit_price_ps
+			MustStoreUInt(500, 64).      // cell_price_ps
+			MustStoreUInt(1000, 64).     // mc_bit_price_ps
+			MustStoreUInt(500000, 64).   // mc_cell_price_ps
+			EndCell()
+	}
+
+	dict := cell.NewDict(32)
+	require.NoError(t, dict.SetIntKey(big.NewInt(0), storagePrices(0, 1)))
+	require.NoError(t, dict.SetIntKey(big.NewInt(1000), storagePrices(1000, 2)))
+	require.NoError(t, dict.SetIntKey(big.NewInt(2000), storagePrices(2000, 3)))
+
+	prices, err := ParseStoragePrices(dict.AsCell(), 1500)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1000), prices.UTimeSince)
+	require.Equal(t, uint64(2), prices.BitPricePS)
+
+	// 1 bit and 1 cell for 2^16 seconds
+	require.Equal(t, big.NewInt(2+500), prices.StorageFee(0, 1, 1, 1<<16))
+	require.Equal(t, big.NewInt(1000+500000), prices.StorageFee(-1, 1, 1, 1<<16))
+}
+
+func TestMessageSize(t *testing.T) {
+	leaf := cell.BeginCell().MustStoreUInt(1, 8).EndCell()
+	root := cell.BeginCell().MustStoreUInt(1, 32).MustStoreRef(leaf).MustStoreRef(leaf).EndCell()
+
+	bits, cells := MessageSize(root, nil, leaf)
+	require.Equal(t, uint64(40), bits)
+	require.Equal(t, uint64(2), cells)
+}
@@ -0,0 +1,213 @@
+package logpoller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller/types"
+)
+
+// Collector loads the external messages sent by a set of addresses in a masterchain block range
+// (prevBlock, toBlock].
+type Collector interface {
+	BackfillForAddresses(ctx context.Context, addresses []*address.Address, prevBlock *ton.BlockIDExt, toBlock *ton.BlockIDExt) ([]types.MsgWithCtx, error)
+}
+
+var (
+	_ Collector = (*LogCollector)(nil)
+	_ Collector = (*BlockCollector)(nil)
+)
+
+// BlockCollector walks every masterchain block of a range and the shard blocks it commits, loading only
+// the transactions of watched addresses. Its cost grows with the number of blocks rather than the number
+// of addresses, and the messages it returns carry the blocks they were included in.
+type BlockCollector struct {
+	lggr     logger.SugaredLogger
+	client   ton.APIClientWrapped
+	pageSize uint32 // Number of transaction IDs to fetch per block listing call
+}
+
+func NewBlockCollector(
+	client ton.APIClientWrapped,
+	lggr logger.Logger,
+	pageSize uint32,
+) *BlockCollector {
+	return &BlockCollector{
+		lggr:     logger.Sugared(lggr),
+		client:   client,
+		pageSize: pageSize,
+	}
+}
+
+// shardKey identifies a shard across its blocks
+type shardKey struct {
+	workchain int32
+	shard     int64
+}
+
+func keyOf(block *ton.BlockIDExt) shardKey {
+	return shardKey{workchain: block.Workchain, shard: block.Shard}
+}
+
+func shardHex(shard int64) string {
+	return fmt.Sprintf("%x", uint64(shard)) //nolint:gosec // shard IDs are conventionally printed unsigned
+}
+
+// shardBlockKey identifies a block of a shard
+type shardBlockKey struct {
+	shardKey
+	seqNo uint32
+}
+
+// BackfillForAddresses loads the external messages sent by the addresses in the masterchain blocks
+// (prevBlock, toBlock] and the shard blocks they commit. prevBlock is required, as walking from genesis
+// is not practical.
+func (bc *BlockCollector) BackfillForAddresses(ctx context.Context, addresses []*address.Address, prevBlock *ton.BlockIDExt, toBlock *ton.BlockIDExt) ([]types.MsgWithCtx, error) {
+	if prevBlock == nil {
+		return nil, errors.New("block collector needs a previous block to walk from")
+	}
+	if prevBlock.SeqNo >= toBlock.SeqNo {
+		return nil, fmt.Errorf("prevBlock %d is not before toBlock %d", prevBlock.SeqNo, toBlock.SeqNo)
+	}
+
+	watched := make(map[string]struct{}, len(addresses))
+	for _, addr := range addresses {
+		watched[addr.StringRaw()] = struct{}{}
+	}
+
+	// shard blocks committed up to prevBlock were already processed
+	prevShards, err := bc.client.GetBlockShardsInfo(ctx, prevBlock)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockShardsInfo for seq %d: %w", prevBlock.SeqNo, err)
+	}
+	lastSeen := make(map[shardKey]uint32, len(prevShards))
+	for _, shard := range prevShards {
+		lastSeen[keyOf(shard)] = shard.SeqNo
+	}
+
+	var allMsgs []types.MsgWithCtx
+	for seqNo := prevBlock.SeqNo + 1; seqNo <= toBlock.SeqNo; seqNo++ {
+		master := toBlock
+		if seqNo != toBlock.SeqNo {
+			master, err = bc.client.LookupBlock(ctx, toBlock.Workchain, toBlock.Shard, seqNo)
+			if err != nil {
+				return nil, fmt.Errorf("LookupBlock for seq %d: %w", seqNo, err)
+			}
+		}
+
+		shards, err := bc.client.GetBlockShardsInfo(ctx, master)
+		if err != nil {
+			return nil, fmt.Errorf("GetBlockShardsInfo for seq %d: %w", seqNo, err)
+		}
+		blocks := []*ton.BlockIDExt{master}
+		seen := make(map[shardBlockKey]struct{})
+		for _, shard := range shards {
+			notSeen, err := bc.notSeenShardBlocks(ctx, lastSeen, seen, shard)
+			if err != nil {
+				return nil, fmt.Errorf("shard blocks of seq %d: %w", seqNo, err)
+			}
+			blocks = append(blocks, notSeen...)
+		}
+		for _, shard := range shards {
+			lastSeen[keyOf(shard)] = shard.SeqNo
+		}
+
+		for _, block := range blocks {
+			msgs, err := bc.blockMessages(ctx, master.SeqNo, block, watched)
+			if err != nil {
+				return nil, err
+			}
+			allMsgs = append(allMsgs, msgs...)
+		}
+	}
+	return allMsgs, nil
+}
+
+// notSeenShardBlocks returns the blocks of a shard committed since the last seen one, oldest first,
+// following parents across splits and merges.
+func (bc *BlockCollector) notSeenShardBlocks(ctx context.Context, lastSeen map[shardKey]uint32, seen map[shardBlockKey]struct{}, shard *ton.BlockIDExt) ([]*ton.BlockIDExt, error) {
+	if seqNo, ok := lastSeen[keyOf(shard)]; ok && shard.SeqNo <= seqNo {
+		return nil, nil
+	}
+	id := shardBlockKey{shardKey: keyOf(shard), seqNo: shard.SeqNo}
+	if _, ok := seen[id]; ok {
+		// reached through both parents of a merge
+		return nil, nil
+	}
+	seen[id] = struct{}{}
+
+	block, err := bc.client.GetBlockData(ctx, shard)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockData for shard %s seq %d: %w", shardHex(shard.Shard), shard.SeqNo, err)
+	}
+	parents, err := block.BlockInfo.GetParentBlocks()
+	if err != nil {
+		return nil, fmt.Errorf("parents of shard %s seq %d: %w", shardHex(shard.Shard), shard.SeqNo, err)
+	}
+
+	var blocks []*ton.BlockIDExt
+	for _, parent := range parents {
+		notSeen, err := bc.notSeenShardBlocks(ctx, lastSeen, seen, parent)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, notSeen...)
+	}
+	return append(blocks, shard), nil
+}
+
+// blockMessages returns the external messages sent by watched addresses in a block
+func (bc *BlockCollector) blockMessages(ctx context.Context, masterSeqNo uint32, block *ton.BlockIDExt, watched map[string]struct{}) ([]types.MsgWithCtx, error) {
+	var msgs []types.MsgWithCtx
+	var blockTime time.Time
+	var after *ton.TransactionID3
+	for more := true; more; {
+		var txs []ton.TransactionShortInfo
+		var err error
+		txs, more, err = bc.client.GetBlockTransactionsV2(ctx, block, bc.pageSize, after)
+		if err != nil {
+			return nil, fmt.Errorf("GetBlockTransactionsV2 for shard %s seq %d: %w", shardHex(block.Shard), block.SeqNo, err)
+		}
+		if len(txs) == 0 {
+			break
+		}
+		after = txs[len(txs)-1].ID3()
+
+		for _, info := range txs {
+			addr := address.NewAddress(0, byte(block.Workchain), info.Account)
+			if _, ok := watched[addr.StringRaw()]; !ok {
+				continue
+			}
+			if blockTime.IsZero() {
+				data, err := bc.client.GetBlockData(ctx, block)
+				if err != nil {
+					return nil, fmt.Errorf("GetBlockData for shard %s seq %d: %w", shardHex(block.Shard), block.SeqNo, err)
+				}
+				blockTime = time.Unix(int64(data.BlockInfo.GenUtime), 0).UTC()
+			}
+
+			tx, err := bc.client.GetTransaction(ctx, block, addr, info.LT)
+			if err != nil {
+				return nil, fmt.Errorf("GetTransaction %s lt %d: %w", addr.String(), info.LT, err)
+			}
+			for _, msg := range externalMessages(tx) {
+				msg.MasterSeqNo = masterSeqNo
+				msg.Block = block
+				msg.BlockTime = blockTime
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+
+	if len(msgs) > 0 {
+		bc.lggr.Debugw("Loaded messages from block", "masterSeq", masterSeqNo, "shard", shardHex(block.Shard), "seq", block.SeqNo, "msgs", len(msgs))
+	}
+	return msgs, nil
+}
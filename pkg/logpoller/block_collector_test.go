@@ -0,0 +1,156 @@
+package logpoller
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+)
+
+const (
+	fullShard  = uint64(0x8000000000000000)
+	leftShard  = uint64(0x4000000000000000)
+	rightShard = uint64(0xc000000000000000)
+)
+
+// shardBlocks serves the headers of shard blocks, the other client methods are not implemented.
+type shardBlocks struct {
+	ton.APIClientWrapped
+	headers map[shardBlockKey]tlb.BlockHeader
+}
+
+func (c *shardBlocks) GetBlockData(_ context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+	header, ok := c.headers[shardBlockKey{shardKey: keyOf(block), seqNo: block.SeqNo}]
+	if !ok {
+		return nil, fmt.Errorf("block %s:%d not found", shardHex(block.Shard), block.SeqNo)
+	}
+	return &tlb.Block{BlockInfo: header}, nil
+}
+
+// shardBlock describes a block of the basechain and the seqnos of its parents, two after a merge.
+type shardBlock struct {
+	shard      uint64
+	seqNo      uint32
+	parents    []uint32
+	afterSplit bool
+}
+
+func (b shardBlock) id() *ton.BlockIDExt {
+	return &ton.BlockIDExt{Workchain: 0, Shard: int64(b.shard), SeqNo: b.seqNo} //nolint:gosec // shard IDs are stored signed
+}
+
+func (b shardBlock) header() tlb.BlockHeader {
+	var h tlb.BlockHeader
+	h.SeqNo = b.seqNo
+	h.Shard = tlb.ShardIdent{PrefixBits: int8(63 - bits.TrailingZeros64(b.shard)), ShardPrefix: b.shard & (b.shard - 1)} //nolint:gosec // at most 63
+	h.AfterSplit = b.afterSplit
+	h.AfterMerge = len(b.parents) == 2
+	h.PrevRef.Prev1 = tlb.ExtBlkRef{SeqNo: b.parents[0]}
+	if h.AfterMerge {
+		h.PrevRef.Prev2 = &tlb.ExtBlkRef{SeqNo: b.parents[1]}
+	}
+	return h
+}
+
+func TestBlockCollector_NotSeenShardBlocks(t *testing.T) {
+	testCases := []struct {
+		name      string
+		lastSeen  map[uint64]uint32
+		blocks    []shardBlock // blocks of the lite server, the committed ones last
+		committed int          // number of committed blocks
+		expected  []shardBlock
+	}{
+		{
+			name:      "no new block",
+			lastSeen:  map[uint64]uint32{fullShard: 10},
+			blocks:    []shardBlock{{shard: fullShard, seqNo: 10, parents: []uint32{9}}},
+			committed: 1,
+		},
+		{
+			name:     "blocks of one shard",
+			lastSeen: map[uint64]uint32{fullShard: 10},
+			blocks: []shardBlock{
+				{shard: fullShard, seqNo: 11, parents: []uint32{10}},
+				{shard: fullShard, seqNo: 12, parents: []uint32{11}},
+			},
+			committed: 1,
+			expected:  []shardBlock{{shard: fullShard, seqNo: 11}, {shard: fullShard, seqNo: 12}},
+		},
+		{
+			name:     "split",
+			lastSeen: map[uint64]uint32{fullShard: 10},
+			blocks: []shardBlock{
+				{shard: leftShard, seqNo: 11, parents: []uint32{10}, afterSplit: true},
+				{shard: leftShard, seqNo: 12, parents: []uint32{11}},
+				{shard: rightShard, seqNo: 11, parents: []uint32{10}, afterSplit: true},
+			},
+			committed: 2,
+			expected:  []shardBlock{{shard: leftShard, seqNo: 11}, {shard: leftShard, seqNo: 12}, {shard: rightShard, seqNo: 11}},
+		},
+		{
+			name:     "merge",
+			lastSeen: map[uint64]uint32{leftShard: 11, rightShard: 11},
+			blocks: []shardBlock{
+				{shard: leftShard, seqNo: 12, parents: []uint32{11}},
+				{shard: fullShard, seqNo: 13, parents: []uint32{12, 11}},
+			},
+			committed: 1,
+			expected:  []shardBlock{{shard: leftShard, seqNo: 12}, {shard: fullShard, seqNo: 13}},
+		},
+		{
+			name:     "split and merge within the range",
+			lastSeen: map[uint64]uint32{fullShard: 10},
+			blocks: []shardBlock{
+				{shard: leftShard, seqNo: 11, parents: []uint32{10}, afterSplit: true},
+				{shard: rightShard, seqNo: 11, parents: []uint32{10}, afterSplit: true},
+				{shard: rightShard, seqNo: 12, parents: []uint32{11}},
+				{shard: fullShard, seqNo: 13, parents: []uint32{11, 12}},
+			},
+			committed: 1,
+			expected:  []shardBlock{{shard: leftShard, seqNo: 11}, {shard: rightShard, seqNo: 11}, {shard: rightShard, seqNo: 12}, {shard: fullShard, seqNo: 13}},
+		},
+		{
+			name:     "parent reached from both children of a split",
+			lastSeen: map[uint64]uint32{fullShard: 10},
+			blocks: []shardBlock{
+				{shard: fullShard, seqNo: 11, parents: []uint32{10}},
+				{shard: leftShard, seqNo: 12, parents: []uint32{11}, afterSplit: true},
+				{shard: rightShard, seqNo: 12, parents: []uint32{11}, afterSplit: true},
+			},
+			committed: 2,
+			expected:  []shardBlock{{shard: fullShard, seqNo: 11}, {shard: leftShard, seqNo: 12}, {shard: rightShard, seqNo: 12}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &shardBlocks{headers: make(map[shardBlockKey]tlb.BlockHeader)}
+			for _, b := range tc.blocks {
+				client.headers[shardBlockKey{shardKey: keyOf(b.id()), seqNo: b.seqNo}] = b.header()
+			}
+			bc := NewBlockCollector(client, logger.Test(t), 16)
+			lastSeen := make(map[shardKey]uint32, len(tc.lastSeen))
+			for shard, seqNo := range tc.lastSeen {
+				lastSeen[keyOf(shardBlock{shard: shard}.id())] = seqNo
+			}
+
+			seen := make(map[shardBlockKey]struct{})
+			var actual []*ton.BlockIDExt
+			for _, b := range tc.blocks[len(tc.blocks)-tc.committed:] {
+				notSeen, err := bc.notSeenShardBlocks(t.Context(), lastSeen, seen, b.id())
+				require.NoError(t, err)
+				actual = append(actual, notSeen...)
+			}
+			var expected []*ton.BlockIDExt
+			for _, b := range tc.expected {
+				expected = append(expected, b.id())
+			}
+			require.Equal(t, expected, actual)
+		})
+	}
+}
@@ -4,12 +4,39 @@ import (
 	"time"
 )
 
+// CollectorMode selects how the log poller loads the messages of the watched addresses.
+type CollectorMode string
+
+const (
+	// CollectorModeAccount lists the transactions of every watched address, the cost grows with the number of addresses.
+	CollectorModeAccount CollectorMode = "account"
+	// CollectorModeBlock walks the masterchain and shard blocks, the cost grows with the number of blocks.
+	// Logs then carry the blocks they were included in.
+	CollectorModeBlock CollectorMode = "block"
+)
+
 type Config struct {
-	PollPeriod time.Duration
-	PageSize   uint32
+	Mode          CollectorMode // How messages are loaded by live polling, account when empty
+	PollPeriod    time.Duration // How often to poll for new blocks
+	PageSize      uint32        // Number of transactions to fetch per API call
+	PruneInterval time.Duration // Interval between prunes of expired logs and of the logs of unregistered filters
+	// Maximum number of masterchain blocks polled to catch up after a restart, the older missed blocks
+	// are skipped. 0 polls every missed block.
+	LookbackWindow uint32
+	// Maximum number of masterchain blocks loaded at once, each range is saved along with its logs before
+	// the next one is loaded. 0 loads every block up to the head at once.
+	MaxBlockRange uint32
+	// Number of masterchain blocks on top of a processed block before its logs are finalized. Blocks are
+	// polled as soon as they are produced, their logs are only returned to finalized queries once confirmed.
+	BlockConfirmations uint32
 }
 
 var DefaultConfigSet = Config{
-	PollPeriod: 3 * time.Second,
-	PageSize:   100,
+	Mode:               CollectorModeAccount,
+	PollPeriod:         3 * time.Second,
+	PageSize:           100,
+	PruneInterval:      time.Minute,
+	LookbackWindow:     blocksKept,
+	MaxBlockRange:      100,
+	BlockConfirmations: 10,
 }
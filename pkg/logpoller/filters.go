@@ -13,18 +13,41 @@ type Filters struct {
 	mu               sync.RWMutex
 	filtersByName    map[string]types.Filter
 	filtersByAddress map[string]map[uint32]struct{}
+	backfilling      map[int64]Backfill // backfills in progress by filter ID
+	backfills        uint64             // number of backfills taken, identifies each one
+}
+
+// Backfill is a backfill of the logs of a filter through head, taken by TakePendingBackfills.
+type Backfill struct {
+	Filter types.Filter
+	Head   uint32
+	job    uint64
 }
 
 func newFilters() *Filters {
 	return &Filters{
 		filtersByName:    make(map[string]types.Filter),
 		filtersByAddress: make(map[string]map[uint32]struct{}),
+		backfilling:      make(map[int64]Backfill),
 	}
 }
 
+// keepsBackfill reports whether a filter replacing another one under the same name is covered by its
+// backfill: it watches the same address and topic, from the same block or later.
+func keepsBackfill(existing, flt types.Filter) bool {
+	return existing.IsBackfilled && existing.Address.Equals(&flt.Address) &&
+		existing.EventTopic == flt.EventTopic && existing.StartingSeqNo <= flt.StartingSeqNo
+}
+
 func (f *Filters) RegisterFilter(_ context.Context, flt types.Filter) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if existing, ok := f.filtersByName[flt.Name]; ok {
+		if !flt.IsBackfilled && keepsBackfill(existing, flt) {
+			flt.IsBackfilled, flt.BackfilledSeqNo = true, existing.BackfilledSeqNo
+		}
+		f.remove(existing)
+	}
 	f.filtersByName[flt.Name] = flt
 	a := flt.Address.String()
 	if f.filtersByAddress[a] == nil {
@@ -40,7 +63,17 @@ func (f *Filters) UnregisterFilter(_ context.Context, name string) {
 	if !ok {
 		return
 	}
-	delete(f.filtersByName, name)
+	f.remove(flt)
+}
+
+// remove drops a filter, and the address/topic it watches unless another filter still watches them.
+func (f *Filters) remove(flt types.Filter) {
+	delete(f.filtersByName, flt.Name)
+	for _, other := range f.filtersByName {
+		if other.Address.Equals(&flt.Address) && other.EventTopic == flt.EventTopic {
+			return
+		}
+	}
 	a := flt.Address.String()
 	delete(f.filtersByAddress[a], flt.EventTopic)
 	if len(f.filtersByAddress[a]) == 0 {
@@ -59,11 +92,11 @@ func (f *Filters) GetDistinctAddresses() []*address.Address {
 	return out
 }
 
-// For a given (contractAddr, topic), return all FilterIDs that match.
-func (f *Filters) MatchingFilters(contractAddr address.Address, topic uint32) []int64 {
+// For a given (contractAddr, topic), return all Filters that match.
+func (f *Filters) MatchingFilters(contractAddr address.Address, topic uint32) []types.Filter {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-	var out []int64
+	var out []types.Filter
 	byTopic, ok := f.filtersByAddress[contractAddr.String()]
 	if !ok {
 		return nil
@@ -71,11 +104,75 @@ func (f *Filters) MatchingFilters(contractAddr address.Address, topic uint32) []
 	if _, watched := byTopic[topic]; !watched {
 		return nil
 	}
-	// collect all IDs whose Filter.Address/topic match
+	// collect all filters whose Filter.Address/topic match
 	for _, flt := range f.filtersByName {
 		if flt.Address.Equals(&contractAddr) && flt.EventTopic == topic {
-			out = append(out, flt.ID)
+			out = append(out, flt)
 		}
 	}
 	return out
 }
+
+// TakePendingBackfills returns backfills through head of the filters that need one and have none in progress,
+// marking them as in progress until FinishBackfill.
+func (f *Filters) TakePendingBackfills(head uint32) []Backfill {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Backfill
+	for _, flt := range f.filtersByName {
+		if _, inProgress := f.backfilling[flt.ID]; flt.IsBackfilled || inProgress {
+			continue
+		}
+		f.backfills++
+		b := Backfill{Filter: flt, Head: head, job: f.backfills}
+		f.backfilling[flt.ID] = b
+		out = append(out, b)
+	}
+	return out
+}
+
+// InProgress reports whether a backfill is still in progress, it is not once finished or reset by a rollback.
+func (f *Filters) InProgress(b Backfill) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.backfilling[b.Filter.ID].job == b.job
+}
+
+// FinishBackfill ends a backfill in progress, a failed one is taken again by the next TakePendingBackfills.
+// A backfill reset by a rollback meanwhile is ignored.
+func (f *Filters) FinishBackfill(b Backfill, succeeded bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.backfilling[b.Filter.ID].job != b.job {
+		return
+	}
+	delete(f.backfilling, b.Filter.ID)
+	if !succeeded {
+		return
+	}
+	for name, flt := range f.filtersByName {
+		if flt.ID == b.Filter.ID {
+			flt.IsBackfilled, flt.BackfilledSeqNo = true, b.Head
+			f.filtersByName[name] = flt
+		}
+	}
+}
+
+// ResetBackfills requires a new backfill of the filters backfilled through a block after seqNo, once rolled back.
+// The backfills in progress through a block after seqNo are dropped, and taken again by the next
+// TakePendingBackfills.
+func (f *Filters) ResetBackfills(seqNo uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, flt := range f.filtersByName {
+		if flt.IsBackfilled && flt.BackfilledSeqNo > seqNo {
+			flt.IsBackfilled = false
+			f.filtersByName[name] = flt
+		}
+	}
+	for id, b := range f.backfilling {
+		if b.Head > seqNo {
+			delete(f.backfilling, id)
+		}
+	}
+}
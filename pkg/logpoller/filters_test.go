@@ -0,0 +1,106 @@
+package logpoller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller/types"
+)
+
+func backfillNames(backfills []Backfill) []string {
+	names := make([]string, 0, len(backfills))
+	for _, b := range backfills {
+		names = append(names, b.Filter.Name)
+	}
+	return names
+}
+
+func TestFilters_TakePendingBackfills(t *testing.T) {
+	ctx := t.Context()
+	f := newFilters()
+	f.RegisterFilter(ctx, types.Filter{ID: 1, Name: "new", Address: *testAddress(1), EventTopic: 7})
+	f.RegisterFilter(ctx, types.Filter{ID: 2, Name: "backfilled", Address: *testAddress(1), EventTopic: 8, IsBackfilled: true, BackfilledSeqNo: 90})
+	f.RegisterFilter(ctx, types.Filter{ID: 3, Name: "failing", Address: *testAddress(2), EventTopic: 7})
+
+	backfills := f.TakePendingBackfills(100)
+	require.ElementsMatch(t, []string{"new", "failing"}, backfillNames(backfills))
+	for _, b := range backfills {
+		require.Equal(t, uint32(100), b.Head)
+		require.True(t, f.InProgress(b))
+	}
+	// backfills in progress are not taken twice
+	require.Empty(t, f.TakePendingBackfills(101))
+
+	testCases := []struct {
+		name      string
+		succeeded bool
+		retaken   bool
+	}{
+		{name: "new", succeeded: true},
+		{name: "failing", succeeded: false, retaken: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b Backfill
+			for _, taken := range backfills {
+				if taken.Filter.Name == tc.name {
+					b = taken
+				}
+			}
+			f.FinishBackfill(b, tc.succeeded)
+			require.False(t, f.InProgress(b))
+
+			retaken := f.TakePendingBackfills(102)
+			if !tc.retaken {
+				require.Empty(t, retaken)
+				require.True(t, f.filtersByName[tc.name].IsBackfilled)
+				require.Equal(t, uint32(100), f.filtersByName[tc.name].BackfilledSeqNo)
+				return
+			}
+			require.Equal(t, []string{tc.name}, backfillNames(retaken))
+			require.Equal(t, uint32(102), retaken[0].Head)
+			f.FinishBackfill(retaken[0], true)
+		})
+	}
+}
+
+func TestFilters_ResetBackfills(t *testing.T) {
+	testCases := []struct {
+		name    string
+		seqNo   uint32
+		pending []string
+	}{
+		{name: "rollback after every backfill", seqNo: 120},
+		{name: "rollback of the in-progress backfill", seqNo: 110, pending: []string{"in progress"}},
+		{name: "rollback of the later backfill", seqNo: 95, pending: []string{"in progress", "later"}},
+		{name: "rollback of every backfill", seqNo: 80, pending: []string{"in progress", "later", "earlier"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			f := newFilters()
+			f.RegisterFilter(ctx, types.Filter{ID: 1, Name: "earlier", Address: *testAddress(1), EventTopic: 7, IsBackfilled: true, BackfilledSeqNo: 90})
+			f.RegisterFilter(ctx, types.Filter{ID: 2, Name: "later", Address: *testAddress(1), EventTopic: 8, IsBackfilled: true, BackfilledSeqNo: 100})
+			f.RegisterFilter(ctx, types.Filter{ID: 3, Name: "in progress", Address: *testAddress(2), EventTopic: 7})
+			inProgress := f.TakePendingBackfills(115)
+			require.Len(t, inProgress, 1)
+
+			f.ResetBackfills(tc.seqNo)
+			reset := tc.seqNo < inProgress[0].Head
+			require.Equal(t, !reset, f.InProgress(inProgress[0]))
+			if reset {
+				// the dropped backfill neither finishes the retaken one nor marks the filter backfilled
+				retaken := f.TakePendingBackfills(tc.seqNo)
+				require.ElementsMatch(t, tc.pending, backfillNames(retaken))
+				f.FinishBackfill(inProgress[0], true)
+				require.False(t, f.filtersByName["in progress"].IsBackfilled)
+				for _, b := range retaken {
+					require.True(t, f.InProgress(b))
+				}
+				return
+			}
+			require.Empty(t, f.TakePendingBackfills(tc.seqNo))
+		})
+	}
+}
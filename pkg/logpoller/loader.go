@@ -134,29 +134,12 @@ func (lc *LogCollector) fetchMessagesForAddress(ctx context.Context, addr *addre
 		// filter and process messages within the current batch.
 		// The batch is sorted from oldest to newest.
 		for _, tx := range batch {
-			if tx.LT <= startLT || tx.IO.Out == nil {
+			if tx.LT <= startLT {
 				// no need to process older transactions, they are already handled.
 				continue
 			}
-
-			msgs, _ := tx.IO.Out.ToSlice()
-
-			for _, msg := range msgs {
-				// only interested in ExternalMessageOut
-				if msg.MsgType != tlb.MsgTypeExternalOut {
-					continue
-				}
-				ext := msg.AsExternalOut()
-				if ext.Body != nil {
-					// TODO: stream back to log poller.Process
-					event := types.MsgWithCtx{
-						TxHash: tx.Hash,
-						LT:     tx.LT,
-						Msg:    ext,
-					}
-					msgsWithCtx = append(msgsWithCtx, event)
-				}
-			}
+			// TODO: stream back to log poller.Process
+			msgsWithCtx = append(msgsWithCtx, externalMessages(tx)...)
 		}
 		// batch[0] is the oldest transaction in this batch.
 		// if it's already older than our start point, we don't need to fetch any more pages.
@@ -172,6 +155,32 @@ func (lc *LogCollector) fetchMessagesForAddress(ctx context.Context, addr *addre
 	return msgsWithCtx, nil
 }
 
+// externalMessages returns the external messages with a body sent by a transaction
+func externalMessages(tx *tlb.Transaction) []types.MsgWithCtx {
+	if tx.IO.Out == nil {
+		return nil
+	}
+	msgs, _ := tx.IO.Out.ToSlice()
+
+	var out []types.MsgWithCtx
+	for i, msg := range msgs {
+		// only interested in ExternalMessageOut
+		if msg.MsgType != tlb.MsgTypeExternalOut {
+			continue
+		}
+		ext := msg.AsExternalOut()
+		if ext.Body != nil {
+			out = append(out, types.MsgWithCtx{
+				TxHash:   tx.Hash,
+				LT:       tx.LT,
+				MsgIndex: uint32(i), //nolint:gosec // a transaction has at most 255 outgoing messages
+				Msg:      ext,
+			})
+		}
+	}
+	return out
+}
+
 /**
 // getTransactionBounds determines the logical time (LT) range for scanning transactions
 // between two blocks for a specific address on the TON blockchain.
@@ -1,8 +1,11 @@
 package logpoller
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -25,8 +28,13 @@ import (
 // registered filters. Each filter specifies an address and event topic, with optional
 // cell-level byte queries for precise filtering.
 //
-// This MVP provides the foundation for TON CCIP functionality and will be enhanced
-// with database persistence, advanced querying, and production-grade scalability features.
+// Filters and the logs they matched are persisted through an ORM, backed by the database
+// when the relayer has a datasource and kept in memory otherwise.
+//
+// Every iteration cross-checks the last processed block against the lite server. When it is
+// reported differently, the blocks processed since the last one reported identically are rolled
+// back with their logs and polled again. Blocks confirmed by enough masterchain blocks on top of
+// them are finalized, and only their logs are returned to finalized queries.
 
 // LogPoller defines the interface for TON log polling service
 type LogPoller interface {
@@ -51,9 +59,12 @@ type LogPoller interface {
 	//
 	// This approach is more robust and adaptable to changes in contract data layouts, as the
 	// filtering logic operates on strongly-typed fields rather than fixed byte offsets.
-	FilteredLogsWithParser(ctx context.Context, address *address.Address, topic uint32, parser types.LogParser, filter types.LogFilter) ([]any, error)
+	// Only the logs processed at the confidence are streamed, latest when empty.
+	FilteredLogsWithParser(ctx context.Context, address *address.Address, topic uint32, parser types.LogParser, filter types.LogFilter, confidence Confidence) ([]any, error)
 }
 
+var _ LogPoller = (*Service)(nil)
+
 // Service is the main TON log polling service implementation.
 // It continuously polls the TON masterchain, discovers new blocks, and processes
 // external messages from registered filter addresses.
@@ -63,29 +74,54 @@ type Service struct {
 	lggr               logger.SugaredLogger // Logger instance
 	client             ton.APIClientWrapped // TON blockchain client
 	filters            *Filters             // Registry of active filters
-	loader             *LogCollector        // Block scanner implementation
-	store              *InMemoryStore       // Log storage (MVP: in-memory)
+	loader             Collector            // Block scanner implementation, selected by the collector mode
+	backfillLoader     *LogCollector        // Account scanner of backfills, cheapest for the history of a single address
+	store              ORM                  // Filter, log and processed block storage
 	pollPeriod         time.Duration        // How often to poll for new blocks
-	lastProcessedSeqNo uint32               // Last processed masterchain sequence number
-	blockConfirmations uint32               // Number of confirmations to wait before processing
+	pruneInterval      time.Duration        // How often to prune expired logs and the logs of unregistered filters
+	lookbackWindow     uint32               // Maximum number of blocks polled to catch up after a restart, 0 for no limit
+	maxBlockRange      uint32               // Maximum number of blocks loaded at once, 0 for no limit
+	lastProcessed      *ton.BlockIDExt      // Last processed masterchain block, nil until resumed from the store
+	blockConfirmations uint32               // Number of confirmations before the logs of a block are finalized
+	rollbackMu         sync.Mutex           // Serializes rollbacks with the logs saved by backfills
 }
 
-// NewLogPoller creates a new TON log polling service instance
+// blocksKept is the number of processed blocks kept in the store by the pruner
+const blocksKept = 1000
+
+// NewLogPoller creates a new TON log polling service instance.
+// Logs are kept in memory when no ORM is given.
 func NewLogPoller(
 	lggr logger.Logger,
 	client ton.APIClientWrapped,
-	cfg Config, // TODO: use global relayer config
+	store ORM,
+	cfg Config,
 ) *Service {
-	store := NewInMemoryStore(lggr)
+	if store == nil {
+		store = NewInMemoryStore(lggr)
+	}
 	filters := newFilters()
 	lp := &Service{
-		lggr:       logger.Sugared(lggr),
-		client:     client,
-		filters:    filters,
-		store:      store,
-		pollPeriod: cfg.PollPeriod,
+		lggr:               logger.Sugared(lggr),
+		client:             client,
+		filters:            filters,
+		store:              store,
+		pollPeriod:         cfg.PollPeriod,
+		pruneInterval:      cfg.PruneInterval,
+		lookbackWindow:     cfg.LookbackWindow,
+		maxBlockRange:      cfg.MaxBlockRange,
+		blockConfirmations: cfg.BlockConfirmations,
+	}
+	lp.backfillLoader = NewLogCollector(lp.client, lp.lggr, cfg.PageSize)
+	switch cfg.Mode {
+	case CollectorModeBlock:
+		lp.loader = NewBlockCollector(lp.client, lp.lggr, cfg.PageSize)
+	case CollectorModeAccount, "":
+		lp.loader = lp.backfillLoader
+	default:
+		lp.lggr.Warnw("unknown collector mode, collecting by account", "mode", cfg.Mode)
+		lp.loader = lp.backfillLoader
 	}
-	lp.loader = NewLogCollector(lp.client, lp.lggr, cfg.PageSize)
 	lp.Service, lp.eng = services.Config{
 		Name:  "TONLogPoller",
 		Start: lp.start,
@@ -93,22 +129,37 @@ func NewLogPoller(
 	return lp
 }
 
-// start initializes the log polling service and begins the polling loop
+// start loads the persisted filters and begins the polling and pruning loops
 func (lp *Service) start(ctx context.Context) error {
 	lp.lggr.Infof("starting logpoller")
+	filters, err := lp.store.SelectFilters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load filters: %w", err)
+	}
+	for _, flt := range filters {
+		lp.filters.RegisterFilter(ctx, flt)
+	}
+	lp.lggr.Infow("loaded filters", "count", len(filters))
+
 	lp.eng.GoTick(services.NewTicker(lp.pollPeriod), func(ctx context.Context) {
 		if err := lp.run(ctx); err != nil {
 			lp.lggr.Errorw("iteration failed", "err", err)
 		}
 	})
+	if lp.pruneInterval > 0 {
+		lp.eng.GoTick(services.NewTicker(lp.pruneInterval), lp.prune)
+	} else {
+		lp.lggr.Warnw("pruning disabled, no prune interval configured")
+	}
 	return nil
 }
 
 // run executes a single polling iteration:
 // 1. Gets current masterchain head
-// 2. Calculates safe-to-process block (with confirmations)
-// 3. Processes new blocks since last processed sequence number
-// 4. Updates last processed sequence number
+// 2. Cross-checks the last processed block, rolling back the blocks reported differently
+// 3. Finalizes the processed blocks with enough confirmations
+// 4. Processes new blocks since last processed block, at most maxBlockRange blocks at a time
+// 5. Saves the logs of every range along with its last block
 func (lp *Service) run(ctx context.Context) (err error) {
 	defer func() {
 		if rec := recover(); rec != nil {
@@ -116,170 +167,415 @@ func (lp *Service) run(ctx context.Context) (err error) {
 		}
 	}()
 
-	lastProcessedSeq, err := lp.getLastProcessedSeqNo()
-	if err != nil {
-		return fmt.Errorf("LoadLastSeq: %w", err)
-	}
-	// TODO: load filter from persistent store
-	// TODO: implement backfill logic(if there is filters marked for backfill)
-
 	// get the current masterchain head
 	currentMaster, err := lp.client.CurrentMasterchainInfo(ctx)
 	if err != nil {
 		return err
 	}
 
-	// calculate the latest block we can safely process with confirmations
-	safeToProcessSeq := currentMaster.SeqNo - lp.blockConfirmations
+	// blocks are processed as soon as they are produced, their logs are finalized once confirmed
+	headSeq := currentMaster.SeqNo
 
-	// if safe to process is behind last processed, we need to wait for more blocks
-	if safeToProcessSeq < lastProcessedSeq {
-		blocksLeft := lastProcessedSeq - safeToProcessSeq
+	prevBlock, err := lp.getLastProcessedBlock(ctx, currentMaster, headSeq)
+	if err != nil {
+		return fmt.Errorf("LoadLastBlock: %w", err)
+	}
+	prevBlock, err = lp.checkConsistency(ctx, currentMaster, prevBlock)
+	if err != nil {
+		return fmt.Errorf("checkConsistency: %w", err)
+	}
+	lastProcessedSeq := prevBlock.SeqNo
+	if err = lp.finalize(ctx, headSeq, lastProcessedSeq); err != nil {
+		return fmt.Errorf("finalize: %w", err)
+	}
+	// filters seen for the first time are polled live from now on, their earlier logs are backfilled
+	lp.startBackfills(lastProcessedSeq)
+
+	// if the head is behind last processed, the lite server is lagging and we need to wait for more blocks
+	if headSeq < lastProcessedSeq {
+		blocksLeft := lastProcessedSeq - headSeq
 		lp.lggr.Debugw("waiting for more blocks to process",
 			"lastProcessed", lastProcessedSeq,
-			"safeToProcess", safeToProcessSeq,
+			"head", headSeq,
 			"blocksLeft", blocksLeft)
 		return nil
 	}
 
-	// if we already processed everything we can safely process, skip
-	if safeToProcessSeq == lastProcessedSeq {
-		lp.lggr.Debugw("skipping already processed safe seq", "seq", safeToProcessSeq)
+	// if we already processed the head, skip
+	if headSeq == lastProcessedSeq {
+		lp.lggr.Debugw("skipping already processed head seq", "seq", headSeq)
 		return nil
 	}
 
-	// get the actual block to process (the safe one)
-	toBlock, err := lp.client.LookupBlock(ctx, currentMaster.Workchain, currentMaster.Shard, safeToProcessSeq)
-	if err != nil {
-		return fmt.Errorf("LookupBlock for safe seq %d: %w", safeToProcessSeq, err)
-	}
-
 	// load the addresses from filters that we're interested in
 	addresses := lp.filters.GetDistinctAddresses()
-	if len(addresses) == 0 {
-		return nil
-	}
 	lp.lggr.Debugw("Processing messages for addresses", "addresses", addresses)
 
-	var prevBlock *ton.BlockIDExt
-	if lastProcessedSeq == 0 {
-		// for the first run, we don't have a previous block to reference
-		prevBlock = nil
-		lp.lggr.Debugw("First run detected, processing from genesis", "toSeq", toBlock.SeqNo)
-	} else {
-		// get the prevBlock based on the last processed seqno
-		prevBlock, err = lp.client.LookupBlock(ctx, toBlock.Workchain, toBlock.Shard, lastProcessedSeq)
+	for prevBlock.SeqNo < headSeq {
+		toBlock := currentMaster
+		if lp.maxBlockRange > 0 && headSeq-prevBlock.SeqNo > lp.maxBlockRange {
+			toBlock, err = lp.client.LookupBlock(ctx, currentMaster.Workchain, currentMaster.Shard, prevBlock.SeqNo+lp.maxBlockRange)
+			if err != nil {
+				return fmt.Errorf("LookupBlock for seq %d: %w", prevBlock.SeqNo+lp.maxBlockRange, err)
+			}
+		}
+		if err = lp.processBlocks(ctx, addresses, prevBlock, toBlock); err != nil {
+			return err
+		}
+		prevBlock = toBlock
+	}
+	return nil
+}
+
+// processBlocks loads the logs of the blocks in the range (prevBlock, toBlock] and saves them along with toBlock.
+// Without addresses to watch, toBlock is saved right away.
+func (lp *Service) processBlocks(ctx context.Context, addresses []*address.Address, prevBlock *ton.BlockIDExt, toBlock *ton.BlockIDExt) error {
+	var logs []types.Log
+	if len(addresses) > 0 {
+		var err error
+		logs, err = lp.processBlocksRange(ctx, addresses, prevBlock, toBlock)
+		if err != nil {
+			return fmt.Errorf("processBlocksRange: %w", err)
+		}
+		for i := range logs {
+			logs[i].ProcessedSeqNo = toBlock.SeqNo
+		}
+	}
+
+	// the logs and the last processed block are saved together, a restart resumes right after the logs saved
+	if err := lp.store.InsertProcessedBlock(ctx, blockFromID(toBlock), logs); err != nil {
+		return fmt.Errorf("InsertProcessedBlock: %w", err)
+	}
+	lp.lastProcessed = toBlock
+	return nil
+}
+
+// checkConsistency cross-checks the last processed block against the lite server. Should it be reported
+// differently, the processed blocks are checked from the latest one down, the blocks after the first one
+// reported identically are rolled back along with their logs, and polling resumes from it.
+// As every masterchain block commits to the previous one, the blocks before a matching one match too.
+func (lp *Service) checkConsistency(ctx context.Context, master *ton.BlockIDExt, last *ton.BlockIDExt) (*ton.BlockIDExt, error) {
+	ok, err := lp.matchesLiteServer(ctx, master, last)
+	if err != nil || ok {
+		return last, err
+	}
+	lp.lggr.Warnw("last processed block is reported differently by the lite server, rolling back",
+		"seq", last.SeqNo, "rootHash", hex.EncodeToString(last.RootHash))
+
+	blocks, err := lp.store.SelectLatestBlocks(ctx, blocksKept)
+	if err != nil {
+		return nil, fmt.Errorf("SelectLatestBlocks: %w", err)
+	}
+	for _, block := range blocks {
+		ok, err = lp.matchesLiteServer(ctx, master, block.ID())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if block.IsFinalized {
+				lp.lggr.Criticalw("finalized block is reported differently by the lite server, rolling back its logs",
+					"seq", block.SeqNo, "rootHash", hex.EncodeToString(block.RootHash))
+			}
+			continue
+		}
+
+		removed, err := lp.rollback(ctx, block.SeqNo)
 		if err != nil {
-			return fmt.Errorf("LookupBlock: %w", err)
+			return nil, err
 		}
+		lp.lggr.Warnw("rolled back blocks reported differently by the lite server",
+			"fromSeq", last.SeqNo, "toSeq", block.SeqNo, "logsRemoved", removed)
+		lp.lastProcessed = block.ID()
+		return lp.lastProcessed, nil
 	}
+	// polling resumes once the lite servers report one of the processed blocks identically again
+	return nil, fmt.Errorf("none of the %d processed blocks down from seq %d is reported identically by the lite server",
+		len(blocks), last.SeqNo)
+}
 
-	err = lp.processBlocksRange(ctx, addresses, prevBlock, toBlock)
+// rollback removes the blocks after seqNo along with their logs, and resets the backfills through them,
+// the backfills in progress through them included, so that none saves its logs afterward
+func (lp *Service) rollback(ctx context.Context, seqNo uint32) (int64, error) {
+	lp.rollbackMu.Lock()
+	defer lp.rollbackMu.Unlock()
+	removed, err := lp.store.RollbackBlocks(ctx, seqNo)
 	if err != nil {
-		return fmt.Errorf("processBlocksRange: %w", err)
+		return 0, fmt.Errorf("RollbackBlocks: %w", err)
 	}
+	lp.filters.ResetBackfills(seqNo)
+	return removed, nil
+}
 
-	// save the last processed seqno
-	lp.lastProcessedSeqNo = toBlock.SeqNo
-	return nil
+// matchesLiteServer reports whether the lite server returns the same hashes for a processed block
+func (lp *Service) matchesLiteServer(ctx context.Context, master *ton.BlockIDExt, block *ton.BlockIDExt) (bool, error) {
+	current, err := lp.client.LookupBlock(ctx, master.Workchain, master.Shard, block.SeqNo)
+	if err != nil {
+		return false, fmt.Errorf("LookupBlock for processed seq %d: %w", block.SeqNo, err)
+	}
+	return bytes.Equal(current.RootHash, block.RootHash) && bytes.Equal(current.FileHash, block.FileHash), nil
+}
+
+// finalize marks the processed blocks with at least blockConfirmations blocks on top of them finalized,
+// up to the last processed block that was just cross-checked
+func (lp *Service) finalize(ctx context.Context, headSeq uint32, lastProcessedSeq uint32) error {
+	if headSeq < lp.blockConfirmations {
+		return nil
+	}
+	return lp.store.MarkBlocksFinalized(ctx, min(headSeq-lp.blockConfirmations, lastProcessedSeq))
 }
 
 // processBlocksRange handles scanning a range of blocks for external messages
 // from the specified addresses. It delegates to the LogCollector for the actual
-// block scanning and then processes the returned messages.
-func (lp *Service) processBlocksRange(ctx context.Context, addresses []*address.Address, prevBlock *ton.BlockIDExt, toBlock *ton.BlockIDExt) error {
+// block scanning and then turns the returned messages into logs.
+func (lp *Service) processBlocksRange(ctx context.Context, addresses []*address.Address, prevBlock *ton.BlockIDExt, toBlock *ton.BlockIDExt) ([]types.Log, error) {
 	msgs, err := lp.loader.BackfillForAddresses(ctx, addresses, prevBlock, toBlock)
 	if err != nil {
-		return fmt.Errorf("BackfillForAddresses: %w", err)
+		return nil, fmt.Errorf("BackfillForAddresses: %w", err)
 	}
 
-	if err := lp.processMessages(msgs); err != nil {
-		return fmt.Errorf("processMessages: %w", err)
+	logs, err := lp.processMessages(msgs)
+	if err != nil {
+		return nil, fmt.Errorf("processMessages: %w", err)
 	}
 
-	return nil
+	return logs, nil
 }
 
-// processMessages iterates through external messages and processes each one
-func (lp *Service) processMessages(msgs []types.MsgWithCtx) error {
+// processMessages turns external messages into logs
+func (lp *Service) processMessages(msgs []types.MsgWithCtx) ([]types.Log, error) {
+	var logs []types.Log
 	for _, msg := range msgs {
-		if err := lp.Process(msg); err != nil {
-			return err
+		msgLogs, err := lp.Process(msg)
+		if err != nil {
+			return nil, err
 		}
+		logs = append(logs, msgLogs...)
 	}
-	return nil
+	return logs, nil
 }
 
 // Process handles a single external message:
 // 1. Extracts event topic from destination address
 // 2. Finds matching filters for the source address and topic
-// 3. Saves logs for each matching filter
-func (lp *Service) Process(msg types.MsgWithCtx) error {
+// 3. Returns a log for each matching filter
+func (lp *Service) Process(msg types.MsgWithCtx) ([]types.Log, error) {
 	bucket := event.NewExtOutLogBucket(msg.Msg.DstAddr)
 	topic, err := bucket.DecodeEventTopic()
 	if err != nil {
-		return fmt.Errorf("failed to decode event topic: %w", err)
+		return nil, fmt.Errorf("failed to decode event topic: %w", err)
 	}
 	lp.lggr.Debugw("Processing message", "src", msg.Msg.SrcAddr, "dst", msg.Msg.DstAddr, "topic", topic)
 
-	fIDs := lp.filters.MatchingFilters(*msg.Msg.SrcAddr, topic)
-	if len(fIDs) == 0 {
-		return nil // no filters matched, nothing to do
-	}
-
-	for _, fid := range fIDs {
-		lp.store.SaveLog(types.Log{
-			FilterID: fid,
-			// TODO: we need custom type for to storing block, tx metadata
-			// SeqNo:      master.SeqNo,
-			TxHash:  msg.TxHash,
-			TxLT:    msg.LT,
-			Address: *msg.Msg.SrcAddr,
-			Topic:   topic,
-			Data:    msg.Msg.Body.ToBOC(),
+	filters := lp.filters.MatchingFilters(*msg.Msg.SrcAddr, topic)
+	if len(filters) == 0 {
+		return nil, nil // no filters matched, nothing to do
+	}
+
+	now := time.Now().UTC()
+	logs := make([]types.Log, 0, len(filters))
+	for _, flt := range filters {
+		var expiresAt *time.Time
+		if flt.Retention > 0 {
+			exp := now.Add(flt.Retention)
+			expiresAt = &exp
+		}
+		logs = append(logs, types.Log{
+			FilterID:   flt.ID,
+			SeqNo:      msg.MasterSeqNo,
+			ShardBlock: msg.Block,
+			BlockTime:  msg.BlockTime,
+			TxHash:     msg.TxHash,
+			TxLT:       msg.LT,
+			MsgIndex:   msg.MsgIndex,
+			Address:    *msg.Msg.SrcAddr,
+			Topic:      topic,
+			Data:       msg.Msg.Body.ToBOC(),
+			ExpiresAt:  expiresAt,
+		})
+	}
+	return logs, nil
+}
+
+// startBackfills launches a backfill job for every filter that needs one, loading its logs from its
+// starting block through head, the last block processed before live polling included the filter.
+// Jobs run alongside live polling, a failed job is retried on the next iteration, as is a job reset by a rollback.
+func (lp *Service) startBackfills(head uint32) {
+	for _, b := range lp.filters.TakePendingBackfills(head) {
+		lp.eng.Go(func(ctx context.Context) {
+			err := lp.backfill(ctx, b)
+			if err != nil {
+				lp.lggr.Errorw("backfill failed", "filter", b.Filter.Name, "err", err)
+			}
+			lp.filters.FinishBackfill(b, err == nil)
 		})
 	}
+}
+
+// backfill loads the logs of a filter in the block range [flt.StartingSeqNo, head] and marks it backfilled.
+// The logs are processed along with head, and rolled back with it. A backfill reset by a rollback of head
+// while loading saves nothing.
+func (lp *Service) backfill(ctx context.Context, b Backfill) error {
+	flt, head := b.Filter, b.Head
+	var logs []types.Log
+	if head > 0 && flt.StartingSeqNo <= head {
+		lp.lggr.Infow("backfilling filter", "filter", flt.Name, "fromSeq", flt.StartingSeqNo, "toSeq", head)
+		master, err := lp.client.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			return err
+		}
+		toBlock, err := lp.client.LookupBlock(ctx, master.Workchain, master.Shard, head)
+		if err != nil {
+			return fmt.Errorf("LookupBlock for head seq %d: %w", head, err)
+		}
+		var prevBlock *ton.BlockIDExt
+		if flt.StartingSeqNo > 1 {
+			prevBlock, err = lp.client.LookupBlock(ctx, master.Workchain, master.Shard, flt.StartingSeqNo-1)
+			if err != nil {
+				return fmt.Errorf("LookupBlock for starting seq %d: %w", flt.StartingSeqNo, err)
+			}
+		}
+
+		msgs, err := lp.backfillLoader.BackfillForAddresses(ctx, []*address.Address{&flt.Address}, prevBlock, toBlock)
+		if err != nil {
+			return fmt.Errorf("BackfillForAddresses: %w", err)
+		}
+		for _, msg := range msgs {
+			msgLogs, err := lp.Process(msg)
+			if err != nil {
+				return err
+			}
+			for _, log := range msgLogs {
+				// other filters of the address are polled live or backfilled on their own
+				if log.FilterID == flt.ID {
+					log.ProcessedSeqNo = head
+					logs = append(logs, log)
+				}
+			}
+		}
+	}
+
+	lp.rollbackMu.Lock()
+	defer lp.rollbackMu.Unlock()
+	if !lp.filters.InProgress(b) {
+		lp.lggr.Warnw("backfill reset by a rollback, dropping its logs", "filter", flt.Name, "head", head, "logs", len(logs))
+		return nil
+	}
+	if err := lp.store.InsertLogs(ctx, logs); err != nil {
+		return fmt.Errorf("InsertLogs: %w", err)
+	}
+	if err := lp.store.MarkFilterBackfilled(ctx, flt.ID, head); err != nil {
+		return err
+	}
+	lp.lggr.Infow("backfilled filter", "filter", flt.Name, "logs", len(logs))
 	return nil
 }
 
-// getLastProcessedSeqNo retrieves the last processed masterchain sequence number.
-// Currently uses in-memory storage; will be replaced with database persistence.
-func (lp *Service) getLastProcessedSeqNo() (uint32, error) {
-	lastProcessed := lp.lastProcessedSeqNo
-	if lastProcessed > 0 {
-		return lastProcessed, nil
+// prune removes expired logs, along with unregistered filters and their logs, and old processed blocks
+func (lp *Service) prune(ctx context.Context) {
+	pruned, err := lp.store.PruneLogs(ctx)
+	if err != nil {
+		lp.lggr.Errorw("failed to prune logs", "err", err)
+	} else if pruned > 0 {
+		lp.lggr.Debugw("pruned logs", "count", pruned)
+	}
+
+	pruned, err = lp.store.PruneBlocks(ctx, blocksKept)
+	if err != nil {
+		lp.lggr.Errorw("failed to prune blocks", "err", err)
+	} else if pruned > 0 {
+		lp.lggr.Debugw("pruned blocks", "count", pruned)
+	}
+}
+
+// getLastProcessedBlock returns the last processed masterchain block, resumed from the store after a restart.
+// On a fresh start polling begins at the head block, the earlier logs of the filters are backfilled.
+// A node down for longer than the lookback window skips the older missed blocks.
+func (lp *Service) getLastProcessedBlock(ctx context.Context, master *ton.BlockIDExt, headSeq uint32) (*ton.BlockIDExt, error) {
+	if lp.lastProcessed != nil {
+		return lp.lastProcessed, nil
+	}
+
+	latest, err := lp.store.SelectLatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var startSeq uint32
+	switch {
+	case latest == nil:
+		startSeq = headSeq
+		lp.lggr.Infow("fresh start, polling from the head block", "seq", startSeq)
+	case lp.lookbackWindow > 0 && headSeq > latest.SeqNo+lp.lookbackWindow:
+		startSeq = headSeq - lp.lookbackWindow
+		lp.lggr.Warnw("last processed block is past the lookback window, skipping missed blocks",
+			"lastProcessed", latest.SeqNo, "resumeFrom", startSeq, "skipped", startSeq-latest.SeqNo)
+	default:
+		lp.lggr.Infow("resuming from last processed block", "seq", latest.SeqNo)
+		lp.lastProcessed = latest.ID()
+		return lp.lastProcessed, nil
+	}
+
+	start, err := lp.client.LookupBlock(ctx, master.Workchain, master.Shard, startSeq)
+	if err != nil {
+		return nil, fmt.Errorf("LookupBlock for start seq %d: %w", startSeq, err)
+	}
+	// saved right away, so that a restart does not move the start of live polling past the backfills
+	if err = lp.store.InsertProcessedBlock(ctx, blockFromID(start), nil); err != nil {
+		return nil, fmt.Errorf("InsertProcessedBlock: %w", err)
 	}
+	lp.lastProcessed = start
+	return lp.lastProcessed, nil
+}
 
-	// TODO: get the latest processed seqno from log table
-	// TODO: implement lookbackwindow configuration and fallback logic if needed
-	return lastProcessed, nil
+func blockFromID(id *ton.BlockIDExt) types.Block {
+	return types.Block{
+		Workchain: id.Workchain,
+		Shard:     id.Shard,
+		SeqNo:     id.SeqNo,
+		RootHash:  id.RootHash,
+		FileHash:  id.FileHash,
+	}
 }
 
-// RegisterFilter adds a new filter to monitor specific address/topic combinations
-func (lp *Service) RegisterFilter(ctx context.Context, flt types.Filter) {
+// RegisterFilter adds a new filter to monitor specific address/topic combinations.
+// The logs of the filter from its starting block are backfilled in the background.
+func (lp *Service) RegisterFilter(ctx context.Context, flt types.Filter) error {
+	flt.IsDeleted, flt.IsBackfilled = false, false
+	id, err := lp.store.InsertFilter(ctx, flt)
+	if err != nil {
+		return fmt.Errorf("failed to register filter %s: %w", flt.Name, err)
+	}
+	flt.ID = id
 	lp.filters.RegisterFilter(ctx, flt)
+	return nil
 }
 
-// UnregisterFilter removes a filter by name
-func (lp *Service) UnregisterFilter(ctx context.Context, name string) {
+// UnregisterFilter removes a filter by name, the logs it matched are pruned in the background
+func (lp *Service) UnregisterFilter(ctx context.Context, name string) error {
+	if err := lp.store.MarkFilterDeleted(ctx, name); err != nil {
+		return fmt.Errorf("failed to unregister filter %s: %w", name, err)
+	}
 	lp.filters.UnregisterFilter(ctx, name)
+	return nil
 }
 
-// GetLogs retrieves all logs for a specific event source address
-func (lp *Service) GetLogs(evtSrcAddress *address.Address) []types.Log {
-	return lp.store.GetLogs(evtSrcAddress.String())
+// GetLogs retrieves all logs for a specific event source address, processed at a confidence
+func (lp *Service) GetLogs(ctx context.Context, evtSrcAddress *address.Address, confidence Confidence) ([]types.Log, error) {
+	return lp.store.SelectLogs(ctx, evtSrcAddress, confidence)
 }
 
 // FilteredLogs retrieves logs filtered by address, topic, and additional cell-level queries.
 // This allows for precise filtering based on the internal structure of TON cell data.
 func (lp *Service) FilteredLogs(
+	ctx context.Context,
 	evtSrcAddress *address.Address,
 	topic uint32,
 	queries []CellQuery,
 	options QueryOptions,
 ) (QueryResult, error) {
 	return lp.store.FilteredLogs(
-		evtSrcAddress.String(),
+		ctx,
+		evtSrcAddress,
 		topic,
 		queries,
 		options,
@@ -287,15 +583,19 @@ func (lp *Service) FilteredLogs(
 }
 
 func (lp *Service) FilteredLogsWithParser(
+	ctx context.Context,
 	evtSrcAddress *address.Address,
 	topic uint32,
 	parser types.LogParser,
 	filter types.LogFilter,
+	confidence Confidence,
 ) ([]any, error) {
 	return lp.store.FilteredLogsWithParser(
-		evtSrcAddress.String(),
+		ctx,
+		evtSrcAddress,
 		topic,
 		parser,
 		filter,
+		confidence,
 	)
 }
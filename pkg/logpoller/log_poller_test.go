@@ -0,0 +1,148 @@
+package logpoller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/ton"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller/types"
+)
+
+// liteServerBlocks serves masterchain blocks by seqno, the other client methods are not implemented.
+type liteServerBlocks struct {
+	ton.APIClientWrapped
+	blocks map[uint32]*ton.BlockIDExt
+	head   uint32
+}
+
+func (c *liteServerBlocks) CurrentMasterchainInfo(context.Context) (*ton.BlockIDExt, error) {
+	return c.blocks[c.head], nil
+}
+
+func (c *liteServerBlocks) LookupBlock(_ context.Context, _ int32, _ int64, seqNo uint32) (*ton.BlockIDExt, error) {
+	block, ok := c.blocks[seqNo]
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", seqNo)
+	}
+	return block, nil
+}
+
+func testBlock(seqNo uint32, fork byte) *ton.BlockIDExt {
+	return &ton.BlockIDExt{Workchain: -1, Shard: -1 << 63, SeqNo: seqNo, RootHash: []byte{fork, byte(seqNo)}, FileHash: []byte{byte(seqNo)}}
+}
+
+func TestService_CheckConsistency(t *testing.T) {
+	const processedFrom, processedTo = 100, 105
+	testCases := []struct {
+		name       string
+		forkedFrom uint32 // first processed block reported differently, 0 when all match
+		resumeSeq  uint32
+		err        string
+	}{
+		{name: "blocks match", resumeSeq: processedTo},
+		{name: "last block reported differently", forkedFrom: processedTo, resumeSeq: processedTo - 1},
+		{name: "several blocks reported differently", forkedFrom: 102, resumeSeq: 101},
+		{name: "no block reported identically", forkedFrom: processedFrom, err: "none of the 6 processed blocks"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			lggr := logger.Test(t)
+			client := &liteServerBlocks{blocks: make(map[uint32]*ton.BlockIDExt)}
+			lp := NewLogPoller(lggr, client, nil, DefaultConfigSet)
+			addr := testAddress(1)
+
+			for seqNo := uint32(processedFrom); seqNo <= processedTo; seqNo++ {
+				log := testLog(1, addr, 7, uint64(seqNo), []byte{1})
+				log.ProcessedSeqNo = seqNo
+				require.NoError(t, lp.store.InsertProcessedBlock(ctx, blockFromID(testBlock(seqNo, 0)), []types.Log{log}))
+				client.blocks[seqNo] = testBlock(seqNo, 0)
+				if tc.forkedFrom > 0 && seqNo >= tc.forkedFrom {
+					client.blocks[seqNo] = testBlock(seqNo, 1)
+				}
+			}
+			// a filter backfilled through the last block and one still backfilling through it
+			lp.filters.RegisterFilter(ctx, types.Filter{ID: 1, Name: "backfilled", Address: *addr, EventTopic: 7, IsBackfilled: true, BackfilledSeqNo: processedTo})
+			lp.filters.RegisterFilter(ctx, types.Filter{ID: 2, Name: "backfilling", Address: *addr, EventTopic: 8})
+			inProgress := lp.filters.TakePendingBackfills(processedTo)
+			require.Len(t, inProgress, 1)
+
+			master := testBlock(processedTo+1, 0)
+			prev, err := lp.checkConsistency(ctx, master, testBlock(processedTo, 0))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.resumeSeq, prev.SeqNo)
+
+			latest, err := lp.store.SelectLatestBlock(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tc.resumeSeq, latest.SeqNo)
+			logs, err := lp.store.SelectLogs(ctx, addr, ConfidenceLatest)
+			require.NoError(t, err)
+			require.Len(t, logs, int(tc.resumeSeq-processedFrom+1))
+
+			rolledBack := tc.resumeSeq < processedTo
+			require.Equal(t, !rolledBack, lp.filters.InProgress(inProgress[0]))
+			backfills := lp.filters.TakePendingBackfills(tc.resumeSeq)
+			if !rolledBack {
+				require.Empty(t, backfills)
+				return
+			}
+			names := make([]string, 0, len(backfills))
+			for _, b := range backfills {
+				names = append(names, b.Filter.Name)
+			}
+			require.ElementsMatch(t, []string{"backfilled", "backfilling"}, names)
+		})
+	}
+}
+
+func TestService_Run(t *testing.T) {
+	const lastSaved = 100
+	testCases := []struct {
+		name      string
+		head      uint32
+		lookback  uint32
+		maxRange  uint32
+		processed []uint32 // blocks saved by the iteration
+	}{
+		{name: "head already processed", head: lastSaved},
+		{name: "range within the limit", head: 150, lookback: 1000, maxRange: 100, processed: []uint32{150}},
+		{name: "range split", head: 350, lookback: 1000, maxRange: 100, processed: []uint32{200, 300, 350}},
+		{name: "range without limit", head: 350, lookback: 1000, processed: []uint32{350}},
+		{name: "missed blocks past the lookback window", head: 350, lookback: 200, maxRange: 100, processed: []uint32{150, 250, 350}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			client := &liteServerBlocks{blocks: make(map[uint32]*ton.BlockIDExt), head: tc.head}
+			for seqNo := uint32(lastSaved); seqNo <= tc.head; seqNo++ {
+				client.blocks[seqNo] = testBlock(seqNo, 0)
+			}
+			cfg := DefaultConfigSet
+			cfg.LookbackWindow, cfg.MaxBlockRange = tc.lookback, tc.maxRange
+			lp := NewLogPoller(logger.Test(t), client, nil, cfg)
+			require.NoError(t, lp.store.InsertProcessedBlock(ctx, blockFromID(testBlock(lastSaved, 0)), nil))
+
+			// blocks are saved even without filters to poll
+			require.NoError(t, lp.run(ctx))
+			blocks, err := lp.store.SelectLatestBlocks(ctx, blocksKept)
+			require.NoError(t, err)
+			saved := make([]uint32, 0, len(blocks))
+			for _, block := range blocks {
+				if block.SeqNo != lastSaved {
+					saved = append(saved, block.SeqNo)
+				}
+			}
+			require.ElementsMatch(t, tc.processed, saved)
+			require.Equal(t, tc.head, lp.lastProcessed.SeqNo)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+-- +goose Up
+CREATE SCHEMA IF NOT EXISTS ton;
+
+CREATE TABLE ton.log_poller_filters (
+    id              BIGSERIAL PRIMARY KEY,
+    chain_id        TEXT NOT NULL,
+    name            TEXT NOT NULL,
+    address         TEXT NOT NULL,
+    event_name      TEXT NOT NULL,
+    event_topic     BIGINT NOT NULL,
+    starting_seq_no BIGINT NOT NULL,
+    retention_secs  BIGINT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_log_poller_filters_chain_name ON ton.log_poller_filters (chain_id, name);
+
+-- data is the BOC of the message body, payload the data bits of its root cell that cell queries compare against
+CREATE TABLE ton.log_poller_logs (
+    id          BIGSERIAL PRIMARY KEY,
+    filter_id   BIGINT NOT NULL REFERENCES ton.log_poller_filters (id) ON DELETE CASCADE,
+    chain_id    TEXT NOT NULL,
+    address     TEXT NOT NULL,
+    event_topic BIGINT NOT NULL,
+    tx_hash     BYTEA NOT NULL,
+    tx_lt       BIGINT NOT NULL,
+    msg_index   INTEGER NOT NULL,
+    data        BYTEA NOT NULL,
+    payload     BYTEA NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    expires_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_log_poller_logs_unique ON ton.log_poller_logs (chain_id, filter_id, tx_hash, msg_index);
+CREATE INDEX idx_log_poller_logs_address_topic ON ton.log_poller_logs (chain_id, address, event_topic, tx_lt);
+CREATE INDEX idx_log_poller_logs_tx_lt ON ton.log_poller_logs (chain_id, tx_lt);
+
+CREATE TABLE ton.log_poller_blocks (
+    chain_id     TEXT NOT NULL,
+    seq_no       BIGINT NOT NULL,
+    root_hash    BYTEA NOT NULL,
+    file_hash    BYTEA NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chain_id, seq_no)
+);
+
+-- +goose Down
+DROP TABLE ton.log_poller_blocks;
+DROP TABLE ton.log_poller_logs;
+DROP TABLE ton.log_poller_filters;
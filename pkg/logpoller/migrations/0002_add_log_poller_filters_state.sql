@@ -0,0 +1,19 @@
+-- +goose Up
+-- unregistered filters are soft-deleted, their logs are pruned in the background
+ALTER TABLE ton.log_poller_filters
+    ADD COLUMN is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN is_backfilled BOOLEAN NOT NULL DEFAULT FALSE;
+
+DROP INDEX ton.idx_log_poller_filters_chain_name;
+CREATE UNIQUE INDEX idx_log_poller_filters_chain_name ON ton.log_poller_filters (chain_id, name) WHERE NOT is_deleted;
+CREATE INDEX idx_log_poller_logs_expires_at ON ton.log_poller_logs (chain_id, expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX ton.idx_log_poller_logs_expires_at;
+DROP INDEX ton.idx_log_poller_filters_chain_name;
+DELETE FROM ton.log_poller_filters WHERE is_deleted;
+CREATE UNIQUE INDEX idx_log_poller_filters_chain_name ON ton.log_poller_filters (chain_id, name);
+
+ALTER TABLE ton.log_poller_filters
+    DROP COLUMN is_deleted,
+    DROP COLUMN is_backfilled;
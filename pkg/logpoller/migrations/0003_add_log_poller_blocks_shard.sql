@@ -0,0 +1,10 @@
+-- +goose Up
+-- the last processed block is resumed from after a restart, identified without a lookup
+ALTER TABLE ton.log_poller_blocks
+    ADD COLUMN workchain INTEGER NOT NULL DEFAULT -1,
+    ADD COLUMN shard BIGINT NOT NULL DEFAULT -9223372036854775808;
+
+-- +goose Down
+ALTER TABLE ton.log_poller_blocks
+    DROP COLUMN workchain,
+    DROP COLUMN shard;
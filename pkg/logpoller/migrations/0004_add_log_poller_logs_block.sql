@@ -0,0 +1,24 @@
+-- +goose Up
+-- blocks of the logs loaded by the block collector, NULL for the logs loaded by account
+ALTER TABLE ton.log_poller_logs
+    ADD COLUMN master_seq_no BIGINT,
+    ADD COLUMN shard_workchain INTEGER,
+    ADD COLUMN shard BIGINT,
+    ADD COLUMN shard_seq_no BIGINT,
+    ADD COLUMN shard_root_hash BYTEA,
+    ADD COLUMN shard_file_hash BYTEA,
+    ADD COLUMN block_time TIMESTAMPTZ;
+
+CREATE INDEX idx_log_poller_logs_master_seq_no ON ton.log_poller_logs (chain_id, master_seq_no) WHERE master_seq_no IS NOT NULL;
+
+-- +goose Down
+DROP INDEX ton.idx_log_poller_logs_master_seq_no;
+
+ALTER TABLE ton.log_poller_logs
+    DROP COLUMN master_seq_no,
+    DROP COLUMN shard_workchain,
+    DROP COLUMN shard,
+    DROP COLUMN shard_seq_no,
+    DROP COLUMN shard_root_hash,
+    DROP COLUMN shard_file_hash,
+    DROP COLUMN block_time;
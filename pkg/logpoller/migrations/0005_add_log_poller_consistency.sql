@@ -0,0 +1,15 @@
+-- +goose Up
+-- logs are rolled back along with the last block processed with them once a lite server reports it differently
+ALTER TABLE ton.log_poller_logs ADD COLUMN processed_seq_no BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE ton.log_poller_blocks ADD COLUMN is_finalized BOOLEAN NOT NULL DEFAULT FALSE;
+-- backfills ending past a rolled back block are redone
+ALTER TABLE ton.log_poller_filters ADD COLUMN backfilled_seq_no BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_log_poller_logs_processed_seq_no ON ton.log_poller_logs (chain_id, processed_seq_no);
+
+-- +goose Down
+DROP INDEX ton.idx_log_poller_logs_processed_seq_no;
+
+ALTER TABLE ton.log_poller_filters DROP COLUMN backfilled_seq_no;
+ALTER TABLE ton.log_poller_blocks DROP COLUMN is_finalized;
+ALTER TABLE ton.log_poller_logs DROP COLUMN processed_seq_no;
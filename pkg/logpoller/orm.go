@@ -0,0 +1,631 @@
+package logpoller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/sqlutil"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller/types"
+)
+
+var ErrFilterNotFound = errors.New("filter not found")
+
+// ORM persists the filters of the log poller, the logs they matched and the masterchain blocks
+// processed, so that they survive a node restart. Log queries skip the logs of deleted filters, which are
+// only removed by the next PruneLogs.
+type ORM interface {
+	// InsertFilter records a filter and returns its ID. Registering a filter under an existing name replaces it,
+	// it then needs a new backfill unless it still watches the same address and topic from the same block or later.
+	InsertFilter(ctx context.Context, flt types.Filter) (int64, error)
+	// SelectFilters returns every filter that was not deleted.
+	SelectFilters(ctx context.Context) ([]types.Filter, error)
+	// MarkFilterDeleted soft-deletes a filter by name, its logs are removed by the next PruneLogs.
+	// Returns ErrFilterNotFound if no filter is registered under that name.
+	MarkFilterDeleted(ctx context.Context, name string) error
+	// MarkFilterBackfilled records that the logs of a filter from its starting block through head were loaded.
+	MarkFilterBackfilled(ctx context.Context, id int64, head uint32) error
+	// PruneLogs removes expired logs along with deleted filters and their logs, returning the number of logs removed.
+	PruneLogs(ctx context.Context) (int64, error)
+	// InsertLogs records logs matched by filters, logs already recorded are ignored.
+	InsertLogs(ctx context.Context, logs []types.Log) error
+	// InsertProcessedBlock records the logs of a polling iteration along with the last block it processed, atomically.
+	InsertProcessedBlock(ctx context.Context, block types.Block, logs []types.Log) error
+	// SelectLatestBlock returns the last processed block, nil if no block was processed.
+	SelectLatestBlock(ctx context.Context) (*types.Block, error)
+	// SelectLatestBlocks returns up to limit processed blocks, latest first.
+	SelectLatestBlocks(ctx context.Context, limit uint32) ([]types.Block, error)
+	// MarkBlocksFinalized marks the processed blocks up to seqNo finalized.
+	MarkBlocksFinalized(ctx context.Context, seqNo uint32) error
+	// RollbackBlocks removes the processed blocks after seqNo and the logs processed with them, atomically.
+	// Filters backfilled through a removed block need a new backfill. Returns the number of logs removed.
+	RollbackBlocks(ctx context.Context, seqNo uint32) (int64, error)
+	// PruneBlocks removes the processed blocks but the latest ones and the latest finalized one,
+	// returning the number of blocks removed.
+	PruneBlocks(ctx context.Context, keep uint32) (int64, error)
+	// SelectLogs returns every log emitted by an address at a confidence, in the order they were recorded.
+	SelectLogs(ctx context.Context, evtSrcAddress *address.Address, confidence Confidence) ([]types.Log, error)
+	// FilteredLogs returns the logs emitted by an address with a topic whose payload passes every cell query,
+	// at the confidence of the options.
+	FilteredLogs(ctx context.Context, evtSrcAddress *address.Address, topic uint32, queries []CellQuery, options QueryOptions) (QueryResult, error)
+	// FilteredLogsWithParser returns the parsed logs emitted by an address with a topic at a confidence,
+	// that pass the filter.
+	FilteredLogsWithParser(ctx context.Context, evtSrcAddress *address.Address, topic uint32, parser types.LogParser, filter types.LogFilter, confidence Confidence) ([]any, error)
+}
+
+var _ ORM = (*DSORM)(nil)
+
+// DSORM is the SQL implementation of ORM, backed by the ton.log_poller_* tables.
+// Cell queries are pushed down to byte-substring comparisons of the stored payloads.
+type DSORM struct {
+	chainID         string
+	ds              sqlutil.DataSource
+	lggr            logger.SugaredLogger
+	cellQueryEngine *CellQueryEngine
+}
+
+// NewORM creates a DSORM scoped to a single chain.
+func NewORM(chainID string, ds sqlutil.DataSource, lggr logger.Logger) *DSORM {
+	lggr = logger.Named(lggr, "LogPollerORM")
+	return &DSORM{
+		chainID:         chainID,
+		ds:              ds,
+		lggr:            logger.Sugared(lggr),
+		cellQueryEngine: NewCellQueryEngine(lggr),
+	}
+}
+
+// dbFilter is the row representation of a filter in ton.log_poller_filters.
+type dbFilter struct {
+	ID              int64     `db:"id"`
+	ChainID         string    `db:"chain_id"`
+	Name            string    `db:"name"`
+	Address         string    `db:"address"`
+	EventName       string    `db:"event_name"`
+	EventTopic      int64     `db:"event_topic"`
+	StartingSeqNo   int64     `db:"starting_seq_no"`
+	RetentionSecs   int64     `db:"retention_secs"`
+	IsDeleted       bool      `db:"is_deleted"`
+	IsBackfilled    bool      `db:"is_backfilled"`
+	BackfilledSeqNo int64     `db:"backfilled_seq_no"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+// dbBlock is the row representation of a processed block in ton.log_poller_blocks.
+type dbBlock struct {
+	ChainID     string    `db:"chain_id"`
+	Workchain   int32     `db:"workchain"`
+	Shard       int64     `db:"shard"`
+	SeqNo       int64     `db:"seq_no"`
+	RootHash    []byte    `db:"root_hash"`
+	FileHash    []byte    `db:"file_hash"`
+	ProcessedAt time.Time `db:"processed_at"`
+	IsFinalized bool      `db:"is_finalized"`
+}
+
+// dbLog is the row representation of a log in ton.log_poller_logs.
+type dbLog struct {
+	ID         int64        `db:"id"`
+	FilterID   int64        `db:"filter_id"`
+	ChainID    string       `db:"chain_id"`
+	Address    string       `db:"address"`
+	EventTopic int64        `db:"event_topic"`
+	TxHash     []byte       `db:"tx_hash"`
+	TxLT       int64        `db:"tx_lt"`
+	MsgIndex   int32        `db:"msg_index"`
+	Data       []byte       `db:"data"`
+	Payload    []byte       `db:"payload"`
+	CreatedAt  time.Time    `db:"created_at"`
+	ExpiresAt  sql.NullTime `db:"expires_at"`
+
+	ProcessedSeqNo int64 `db:"processed_seq_no"`
+
+	MasterSeqNo    sql.NullInt64 `db:"master_seq_no"`
+	ShardWorkchain sql.NullInt32 `db:"shard_workchain"`
+	Shard          sql.NullInt64 `db:"shard"`
+	ShardSeqNo     sql.NullInt64 `db:"shard_seq_no"`
+	ShardRootHash  []byte        `db:"shard_root_hash"`
+	ShardFileHash  []byte        `db:"shard_file_hash"`
+	BlockTime      sql.NullTime  `db:"block_time"`
+}
+
+func (o *DSORM) InsertFilter(ctx context.Context, flt types.Filter) (int64, error) {
+	query := `INSERT INTO ton.log_poller_filters
+		(chain_id, name, address, event_name, event_topic, starting_seq_no, retention_secs, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (chain_id, name) WHERE NOT is_deleted DO UPDATE SET
+			address = EXCLUDED.address, event_name = EXCLUDED.event_name, event_topic = EXCLUDED.event_topic,
+			starting_seq_no = EXCLUDED.starting_seq_no, retention_secs = EXCLUDED.retention_secs,
+			is_backfilled = ton.log_poller_filters.is_backfilled
+				AND ton.log_poller_filters.address = EXCLUDED.address
+				AND ton.log_poller_filters.event_topic = EXCLUDED.event_topic
+				AND ton.log_poller_filters.starting_seq_no <= EXCLUDED.starting_seq_no
+		RETURNING id`
+	var id int64
+	err := o.ds.GetContext(ctx, &id, query,
+		o.chainID, flt.Name, flt.Address.StringRaw(), flt.EventName, int64(flt.EventTopic), int64(flt.StartingSeqNo), int64(flt.Retention/time.Second))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert filter %s: %w", flt.Name, err)
+	}
+	return id, nil
+}
+
+func (o *DSORM) SelectFilters(ctx context.Context) ([]types.Filter, error) {
+	var rows []dbFilter
+	query := `SELECT * FROM ton.log_poller_filters WHERE chain_id = $1 AND NOT is_deleted ORDER BY id ASC`
+	if err := o.ds.SelectContext(ctx, &rows, query, o.chainID); err != nil {
+		return nil, fmt.Errorf("failed to select filters: %w", err)
+	}
+
+	filters := make([]types.Filter, 0, len(rows))
+	for _, row := range rows {
+		flt, err := row.toFilter()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, flt)
+	}
+	return filters, nil
+}
+
+func (o *DSORM) MarkFilterDeleted(ctx context.Context, name string) error {
+	query := `UPDATE ton.log_poller_filters SET is_deleted = TRUE WHERE chain_id = $1 AND name = $2 AND NOT is_deleted`
+	res, err := o.ds.ExecContext(ctx, query, o.chainID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete filter %s: %w", name, err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for filter %s: %w", name, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrFilterNotFound, name)
+	}
+	return nil
+}
+
+func (o *DSORM) MarkFilterBackfilled(ctx context.Context, id int64, head uint32) error {
+	query := `UPDATE ton.log_poller_filters SET is_backfilled = TRUE, backfilled_seq_no = $3 WHERE chain_id = $1 AND id = $2`
+	if _, err := o.ds.ExecContext(ctx, query, o.chainID, id, int64(head)); err != nil {
+		return fmt.Errorf("failed to mark filter %d backfilled: %w", id, err)
+	}
+	return nil
+}
+
+func (o *DSORM) PruneLogs(ctx context.Context) (int64, error) {
+	var pruned int64
+	err := sqlutil.TransactDataSource(ctx, o.ds, nil, func(tx sqlutil.DataSource) error {
+		query := `DELETE FROM ton.log_poller_logs
+			WHERE chain_id = $1 AND (expires_at < NOW() OR filter_id IN (
+				SELECT id FROM ton.log_poller_filters WHERE chain_id = $1 AND is_deleted
+			))`
+		res, err := tx.ExecContext(ctx, query, o.chainID)
+		if err != nil {
+			return fmt.Errorf("failed to delete logs: %w", err)
+		}
+		if pruned, err = res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected for logs: %w", err)
+		}
+
+		query = `DELETE FROM ton.log_poller_filters WHERE chain_id = $1 AND is_deleted`
+		if _, err = tx.ExecContext(ctx, query, o.chainID); err != nil {
+			return fmt.Errorf("failed to delete filters: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune logs: %w", err)
+	}
+	return pruned, nil
+}
+
+func (o *DSORM) InsertLogs(ctx context.Context, logs []types.Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	rows := make([]dbLog, 0, len(logs))
+	for i, log := range logs {
+		payload, err := o.cellQueryEngine.ExtractCellPayload(log.Data, i)
+		if err != nil {
+			return err
+		}
+		var expiresAt sql.NullTime
+		if log.ExpiresAt != nil {
+			expiresAt = sql.NullTime{Time: *log.ExpiresAt, Valid: true}
+		}
+		row := dbLog{
+			FilterID:       log.FilterID,
+			ChainID:        o.chainID,
+			Address:        log.Address.StringRaw(),
+			EventTopic:     int64(log.Topic),
+			TxHash:         log.TxHash,
+			TxLT:           int64(log.TxLT),     //nolint:gosec // LTs stay far below 2^63
+			MsgIndex:       int32(log.MsgIndex), //nolint:gosec // a transaction has at most 255 outgoing messages
+			Data:           log.Data,
+			Payload:        payload,
+			ExpiresAt:      expiresAt,
+			ProcessedSeqNo: int64(log.ProcessedSeqNo),
+		}
+		if log.ShardBlock != nil {
+			row.MasterSeqNo = sql.NullInt64{Int64: int64(log.SeqNo), Valid: true}
+			row.ShardWorkchain = sql.NullInt32{Int32: log.ShardBlock.Workchain, Valid: true}
+			row.Shard = sql.NullInt64{Int64: log.ShardBlock.Shard, Valid: true}
+			row.ShardSeqNo = sql.NullInt64{Int64: int64(log.ShardBlock.SeqNo), Valid: true}
+			row.ShardRootHash = log.ShardBlock.RootHash
+			row.ShardFileHash = log.ShardBlock.FileHash
+			row.BlockTime = sql.NullTime{Time: log.BlockTime, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+
+	query := `INSERT INTO ton.log_poller_logs
+		(filter_id, chain_id, address, event_topic, tx_hash, tx_lt, msg_index, data, payload, created_at, expires_at, processed_seq_no,
+		 master_seq_no, shard_workchain, shard, shard_seq_no, shard_root_hash, shard_file_hash, block_time)
+		VALUES (:filter_id, :chain_id, :address, :event_topic, :tx_hash, :tx_lt, :msg_index, :data, :payload, NOW(), :expires_at, :processed_seq_no,
+		 :master_seq_no, :shard_workchain, :shard, :shard_seq_no, :shard_root_hash, :shard_file_hash, :block_time)
+		ON CONFLICT (chain_id, filter_id, tx_hash, msg_index) DO NOTHING`
+	if _, err := o.ds.NamedExecContext(ctx, query, rows); err != nil {
+		return fmt.Errorf("failed to insert %d logs: %w", len(rows), err)
+	}
+	return nil
+}
+
+func (o *DSORM) InsertProcessedBlock(ctx context.Context, block types.Block, logs []types.Log) error {
+	return sqlutil.Transact(ctx, o.withDS, o.ds, nil, func(orm *DSORM) error {
+		if err := orm.InsertLogs(ctx, logs); err != nil {
+			return err
+		}
+		query := `INSERT INTO ton.log_poller_blocks (chain_id, workchain, shard, seq_no, root_hash, file_hash, processed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+			ON CONFLICT (chain_id, seq_no) DO UPDATE SET
+				workchain = EXCLUDED.workchain, shard = EXCLUDED.shard, root_hash = EXCLUDED.root_hash,
+				file_hash = EXCLUDED.file_hash, processed_at = NOW(), is_finalized = FALSE`
+		_, err := orm.ds.ExecContext(ctx, query, o.chainID, block.Workchain, block.Shard, int64(block.SeqNo), block.RootHash, block.FileHash)
+		if err != nil {
+			return fmt.Errorf("failed to insert block %d: %w", block.SeqNo, err)
+		}
+		return nil
+	})
+}
+
+func (o *DSORM) SelectLatestBlock(ctx context.Context) (*types.Block, error) {
+	var row dbBlock
+	query := `SELECT * FROM ton.log_poller_blocks WHERE chain_id = $1 ORDER BY seq_no DESC LIMIT 1`
+	err := o.ds.GetContext(ctx, &row, query, o.chainID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select latest block: %w", err)
+	}
+	block := row.toBlock()
+	return &block, nil
+}
+
+func (o *DSORM) SelectLatestBlocks(ctx context.Context, limit uint32) ([]types.Block, error) {
+	var rows []dbBlock
+	query := `SELECT * FROM ton.log_poller_blocks WHERE chain_id = $1 ORDER BY seq_no DESC LIMIT $2`
+	if err := o.ds.SelectContext(ctx, &rows, query, o.chainID, int64(limit)); err != nil {
+		return nil, fmt.Errorf("failed to select latest blocks: %w", err)
+	}
+	blocks := make([]types.Block, 0, len(rows))
+	for _, row := range rows {
+		blocks = append(blocks, row.toBlock())
+	}
+	return blocks, nil
+}
+
+func (o *DSORM) MarkBlocksFinalized(ctx context.Context, seqNo uint32) error {
+	query := `UPDATE ton.log_poller_blocks SET is_finalized = TRUE WHERE chain_id = $1 AND seq_no <= $2 AND NOT is_finalized`
+	if _, err := o.ds.ExecContext(ctx, query, o.chainID, int64(seqNo)); err != nil {
+		return fmt.Errorf("failed to mark blocks up to %d finalized: %w", seqNo, err)
+	}
+	return nil
+}
+
+func (o *DSORM) RollbackBlocks(ctx context.Context, seqNo uint32) (int64, error) {
+	var removed int64
+	err := sqlutil.TransactDataSource(ctx, o.ds, nil, func(tx sqlutil.DataSource) error {
+		query := `DELETE FROM ton.log_poller_logs WHERE chain_id = $1 AND processed_seq_no > $2`
+		res, err := tx.ExecContext(ctx, query, o.chainID, int64(seqNo))
+		if err != nil {
+			return fmt.Errorf("failed to delete logs: %w", err)
+		}
+		if removed, err = res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected for logs: %w", err)
+		}
+
+		query = `DELETE FROM ton.log_poller_blocks WHERE chain_id = $1 AND seq_no > $2`
+		if _, err = tx.ExecContext(ctx, query, o.chainID, int64(seqNo)); err != nil {
+			return fmt.Errorf("failed to delete blocks: %w", err)
+		}
+
+		query = `UPDATE ton.log_poller_filters SET is_backfilled = FALSE
+			WHERE chain_id = $1 AND is_backfilled AND backfilled_seq_no > $2`
+		if _, err = tx.ExecContext(ctx, query, o.chainID, int64(seqNo)); err != nil {
+			return fmt.Errorf("failed to reset backfills: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll back blocks after %d: %w", seqNo, err)
+	}
+	return removed, nil
+}
+
+func (o *DSORM) PruneBlocks(ctx context.Context, keep uint32) (int64, error) {
+	query := `DELETE FROM ton.log_poller_blocks
+		WHERE chain_id = $1 AND seq_no <= (SELECT MAX(seq_no) FROM ton.log_poller_blocks WHERE chain_id = $1) - $2
+			AND seq_no < (SELECT COALESCE(MAX(seq_no), 0) FROM ton.log_poller_blocks WHERE chain_id = $1 AND is_finalized)`
+	res, err := o.ds.ExecContext(ctx, query, o.chainID, int64(keep))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune blocks: %w", err)
+	}
+	pruned, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected for blocks: %w", err)
+	}
+	return pruned, nil
+}
+
+// withDS returns a copy of the ORM using another datasource, such as a transaction.
+func (o *DSORM) withDS(ds sqlutil.DataSource) *DSORM {
+	return &DSORM{chainID: o.chainID, ds: ds, lggr: o.lggr, cellQueryEngine: o.cellQueryEngine}
+}
+
+func (o *DSORM) SelectLogs(ctx context.Context, evtSrcAddress *address.Address, confidence Confidence) ([]types.Log, error) {
+	visible, err := visibleLogsConditions(confidence)
+	if err != nil {
+		return nil, err
+	}
+	var rows []dbLog
+	query := `SELECT * FROM ton.log_poller_logs WHERE chain_id = $1 AND address = $2` + visible + ` ORDER BY id ASC`
+	if err := o.ds.SelectContext(ctx, &rows, query, o.chainID, evtSrcAddress.StringRaw()); err != nil {
+		return nil, fmt.Errorf("failed to select logs of %s: %w", evtSrcAddress, err)
+	}
+	return toLogs(rows)
+}
+
+func (o *DSORM) FilteredLogs(
+	ctx context.Context,
+	evtSrcAddress *address.Address,
+	topic uint32,
+	queries []CellQuery,
+	options QueryOptions,
+) (QueryResult, error) {
+	args := []any{o.chainID, evtSrcAddress.StringRaw(), int64(topic)}
+	conditions, args, err := cellQueryConditions(queries, args)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	orderBy, err := orderByClause(options.SortBy)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	visible, err := visibleLogsConditions(options.Confidence)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	from := `FROM ton.log_poller_logs WHERE chain_id = $1 AND address = $2 AND event_topic = $3` + visible + conditions
+
+	var total int
+	if err = o.ds.GetContext(ctx, &total, `SELECT COUNT(*) `+from, args...); err != nil {
+		return QueryResult{}, fmt.Errorf("failed to count logs of %s: %w", evtSrcAddress, err)
+	}
+
+	query := `SELECT * ` + from + orderBy
+	if options.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", options.Limit)
+	}
+	if options.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", options.Offset)
+	}
+	var rows []dbLog
+	if err = o.ds.SelectContext(ctx, &rows, query, args...); err != nil {
+		return QueryResult{}, fmt.Errorf("failed to select logs of %s: %w", evtSrcAddress, err)
+	}
+	logs, err := toLogs(rows)
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	return QueryResult{
+		Logs:    logs,
+		HasMore: max(options.Offset, 0)+len(logs) < total,
+		Total:   total,
+	}, nil
+}
+
+func (o *DSORM) FilteredLogsWithParser(
+	ctx context.Context,
+	evtSrcAddress *address.Address,
+	topic uint32,
+	parser types.LogParser,
+	filter types.LogFilter,
+	confidence Confidence,
+) ([]any, error) {
+	visible, err := visibleLogsConditions(confidence)
+	if err != nil {
+		return nil, err
+	}
+	var rows []dbLog
+	query := `SELECT * FROM ton.log_poller_logs WHERE chain_id = $1 AND address = $2 AND event_topic = $3` + visible + ` ORDER BY id ASC`
+	if err := o.ds.SelectContext(ctx, &rows, query, o.chainID, evtSrcAddress.StringRaw(), int64(topic)); err != nil {
+		return nil, fmt.Errorf("failed to select logs of %s: %w", evtSrcAddress, err)
+	}
+
+	results := make([]any, 0, len(rows))
+	for _, row := range rows {
+		c, err := cell.FromBOC(row.Data)
+		if err != nil {
+			o.lggr.Warnw("Failed to decode log data from BoC", "id", row.ID, "err", err)
+			continue
+		}
+
+		parsedEvent, err := parser(c)
+		if err != nil {
+			o.lggr.Warnw("Parser failed to process log data", "id", row.ID, "err", err)
+			continue
+		}
+
+		if filter != nil && !filter(parsedEvent) {
+			continue
+		}
+
+		results = append(results, parsedEvent)
+	}
+	return results, nil
+}
+
+// visibleLogsConditions restricts a query of the logs of the chain $1 to the logs of filters that were not deleted,
+// their logs are only removed by the next PruneLogs, and to the logs processed at a confidence.
+func visibleLogsConditions(confidence Confidence) (string, error) {
+	conditions := ` AND filter_id IN (SELECT id FROM ton.log_poller_filters WHERE chain_id = $1 AND NOT is_deleted)`
+	switch confidence {
+	case ConfidenceLatest, "":
+	case ConfidenceFinalized:
+		conditions += ` AND processed_seq_no <= (SELECT COALESCE(MAX(seq_no), -1) FROM ton.log_poller_blocks WHERE chain_id = $1 AND is_finalized)`
+	default:
+		return "", fmt.Errorf("unsupported confidence: %s", confidence)
+	}
+	return conditions, nil
+}
+
+// cellQueryConditions translates cell queries into comparisons of byte substrings of the stored payload,
+// appending their values to args. As in CellQueryEngine, a payload too short for a query does not pass it.
+func cellQueryConditions(queries []CellQuery, args []any) (string, []any, error) {
+	var sb strings.Builder
+	for i, query := range queries {
+		op, err := sqlOperator(query.Operator)
+		if err != nil {
+			return "", nil, fmt.Errorf("cell query #%d: %w", i, err)
+		}
+		end := query.Offset + uint(len(query.Value))
+		args = append(args, query.Value)
+		// substring positions are 1-based
+		fmt.Fprintf(&sb, " AND octet_length(payload) >= %d AND substring(payload FROM %d FOR %d) %s $%d",
+			end, query.Offset+1, len(query.Value), op, len(args))
+	}
+	return sb.String(), args, nil
+}
+
+// sqlOperator returns the SQL comparison of an operator, bytea values compare byte-wise like bytes.Compare.
+func sqlOperator(op Operator) (string, error) {
+	switch op {
+	case EQ, GT, GTE, LT, LTE:
+		return string(op), nil
+	case NEQ:
+		return "<>", nil
+	default:
+		return "", fmt.Errorf("unsupported operator: %s", op)
+	}
+}
+
+// orderByClause returns the ORDER BY clause of a query, logs are otherwise returned in the order they were recorded.
+func orderByClause(sortBy []SortBy) (string, error) {
+	terms := make([]string, 0, len(sortBy)+1)
+	for _, s := range sortBy {
+		if s.Field != SortByTxLT {
+			return "", fmt.Errorf("unsupported sort field: %s", s.Field)
+		}
+		switch s.Order {
+		case ASC, DESC:
+		default:
+			return "", fmt.Errorf("unsupported sort order: %s", s.Order)
+		}
+		terms = append(terms, fmt.Sprintf("%s %s", s.Field, s.Order))
+	}
+	terms = append(terms, "id ASC")
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+func (r dbFilter) toFilter() (types.Filter, error) {
+	addr, err := address.ParseRawAddr(r.Address)
+	if err != nil {
+		return types.Filter{}, fmt.Errorf("invalid address for filter %s: %w", r.Name, err)
+	}
+	return types.Filter{
+		ID:              r.ID,
+		Name:            r.Name,
+		Address:         *addr,
+		EventName:       r.EventName,
+		EventTopic:      uint32(r.EventTopic),    //nolint:gosec // topic is stored from a uint32
+		StartingSeqNo:   uint32(r.StartingSeqNo), //nolint:gosec // seqno is stored from a uint32
+		Retention:       time.Duration(r.RetentionSecs) * time.Second,
+		IsDeleted:       r.IsDeleted,
+		IsBackfilled:    r.IsBackfilled,
+		BackfilledSeqNo: uint32(r.BackfilledSeqNo), //nolint:gosec // seqno is stored from a uint32
+	}, nil
+}
+
+func (r dbBlock) toBlock() types.Block {
+	return types.Block{
+		Workchain:   r.Workchain,
+		Shard:       r.Shard,
+		SeqNo:       uint32(r.SeqNo), //nolint:gosec // seqno is stored from a uint32
+		RootHash:    r.RootHash,
+		FileHash:    r.FileHash,
+		ProcessedAt: r.ProcessedAt,
+		IsFinalized: r.IsFinalized,
+	}
+}
+
+func toLogs(rows []dbLog) ([]types.Log, error) {
+	logs := make([]types.Log, 0, len(rows))
+	for _, row := range rows {
+		log, err := row.toLog()
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+func (r dbLog) toLog() (types.Log, error) {
+	addr, err := address.ParseRawAddr(r.Address)
+	if err != nil {
+		return types.Log{}, fmt.Errorf("invalid address for log %d: %w", r.ID, err)
+	}
+	var expiresAt *time.Time
+	if r.ExpiresAt.Valid {
+		expiresAt = &r.ExpiresAt.Time
+	}
+	var shardBlock *ton.BlockIDExt
+	if r.MasterSeqNo.Valid {
+		shardBlock = &ton.BlockIDExt{
+			Workchain: r.ShardWorkchain.Int32,
+			Shard:     r.Shard.Int64,
+			SeqNo:     uint32(r.ShardSeqNo.Int64), //nolint:gosec // seqno is stored from a uint32
+			RootHash:  r.ShardRootHash,
+			FileHash:  r.ShardFileHash,
+		}
+	}
+	return types.Log{
+		ID:             r.ID,
+		FilterID:       r.FilterID,
+		SeqNo:          uint32(r.MasterSeqNo.Int64), //nolint:gosec // seqno is stored from a uint32
+		ShardBlock:     shardBlock,
+		BlockTime:      r.BlockTime.Time,
+		Address:        *addr,
+		TxHash:         r.TxHash,
+		TxLT:           uint64(r.TxLT),       //nolint:gosec // LT is stored from a uint64
+		MsgIndex:       uint32(r.MsgIndex),   //nolint:gosec // index is stored from a uint32
+		Topic:          uint32(r.EventTopic), //nolint:gosec // topic is stored from a uint32
+		Data:           r.Data,
+		CreatedAt:      r.CreatedAt,
+		ReceivedAt:     r.CreatedAt,
+		ExpiresAt:      expiresAt,
+		ProcessedSeqNo: uint32(r.ProcessedSeqNo), //nolint:gosec // seqno is stored from a uint32
+	}, nil
+}
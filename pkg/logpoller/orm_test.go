@@ -0,0 +1,360 @@
+package logpoller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/sqlutil/sqltest"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller/types"
+	"github.com/smartcontractkit/chainlink-ton/pkg/migrate"
+)
+
+// newTestORM migrates a test database and returns ORMs scoped to chainIDs, the schema needs Postgres.
+func newTestORM(t *testing.T, chainIDs ...string) []*DSORM {
+	sqltest.SkipInMemory(t)
+	db := sqltest.NewDB(t, sqltest.TestURL(t))
+	lggr := logger.Test(t)
+	require.NoError(t, Migrate(t.Context(), lggr, db))
+
+	orms := make([]*DSORM, 0, len(chainIDs))
+	for _, chainID := range chainIDs {
+		orms = append(orms, NewORM(chainID, db, lggr))
+	}
+	return orms
+}
+
+func testAddress(b byte) *address.Address {
+	data := make([]byte, 32)
+	data[31] = b
+	return address.NewAddress(0, 0, data)
+}
+
+// testLog returns a log of filterID whose cell payload is payload.
+func testLog(filterID int64, addr *address.Address, topic uint32, lt uint64, payload []byte) types.Log {
+	return types.Log{
+		FilterID: filterID,
+		Address:  *addr,
+		TxHash:   []byte{byte(lt >> 8), byte(lt)},
+		TxLT:     lt,
+		Topic:    topic,
+		Data:     cell.BeginCell().MustStoreSlice(payload, uint(len(payload))*8).EndCell().ToBOC(),
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := migrate.Load(Migrations())
+	require.NoError(t, err)
+	require.Len(t, migrations, 5)
+	for i, m := range migrations {
+		require.Equal(t, int64(i+1), m.Version, m.Name)
+		require.NotEmpty(t, m.Up, m.Name)
+	}
+}
+
+func TestDSORM_Filters(t *testing.T) {
+	orms := newTestORM(t, "-239", "-3")
+	orm, other := orms[0], orms[1]
+	ctx := t.Context()
+
+	flt := types.Filter{Name: "ccip", Address: *testAddress(1), EventName: "CCIPMessageSent", EventTopic: 7, StartingSeqNo: 100, Retention: time.Hour}
+	id, err := orm.InsertFilter(ctx, flt)
+	require.NoError(t, err)
+	require.NoError(t, orm.MarkFilterBackfilled(ctx, id, 150))
+
+	filters, err := orm.SelectFilters(ctx)
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	require.Equal(t, id, filters[0].ID)
+	require.Equal(t, flt.Name, filters[0].Name)
+	require.True(t, flt.Address.Equals(&filters[0].Address))
+	require.Equal(t, flt.EventName, filters[0].EventName)
+	require.Equal(t, flt.EventTopic, filters[0].EventTopic)
+	require.Equal(t, flt.StartingSeqNo, filters[0].StartingSeqNo)
+	require.Equal(t, flt.Retention, filters[0].Retention)
+	require.True(t, filters[0].IsBackfilled)
+	require.Equal(t, uint32(150), filters[0].BackfilledSeqNo)
+
+	filters, err = other.SelectFilters(ctx)
+	require.NoError(t, err)
+	require.Empty(t, filters)
+
+	testCases := []struct {
+		name       string
+		update     func(flt types.Filter) types.Filter
+		backfilled bool
+	}{
+		{
+			name:       "later starting block keeps the backfill",
+			update:     func(flt types.Filter) types.Filter { flt.StartingSeqNo = 120; return flt },
+			backfilled: true,
+		},
+		{
+			name:       "earlier starting block needs a backfill",
+			update:     func(flt types.Filter) types.Filter { flt.StartingSeqNo = 50; return flt },
+			backfilled: false,
+		},
+		{
+			name:       "other topic needs a backfill",
+			update:     func(flt types.Filter) types.Filter { flt.EventTopic = 8; return flt },
+			backfilled: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, orm.MarkFilterBackfilled(ctx, id, 150))
+			replacedID, err := orm.InsertFilter(ctx, tc.update(flt))
+			require.NoError(t, err)
+			require.Equal(t, id, replacedID)
+
+			filters, err := orm.SelectFilters(ctx)
+			require.NoError(t, err)
+			require.Len(t, filters, 1)
+			require.Equal(t, tc.backfilled, filters[0].IsBackfilled)
+		})
+	}
+
+	require.NoError(t, orm.MarkFilterDeleted(ctx, flt.Name))
+	require.ErrorIs(t, orm.MarkFilterDeleted(ctx, flt.Name), ErrFilterNotFound)
+	filters, err = orm.SelectFilters(ctx)
+	require.NoError(t, err)
+	require.Empty(t, filters)
+
+	// a deleted filter frees its name
+	newID, err := orm.InsertFilter(ctx, flt)
+	require.NoError(t, err)
+	require.NotEqual(t, id, newID)
+}
+
+func TestDSORM_LogsAndBlocks(t *testing.T) {
+	orm := newTestORM(t, "-239")[0]
+	ctx := t.Context()
+	addr := testAddress(1)
+
+	block, err := orm.SelectLatestBlock(ctx)
+	require.NoError(t, err)
+	require.Nil(t, block)
+
+	id, err := orm.InsertFilter(ctx, types.Filter{Name: "ccip", Address: *addr, EventTopic: 7})
+	require.NoError(t, err)
+
+	logs := []types.Log{testLog(id, addr, 7, 10, []byte{1}), testLog(id, addr, 7, 11, []byte{2})}
+	for i := range logs {
+		logs[i].ProcessedSeqNo = 100
+	}
+	require.NoError(t, orm.InsertProcessedBlock(ctx, types.Block{Workchain: -1, Shard: -1 << 63, SeqNo: 100, RootHash: []byte{1}, FileHash: []byte{2}}, logs))
+	// logs already recorded are ignored
+	require.NoError(t, orm.InsertLogs(ctx, logs[:1]))
+
+	stored, err := orm.SelectLogs(ctx, addr, ConfidenceLatest)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	for i, log := range stored {
+		require.Equal(t, logs[i].FilterID, log.FilterID)
+		require.True(t, addr.Equals(&log.Address))
+		require.Equal(t, logs[i].TxHash, log.TxHash)
+		require.Equal(t, logs[i].TxLT, log.TxLT)
+		require.Equal(t, logs[i].Topic, log.Topic)
+		require.Equal(t, logs[i].Data, log.Data)
+		require.Equal(t, uint32(100), log.ProcessedSeqNo)
+	}
+
+	block, err = orm.SelectLatestBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint32(100), block.SeqNo)
+	require.Equal(t, int32(-1), block.Workchain)
+	require.Equal(t, []byte{1}, block.RootHash)
+	require.Equal(t, []byte{2}, block.FileHash)
+	require.False(t, block.IsFinalized)
+
+	// logs of deleted filters are pruned
+	require.NoError(t, orm.MarkFilterDeleted(ctx, "ccip"))
+	pruned, err := orm.PruneLogs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), pruned)
+	stored, err = orm.SelectLogs(ctx, addr, ConfidenceLatest)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+}
+
+// TestDSORM_FilteredLogs checks that the cell queries pushed down to SQL select the same logs as the in-memory
+// CellQueryEngine.
+func TestDSORM_FilteredLogs(t *testing.T) {
+	orm := newTestORM(t, "-239")[0]
+	ctx := t.Context()
+	addr := testAddress(1)
+
+	id, err := orm.InsertFilter(ctx, types.Filter{Name: "ccip", Address: *addr, EventTopic: 7})
+	require.NoError(t, err)
+	payloads := [][]byte{
+		{0x00, 0x01, 0x02},
+		{0x00, 0x02, 0x02},
+		{0x00, 0x02},
+		{0x01, 0xff, 0x00, 0x00},
+		{0x00},
+	}
+	logs := make([]types.Log, 0, len(payloads))
+	for i, payload := range payloads {
+		logs = append(logs, testLog(id, addr, 7, uint64(10+i), payload)) //nolint:gosec // small test index
+	}
+	// other topics and addresses are never selected
+	logs = append(logs, testLog(id, addr, 8, 20, payloads[0]), testLog(id, testAddress(2), 7, 21, payloads[0]))
+	require.NoError(t, orm.InsertLogs(ctx, logs))
+
+	testCases := []struct {
+		name    string
+		queries []CellQuery
+	}{
+		{name: "no queries"},
+		{name: "equal", queries: []CellQuery{{Offset: 1, Operator: EQ, Value: []byte{0x02}}}},
+		{name: "not equal", queries: []CellQuery{{Offset: 1, Operator: NEQ, Value: []byte{0x02}}}},
+		{name: "greater", queries: []CellQuery{{Offset: 0, Operator: GT, Value: []byte{0x00, 0x01}}}},
+		{name: "greater or equal", queries: []CellQuery{{Offset: 0, Operator: GTE, Value: []byte{0x00, 0x02}}}},
+		{name: "less", queries: []CellQuery{{Offset: 0, Operator: LT, Value: []byte{0x00, 0x02}}}},
+		{name: "less or equal", queries: []CellQuery{{Offset: 1, Operator: LTE, Value: []byte{0x02, 0x02}}}},
+		{name: "payload too short", queries: []CellQuery{{Offset: 2, Operator: NEQ, Value: []byte{0x07}}}},
+		{name: "several queries", queries: []CellQuery{
+			{Offset: 0, Operator: EQ, Value: []byte{0x00}},
+			{Offset: 1, Operator: GTE, Value: []byte{0x02}},
+			{Offset: 2, Operator: EQ, Value: []byte{0x02}},
+		}},
+	}
+	engine := NewCellQueryEngine(logger.Test(t))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var expected []uint64
+			for i, payload := range payloads {
+				passes, err := engine.PassesAllQueries(payload, tc.queries, i)
+				require.NoError(t, err)
+				if passes {
+					expected = append(expected, logs[i].TxLT)
+				}
+			}
+
+			result, err := orm.FilteredLogs(ctx, addr, 7, tc.queries, QueryOptions{Confidence: ConfidenceLatest})
+			require.NoError(t, err)
+			actual := make([]uint64, 0, len(result.Logs))
+			for _, log := range result.Logs {
+				actual = append(actual, log.TxLT)
+			}
+			require.ElementsMatch(t, expected, actual)
+			require.Equal(t, len(expected), result.Total)
+		})
+	}
+
+	result, err := orm.FilteredLogs(ctx, addr, 7, nil, QueryOptions{
+		Limit: 2, Offset: 1, SortBy: []SortBy{{Field: SortByTxLT, Order: DESC}}, Confidence: ConfidenceLatest,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Logs, 2)
+	require.Equal(t, uint64(13), result.Logs[0].TxLT)
+	require.Equal(t, uint64(12), result.Logs[1].TxLT)
+	require.True(t, result.HasMore)
+	require.Equal(t, len(payloads), result.Total)
+
+	_, err = orm.FilteredLogs(ctx, addr, 7, []CellQuery{{Operator: "~", Value: []byte{0}}}, QueryOptions{})
+	require.ErrorContains(t, err, "unsupported operator")
+}
+
+// TestORM_VisibleLogs checks that both stores skip the logs of deleted filters, and the logs of blocks not yet
+// finalized when finalized logs are asked for.
+func TestORM_VisibleLogs(t *testing.T) {
+	stores := map[string]func(t *testing.T) ORM{
+		"in-memory": func(t *testing.T) ORM { return NewInMemoryStore(logger.Test(t)) },
+		"postgres":  func(t *testing.T) ORM { return newTestORM(t, "-239")[0] },
+	}
+	// the payload of a test log is its LT
+	parser := func(c *cell.Cell) (any, error) { return c.BeginParse().LoadUInt(8) }
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			ctx := t.Context()
+			addr := testAddress(1)
+
+			active, err := store.InsertFilter(ctx, types.Filter{Name: "active", Address: *addr, EventTopic: 7})
+			require.NoError(t, err)
+			deleted, err := store.InsertFilter(ctx, types.Filter{Name: "deleted", Address: *addr, EventTopic: 7})
+			require.NoError(t, err)
+			for seqNo, filterLTs := range map[uint32]map[int64]uint64{
+				100: {active: 10, deleted: 11},
+				101: {active: 12, deleted: 13},
+			} {
+				var logs []types.Log
+				for filterID, lt := range filterLTs {
+					log := testLog(filterID, addr, 7, lt, []byte{byte(lt)})
+					log.ProcessedSeqNo = seqNo
+					logs = append(logs, log)
+				}
+				block := types.Block{Workchain: -1, Shard: -1 << 63, SeqNo: seqNo, RootHash: []byte{byte(seqNo)}, FileHash: []byte{byte(seqNo)}}
+				require.NoError(t, store.InsertProcessedBlock(ctx, block, logs))
+			}
+			require.NoError(t, store.MarkBlocksFinalized(ctx, 100))
+			require.NoError(t, store.MarkFilterDeleted(ctx, "deleted"))
+
+			testCases := []struct {
+				name       string
+				confidence Confidence
+				lts        []uint64
+			}{
+				{name: "latest by default", lts: []uint64{10, 12}},
+				{name: "latest", confidence: ConfidenceLatest, lts: []uint64{10, 12}},
+				{name: "finalized", confidence: ConfidenceFinalized, lts: []uint64{10}},
+			}
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					logs, err := store.SelectLogs(ctx, addr, tc.confidence)
+					require.NoError(t, err)
+					lts := make([]uint64, 0, len(logs))
+					for _, log := range logs {
+						lts = append(lts, log.TxLT)
+					}
+					require.ElementsMatch(t, tc.lts, lts)
+
+					result, err := store.FilteredLogs(ctx, addr, 7, nil, QueryOptions{Confidence: tc.confidence})
+					require.NoError(t, err)
+					lts = lts[:0]
+					for _, log := range result.Logs {
+						lts = append(lts, log.TxLT)
+					}
+					require.ElementsMatch(t, tc.lts, lts)
+					require.Equal(t, len(tc.lts), result.Total)
+
+					parsed, err := store.FilteredLogsWithParser(ctx, addr, 7, parser, nil, tc.confidence)
+					require.NoError(t, err)
+					lts = lts[:0]
+					for _, payload := range parsed {
+						lts = append(lts, payload.(uint64))
+					}
+					require.ElementsMatch(t, tc.lts, lts)
+				})
+			}
+
+			_, err = store.SelectLogs(ctx, addr, "safe")
+			require.ErrorContains(t, err, "unsupported confidence")
+			_, err = store.FilteredLogs(ctx, addr, 7, nil, QueryOptions{Confidence: "safe"})
+			require.ErrorContains(t, err, "unsupported confidence")
+			_, err = store.FilteredLogsWithParser(ctx, addr, 7, parser, nil, "safe")
+			require.ErrorContains(t, err, "unsupported confidence")
+		})
+	}
+}
+
+func TestCellQueryConditions(t *testing.T) {
+	conditions, args, err := cellQueryConditions([]CellQuery{
+		{Offset: 0, Operator: EQ, Value: []byte{0x01}},
+		{Offset: 4, Operator: NEQ, Value: []byte{0x02, 0x03}},
+	}, []any{"-239"})
+	require.NoError(t, err)
+	require.Equal(t, " AND octet_length(payload) >= 1 AND substring(payload FROM 1 FOR 1) = $2"+
+		" AND octet_length(payload) >= 6 AND substring(payload FROM 5 FOR 2) <> $3", conditions)
+	require.Equal(t, []any{"-239", []byte{0x01}, []byte{0x02, 0x03}}, args)
+
+	_, _, err = cellQueryConditions([]CellQuery{{Operator: "~"}}, nil)
+	require.ErrorContains(t, err, "cell query #0")
+}
@@ -52,10 +52,22 @@ type SortBy struct {
 	Order SortOrder
 }
 
+// Confidence is the level of confirmation of the blocks the logs returned by a query were processed with.
+type Confidence string
+
+const (
+	// ConfidenceLatest returns every processed log, including the logs of blocks not yet finalized.
+	ConfidenceLatest Confidence = "latest"
+	// ConfidenceFinalized returns only the logs processed with blocks that are confirmed and were
+	// cross-checked against the lite servers, they are not rolled back.
+	ConfidenceFinalized Confidence = "finalized"
+)
+
 type QueryOptions struct {
-	Limit  int
-	Offset int
-	SortBy []SortBy
+	Limit      int
+	Offset     int
+	SortBy     []SortBy
+	Confidence Confidence // latest when empty, finalized logs are only returned when asked for
 }
 
 type QueryResult struct {
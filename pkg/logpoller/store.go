@@ -1,10 +1,16 @@
 package logpoller
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"fmt"
+	"math"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 
 	"github.com/smartcontractkit/chainlink-common/pkg/logger"
@@ -12,16 +18,19 @@ import (
 	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller/types"
 )
 
-// InMemoryStore is a temporary in-memory implementation for TON CCIP MVP.
-// This provides basic log storage and querying capabilities without database persistence.
-// For production use, this should be replaced with proper database-backed storage.
-//
-// TODO(NONEVM-2187): implement ORM using a database for persistence in production
+var _ ORM = (*InMemoryStore)(nil)
+
+// InMemoryStore is an in-memory implementation of ORM, used by tests and when no datasource
+// is configured. Logs are scanned linearly on every query and lost on restart.
 type InMemoryStore struct {
 	lggr            logger.SugaredLogger
 	cellQueryEngine *CellQueryEngine
 	mu              sync.Mutex
+	filters         []types.Filter // including the deleted ones, until pruned
+	lastFilterID    int64
+	lastLogID       int64
 	logs            []types.Log
+	blocks          []types.Block
 }
 
 func NewInMemoryStore(lggr logger.Logger) *InMemoryStore {
@@ -31,35 +40,231 @@ func NewInMemoryStore(lggr logger.Logger) *InMemoryStore {
 	}
 }
 
-func (s *InMemoryStore) SaveLog(log types.Log) {
+func (s *InMemoryStore) InsertFilter(_ context.Context, flt types.Filter) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.filterIndex(flt.Name); i != -1 {
+		flt.ID = s.filters[i].ID
+		flt.IsDeleted, flt.IsBackfilled = false, keepsBackfill(s.filters[i], flt)
+		flt.BackfilledSeqNo = s.filters[i].BackfilledSeqNo
+		s.filters[i] = flt
+		return flt.ID, nil
+	}
+	s.lastFilterID++
+	flt.ID = s.lastFilterID
+	flt.IsDeleted, flt.IsBackfilled = false, false
+	s.filters = append(s.filters, flt)
+	return flt.ID, nil
+}
+
+func (s *InMemoryStore) SelectFilters(_ context.Context) ([]types.Filter, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	var out []types.Filter
+	for _, flt := range s.filters {
+		if !flt.IsDeleted {
+			out = append(out, flt)
+		}
+	}
+	return out, nil
+}
+
+func (s *InMemoryStore) MarkFilterDeleted(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.filterIndex(name)
+	if i == -1 {
+		return fmt.Errorf("%w: %s", ErrFilterNotFound, name)
+	}
+	s.filters[i].IsDeleted = true
+	return nil
+}
+
+func (s *InMemoryStore) MarkFilterBackfilled(_ context.Context, id int64, head uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.filters {
+		if s.filters[i].ID == id {
+			s.filters[i].IsBackfilled, s.filters[i].BackfilledSeqNo = true, head
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) PruneLogs(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := make(map[int64]struct{})
+	s.filters = slices.DeleteFunc(s.filters, func(flt types.Filter) bool {
+		if flt.IsDeleted {
+			deleted[flt.ID] = struct{}{}
+		}
+		return flt.IsDeleted
+	})
+
+	now := time.Now()
+	before := len(s.logs)
+	s.logs = slices.DeleteFunc(s.logs, func(log types.Log) bool {
+		_, filterDeleted := deleted[log.FilterID]
+		return filterDeleted || (log.ExpiresAt != nil && log.ExpiresAt.Before(now))
+	})
+	return int64(before - len(s.logs)), nil
+}
+
+// filterIndex returns the index of the filter registered under a name, -1 if none.
+func (s *InMemoryStore) filterIndex(name string) int {
+	return slices.IndexFunc(s.filters, func(flt types.Filter) bool {
+		return flt.Name == name && !flt.IsDeleted
+	})
+}
+
+func (s *InMemoryStore) InsertLogs(_ context.Context, logs []types.Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insertLogs(logs)
+	return nil
+}
+
+func (s *InMemoryStore) insertLogs(logs []types.Log) {
 	now := time.Now().UTC()
-	log.ReceivedAt = now
-	if log.ExpiresAt == nil && log.ReceivedAt != (time.Time{}) {
-		// TODO: use configurable retention period
-		// TODO: there is no expiration logic in memory store currently
-		exp := now.Add(24 * time.Hour)
-		log.ExpiresAt = &exp
+	for _, log := range logs {
+		if slices.ContainsFunc(s.logs, func(l types.Log) bool {
+			return l.FilterID == log.FilterID && l.MsgIndex == log.MsgIndex && bytes.Equal(l.TxHash, log.TxHash)
+		}) {
+			continue
+		}
+		s.lastLogID++
+		log.ID = s.lastLogID
+		log.CreatedAt = now
+		log.ReceivedAt = now
+		s.logs = append(s.logs, log)
 	}
-	s.logs = append(s.logs, log)
 }
 
-func (s *InMemoryStore) GetLogs(evtSrcAddress string) []types.Log {
+func (s *InMemoryStore) InsertProcessedBlock(_ context.Context, block types.Block, logs []types.Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insertLogs(logs)
+	block.ProcessedAt, block.IsFinalized = time.Now().UTC(), false
+	s.blocks = slices.DeleteFunc(s.blocks, func(b types.Block) bool { return b.SeqNo == block.SeqNo })
+	s.blocks = append(s.blocks, block)
+	slices.SortFunc(s.blocks, func(a, b types.Block) int { return cmp.Compare(a.SeqNo, b.SeqNo) })
+	return nil
+}
+
+func (s *InMemoryStore) SelectLatestBlock(_ context.Context) (*types.Block, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if len(s.blocks) == 0 {
+		return nil, nil
+	}
+	latest := s.blocks[len(s.blocks)-1]
+	return &latest, nil
+}
+
+func (s *InMemoryStore) SelectLatestBlocks(_ context.Context, limit uint32) ([]types.Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []types.Block
+	for i := len(s.blocks) - 1; i >= 0 && len(out) < int(limit); i-- {
+		out = append(out, s.blocks[i])
+	}
+	return out, nil
+}
+
+func (s *InMemoryStore) MarkBlocksFinalized(_ context.Context, seqNo uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.blocks {
+		if s.blocks[i].SeqNo <= seqNo {
+			s.blocks[i].IsFinalized = true
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) RollbackBlocks(_ context.Context, seqNo uint32) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := len(s.logs)
+	s.logs = slices.DeleteFunc(s.logs, func(log types.Log) bool { return log.ProcessedSeqNo > seqNo })
+	s.blocks = slices.DeleteFunc(s.blocks, func(b types.Block) bool { return b.SeqNo > seqNo })
+	for i := range s.filters {
+		if s.filters[i].BackfilledSeqNo > seqNo {
+			s.filters[i].IsBackfilled = false
+		}
+	}
+	return int64(before - len(s.logs)), nil
+}
+
+// finalizedSeqNo returns the latest finalized block, -1 if no block is finalized.
+func (s *InMemoryStore) finalizedSeqNo() int64 {
+	for i := len(s.blocks) - 1; i >= 0; i-- {
+		if s.blocks[i].IsFinalized {
+			return int64(s.blocks[i].SeqNo)
+		}
+	}
+	return -1
+}
+
+func (s *InMemoryStore) PruneBlocks(_ context.Context, keep uint32) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.blocks) == 0 {
+		return 0, nil
+	}
+	latest := s.blocks[len(s.blocks)-1].SeqNo
+	finalized := s.finalizedSeqNo()
+	before := len(s.blocks)
+	s.blocks = slices.DeleteFunc(s.blocks, func(b types.Block) bool {
+		return b.SeqNo+keep <= latest && int64(b.SeqNo) < finalized
+	})
+	return int64(before - len(s.blocks)), nil
+}
+
+// visibleLogs returns a predicate matching the logs of filters that were not deleted, their logs are only
+// removed by the next PruneLogs, processed at a confidence.
+func (s *InMemoryStore) visibleLogs(confidence Confidence) (func(types.Log) bool, error) {
+	processedUpTo := int64(math.MaxUint32)
+	switch confidence {
+	case ConfidenceLatest, "":
+	case ConfidenceFinalized:
+		processedUpTo = s.finalizedSeqNo()
+	default:
+		return nil, fmt.Errorf("unsupported confidence: %s", confidence)
+	}
+	deleted := make(map[int64]struct{})
+	for _, flt := range s.filters {
+		if flt.IsDeleted {
+			deleted[flt.ID] = struct{}{}
+		}
+	}
+	return func(log types.Log) bool {
+		_, filterDeleted := deleted[log.FilterID]
+		return !filterDeleted && int64(log.ProcessedSeqNo) <= processedUpTo
+	}, nil
+}
+
+func (s *InMemoryStore) SelectLogs(_ context.Context, evtSrcAddress *address.Address, confidence Confidence) ([]types.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	visible, err := s.visibleLogs(confidence)
+	if err != nil {
+		return nil, err
+	}
 	var out []types.Log
 	for _, log := range s.logs {
-		if log.Address.String() == evtSrcAddress {
+		if log.Address.Equals(evtSrcAddress) && visible(log) {
 			out = append(out, log)
 		}
 	}
-	return out
+	return out, nil
 }
 
 // FilteredLogs finds logs by address and topic, then applies cell-level filters.
 func (s *InMemoryStore) FilteredLogs(
-	evtSrcAddress string,
+	_ context.Context,
+	evtSrcAddress *address.Address,
 	topic uint32,
 	filters []CellQuery,
 	options QueryOptions,
@@ -70,11 +275,19 @@ func (s *InMemoryStore) FilteredLogs(
 	s.lggr.Debugf("GetLogsByTopicWithFilter called. Total logs: %d, Address: %s, Topic: %d",
 		len(s.logs), evtSrcAddress, topic)
 
+	visible, err := s.visibleLogs(options.Confidence)
+	if err != nil {
+		return QueryResult{}, err
+	}
+
 	var matchingLogs []types.Log
 
 	for i, log := range s.logs {
-		// match by address and topic (would be indexed query in DB)
-		if log.Topic != topic || log.Address.String() != evtSrcAddress {
+		// match by address and topic (indexed query in DSORM)
+		if log.Topic != topic || !log.Address.Equals(evtSrcAddress) {
+			continue
+		}
+		if !visible(log) {
 			continue
 		}
 
@@ -100,17 +313,23 @@ func (s *InMemoryStore) FilteredLogs(
 }
 
 func (s *InMemoryStore) FilteredLogsWithParser(
-	evtSrcAddress string,
+	_ context.Context,
+	evtSrcAddress *address.Address,
 	topic uint32,
 	parser types.LogParser,
 	filter types.LogFilter,
+	confidence Confidence,
 ) ([]any, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	visible, err := s.visibleLogs(confidence)
+	if err != nil {
+		return nil, err
+	}
 	results := make([]any, 0, len(s.logs))
 	for i, log := range s.logs {
-		if log.Topic != topic || log.Address.String() != evtSrcAddress {
+		if log.Topic != topic || !log.Address.Equals(evtSrcAddress) || !visible(log) {
 			continue
 		}
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 )
 
 // TON CCIP MVP Types
@@ -24,34 +25,69 @@ type Filter struct {
 	EventName     string          // EventName is the name of the event to filter logs for.
 	EventTopic    uint32          // EventTopic is a topic identifier for the event log.
 	StartingSeqNo uint32          // StartingSeqNo defines the starting sequence number for log polling
-	Retention     time.Duration   // Retention specifies the duration for which the logs should be retained
-	// TODO: add more fields for production (IsDeleted, IsBackfilled, MaxLogsKept, etc.)
+	Retention     time.Duration   // Retention specifies the duration for which the logs should be retained, 0 keeps them
+	IsDeleted     bool            // IsDeleted is set once the filter is unregistered, its logs are then pruned.
+	IsBackfilled  bool            // IsBackfilled is set once the logs of the filter from StartingSeqNo were loaded.
+	// BackfilledSeqNo is the last masterchain block of the backfill, it is redone if that block is rolled back.
+	BackfilledSeqNo uint32
+	// TODO: add more fields for production (MaxLogsKept, etc.)
 }
 
-// TODO: do we want to store the workchain and its seqno to be able to query the block directly?
 type Log struct {
-	ID       int64 // Unique identifier for the log entry.
-	FilterID int64 // Identifier of the filter that matched this log.
-	// SeqNo      uint32 // currently ListTransactions does not return seqno, need to update with block polling
+	ID         int64           // Unique identifier for the log entry.
+	FilterID   int64           // Identifier of the filter that matched this log.
+	SeqNo      uint32          // Masterchain sequence number of the block committing the transaction, 0 unless collected by blocks.
+	ShardBlock *ton.BlockIDExt // Shard block of the transaction, nil unless collected by blocks.
+	BlockTime  time.Time       // Generation time of the shard block of the transaction, zero unless collected by blocks.
 	Address    address.Address // Address associated with the log entry.
 	TxHash     []byte          // Transaction hash for uniqueness within the blockchain.
 	TxLT       uint64          // Logical time (LT) of the transaction, used for ordering and uniqueness.
+	MsgIndex   uint32          // Index of the external message among the outgoing messages of the transaction.
 	Topic      uint32          // Topic identifier for categorizing the log entry.
 	Data       []byte          // Raw BOC (Bag of Cells) of the body cell containing the log data.
 	CreatedAt  time.Time       // Timestamp when the log entry was created.
 	ReceivedAt time.Time       // Timestamp when the log entry was received by the system.
 	ExpiresAt  *time.Time      // Optional expiration timestamp for the log entry.
 	Error      *string         // Optional error message associated with the log entry.
-	// TODO: Add SeqNo when we have block information available
-	// TODO: add fields for replay and debugging (BlockHash, BlockNumber, BlockTimestamp, TxHash, etc.)
+	// Last masterchain block processed along with the log, the log is rolled back with it.
+	ProcessedSeqNo uint32
+	// TODO: add fields for replay and debugging
 }
 
-// TODO: define block, transaction, and other data structures for easier debug and replay
+// Block is a masterchain block processed by the log poller.
+type Block struct {
+	Workchain   int32     // Workchain of the block, the masterchain.
+	Shard       int64     // Shard of the block, the masterchain shard.
+	SeqNo       uint32    // Masterchain sequence number of the block.
+	RootHash    []byte    // Root hash of the block.
+	FileHash    []byte    // File hash of the block.
+	ProcessedAt time.Time // Timestamp when the block was processed.
+	IsFinalized bool      // IsFinalized is set once the block is confirmed and was cross-checked against the lite servers.
+}
+
+// ID returns the identifier of the block.
+func (b Block) ID() *ton.BlockIDExt {
+	return &ton.BlockIDExt{
+		Workchain: b.Workchain,
+		Shard:     b.Shard,
+		SeqNo:     b.SeqNo,
+		RootHash:  b.RootHash,
+		FileHash:  b.FileHash,
+	}
+}
+
+// TODO: define transaction and other data structures for easier debug and replay
 // Similar to Solana's BlockData, ProgramLog, ProgramEvent, and Block types
 
 // TODO: better name
 type MsgWithCtx struct {
-	TxHash []byte
-	LT     uint64
-	Msg    *tlb.ExternalMessageOut
+	TxHash   []byte
+	LT       uint64
+	MsgIndex uint32 // index of the message among the outgoing messages of the transaction
+	Msg      *tlb.ExternalMessageOut
+
+	// set by the block collector only
+	MasterSeqNo uint32          // masterchain block committing the transaction
+	Block       *ton.BlockIDExt // shard block of the transaction
+	BlockTime   time.Time       // generation time of the shard block
 }
@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/sqlutil"
+)
+
+const (
+	upAnnotation   = "-- +goose Up"
+	downAnnotation = "-- +goose Down"
+)
+
+// Migration is a SQL migration in the goose format, versioned by the number its file name starts with.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string // statements below the Up annotation, up to the Down annotation
+}
+
+// Load parses the migrations at the root of fsys, sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int64]string, len(names))
+	for _, name := range names {
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s is not named <version>_<description>.sql", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s does not start with a positive version", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, name, version)
+		}
+		seen[version] = name
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		_, up, found := strings.Cut(string(content), upAnnotation)
+		if !found {
+			return nil, fmt.Errorf("migration %s has no %q annotation", name, upAnnotation)
+		}
+		up, _, _ = strings.Cut(up, downAnnotation)
+
+		migrations = append(migrations, Migration{Version: version, Name: name, Up: strings.TrimSpace(up)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies the migrations of fsys that were not applied yet, in version order, recording the applied
+// versions in table. Every package owning tables keeps its own table, so that its migrations are
+// versioned apart from the ones of the other packages. The migrations are applied in one transaction,
+// holding an advisory lock that serializes concurrent runs, e.g. by the chains of one node, as the
+// migrations of the packages share the ton schema.
+func Up(ctx context.Context, lggr logger.Logger, ds sqlutil.DataSource, fsys fs.FS, table string) error {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+
+	return sqlutil.TransactDataSource(ctx, ds, nil, func(tx sqlutil.DataSource) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockID()); err != nil {
+			return fmt.Errorf("failed to lock %s: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+			version    BIGINT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`); err != nil {
+			return fmt.Errorf("failed to create %s: %w", table, err)
+		}
+
+		var versions []int64
+		if err := tx.SelectContext(ctx, &versions, `SELECT version FROM `+table); err != nil {
+			return fmt.Errorf("failed to select applied migrations: %w", err)
+		}
+		applied := make(map[int64]bool, len(versions))
+		for _, version := range versions {
+			applied[version] = true
+		}
+
+		for _, m := range migrations {
+			if applied[m.Version] {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO `+table+` (version) VALUES ($1)`, m.Version); err != nil {
+				return fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+			}
+			lggr.Infow("applied migration", "table", table, "migration", m.Name)
+		}
+		return nil
+	})
+}
+
+// lockID derives the advisory lock held while applying migrations.
+func lockID() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("chainlink-ton migrations"))
+	return int64(h.Sum64()) //nolint:gosec // any 64 bits identify the lock
+}
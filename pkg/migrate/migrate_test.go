@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name       string
+		files      fstest.MapFS
+		migrations []Migration
+		err        string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"0010_add_column.sql":   {Data: []byte("-- +goose Up\nALTER TABLE t ADD COLUMN c INT;\n\n-- +goose Down\nALTER TABLE t DROP COLUMN c;\n")},
+				"0002_create_table.sql": {Data: []byte("-- +goose Up\nCREATE TABLE t (id INT);\n-- +goose Down\nDROP TABLE t;\n")},
+				"README.md":             {Data: []byte("not a migration")},
+			},
+			migrations: []Migration{
+				{Version: 2, Name: "0002_create_table.sql", Up: "CREATE TABLE t (id INT);"},
+				{Version: 10, Name: "0010_add_column.sql", Up: "ALTER TABLE t ADD COLUMN c INT;"},
+			},
+		},
+		{
+			name: "comments before the up annotation are skipped",
+			files: fstest.MapFS{
+				"0001_create_table.sql": {Data: []byte("-- creates t\n-- +goose Up\n-- keeps ids\nCREATE TABLE t (id INT);\n")},
+			},
+			migrations: []Migration{
+				{Version: 1, Name: "0001_create_table.sql", Up: "-- keeps ids\nCREATE TABLE t (id INT);"},
+			},
+		},
+		{
+			name:  "missing up annotation",
+			files: fstest.MapFS{"0001_create_table.sql": {Data: []byte("CREATE TABLE t (id INT);")}},
+			err:   "has no",
+		},
+		{
+			name:  "missing version",
+			files: fstest.MapFS{"createtable.sql": {Data: []byte("-- +goose Up\n")}},
+			err:   "is not named",
+		},
+		{
+			name:  "invalid version",
+			files: fstest.MapFS{"first_create_table.sql": {Data: []byte("-- +goose Up\n")}},
+			err:   "positive version",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"0001_create_table.sql": {Data: []byte("-- +goose Up\n")},
+				"1_create_other.sql":    {Data: []byte("-- +goose Up\n")},
+			},
+			err: "share version 1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := Load(tc.files)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.migrations, migrations)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/services"
+)
+
+var promBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ton_balance",
+	Help: "Balance of a TON sender wallet, in TON",
+}, []string{"chainID", "account"})
+
+// BalanceMonitor periodically reads the balance of the TXM sender wallets, exports it as a metric
+// and reports unhealthy while a wallet is below the low balance threshold.
+type BalanceMonitor struct {
+	services.Service
+	eng *services.Engine
+
+	lggr     logger.SugaredLogger
+	chainID  string
+	cfg      Config
+	client   ton.APIClientWrapped
+	accounts []*address.Address
+
+	lock     sync.RWMutex
+	balances map[string]tlb.Coins // latest balance by account address
+}
+
+// NewBalanceMonitor creates a BalanceMonitor for the given wallet addresses.
+func NewBalanceMonitor(lggr logger.Logger, chainID string, cfg Config, client ton.APIClientWrapped, accounts []*address.Address) *BalanceMonitor {
+	b := &BalanceMonitor{
+		lggr:     logger.Sugared(lggr),
+		chainID:  chainID,
+		cfg:      cfg,
+		client:   client,
+		accounts: accounts,
+		balances: map[string]tlb.Coins{},
+	}
+	b.Service, b.eng = services.Config{
+		Name:  "TONBalanceMonitor",
+		Start: b.start,
+	}.NewServiceEngine(lggr)
+	return b
+}
+
+func (b *BalanceMonitor) start(context.Context) error {
+	b.eng.GoTick(services.NewTicker(b.cfg.BalancePollPeriod), b.updateBalances)
+	return nil
+}
+
+// Balance returns the latest known balance of an account, false if it was not read yet.
+func (b *BalanceMonitor) Balance(account string) (tlb.Coins, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	balance, ok := b.balances[account]
+	return balance, ok
+}
+
+func (b *BalanceMonitor) updateBalances(ctx context.Context) {
+	block, err := b.client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		b.lggr.Errorw("failed to get masterchain info", "err", err)
+		return
+	}
+
+	threshold := tlb.FromNanoTON(new(big.Int).SetUint64(b.cfg.LowBalanceThresholdNano))
+	for _, account := range b.accounts {
+		addr := account.String()
+
+		acc, err := b.client.GetAccount(ctx, block, account)
+		if err != nil {
+			b.lggr.Errorw("failed to get account balance", "account", addr, "err", err)
+			continue
+		}
+
+		balance := tlb.ZeroCoins
+		if acc.State != nil {
+			balance = acc.State.Balance
+		}
+
+		b.lock.Lock()
+		b.balances[addr] = balance
+		b.lock.Unlock()
+
+		balanceTON, _ := new(big.Float).Quo(new(big.Float).SetInt(balance.Nano()), big.NewFloat(1e9)).Float64()
+		promBalance.WithLabelValues(b.chainID, addr).Set(balanceTON)
+
+		condition := "lowBalance:" + addr
+		if balance.Nano().Cmp(threshold.Nano()) < 0 {
+			b.lggr.Warnw("account balance below threshold", "account", addr, "balance", balance.String(), "threshold", threshold.String())
+			b.eng.SetHealthCond(condition, fmt.Errorf("balance of %s is %s TON, below threshold of %s TON", addr, balance.String(), threshold.String()))
+		} else {
+			b.eng.ClearHealthCond(condition)
+		}
+	}
+}
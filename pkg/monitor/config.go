@@ -0,0 +1,15 @@
+package monitor
+
+import (
+	"time"
+)
+
+type Config struct {
+	BalancePollPeriod       time.Duration // How often the sender wallet balances are read
+	LowBalanceThresholdNano uint64        // Balance in nanotons under which a wallet reports unhealthy
+}
+
+var DefaultConfigSet = Config{
+	BalancePollPeriod:       time.Minute,
+	LowBalanceThresholdNano: 1_000_000_000, // 1 TON
+}
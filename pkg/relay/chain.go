@@ -9,10 +9,13 @@ import (
 	"math/big"
 	"math/rand/v2"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
 	"github.com/xssnick/tonutils-go/tvm/cell"
@@ -31,8 +34,8 @@ import (
 	"github.com/smartcontractkit/chainlink-ton/pkg/config"
 	"github.com/smartcontractkit/chainlink-ton/pkg/fees"
 	"github.com/smartcontractkit/chainlink-ton/pkg/logpoller"
+	"github.com/smartcontractkit/chainlink-ton/pkg/monitor"
 	tonconfig "github.com/smartcontractkit/chainlink-ton/pkg/ton/config"
-	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
 	"github.com/smartcontractkit/chainlink-ton/pkg/txm"
 )
 
@@ -40,7 +43,9 @@ type Chain interface {
 	commontypes.ChainService
 
 	ID() string
+	Config() *config.TOMLConfig
 	TxManager() TxManager
+	Transfer(ctx context.Context, from, to string, amount *big.Int, balanceCheck bool) (string, error)
 	LogPoller() logpoller.LogPoller
 	GetClient(ctx context.Context) (*ton.APIClient, error)
 }
@@ -67,8 +72,11 @@ type chain struct {
 	lggr logger.Logger
 	ds   sqlutil.DataSource
 
-	txm *txm.Txm
-	lp  logpoller.LogPoller
+	senders        []txm.Sender
+	txm            TxManager
+	lp             logpoller.LogPoller
+	balanceMonitor *monitor.BalanceMonitor
+	feeEstimator   *fees.ConfigEstimator
 
 	clientCache map[int]*cachedClient
 	cacheMu     sync.RWMutex
@@ -85,7 +93,7 @@ func NewChain(cfg *config.TOMLConfig, opts ChainOpts) (Chain, error) {
 func newChain(ctx context.Context, cfg *config.TOMLConfig, loopKs loop.Keystore, lggr logger.Logger, ds sqlutil.DataSource) (*chain, error) {
 	lggr = logger.With(lggr, "chainID", cfg.ChainID)
 
-	// TEMP: fetch the first account in the store to use for transmissions to avoid having to specify it in TOML
+	// every account in the keystore backs a sender wallet of the TXM
 	accounts, err := loopKs.Accounts(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch accounts from keystore: %w", err)
@@ -112,20 +120,63 @@ func newChain(ctx context.Context, cfg *config.TOMLConfig, loopKs loop.Keystore,
 		return nil, fmt.Errorf("failed to create TON client for chain ID %s: %w", cfg.ChainID, err)
 	}
 
-	signerWallet, err := ch.GetSignerWallet(ctx, tonClient, loopKs, 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get signer wallet for chain ID %s: %w", cfg.ChainID, err)
+	senders := make([]txm.Sender, 0, len(accounts))
+	senderAddresses := make([]*address.Address, 0, len(accounts))
+	for i, account := range accounts {
+		signerWallet, err := ch.GetSignerWallet(ctx, tonClient, loopKs, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signer wallet for chain ID %s: %w", cfg.ChainID, err)
+		}
+		senders = append(senders, txm.Sender{Wallet: signerWallet, Account: account})
+		senderAddresses = append(senderAddresses, signerWallet.Address())
 	}
 
-	apiClient := tracetracking.SignedAPIClient{
-		Client: tonClient,
-		Wallet: *signerWallet,
+	var orm txm.ORM
+	if ds != nil {
+		if err := txm.Migrate(ctx, lggr, ds); err != nil {
+			return nil, fmt.Errorf("failed to migrate TXM tables for chain ID %s: %w", cfg.ChainID, err)
+		}
+		orm = txm.NewORM(cfg.ChainID, ds, lggr)
 	}
 
 	txmCfg := txm.DefaultConfigSet
-	ch.txm = txm.New(lggr, loopKs, apiClient, txmCfg)
+	if cfg.TransactionManager != nil {
+		txmCfg = *cfg.TransactionManager
+	}
+	ch.senders = senders
+	txManager, err := txm.NewWithSenders(lggr, loopKs, tonClient, senders, orm, txmCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TXM for chain ID %s: %w", cfg.ChainID, err)
+	}
 
-	// TODO: Setup accounts balance monitor
+	balanceMonitorCfg := monitor.DefaultConfigSet
+	if cfg.BalanceMonitor != nil {
+		balanceMonitorCfg = *cfg.BalanceMonitor
+	}
+	ch.balanceMonitor = monitor.NewBalanceMonitor(lggr, cfg.ChainID, balanceMonitorCfg, tonClient, senderAddresses)
+	txManager.ChainID = cfg.ChainID
+	txManager.Balances = ch.balanceMonitor
+
+	feeEstimatorCfg := fees.DefaultConfigSet
+	if cfg.FeeEstimator != nil {
+		feeEstimatorCfg = *cfg.FeeEstimator
+	}
+	ch.feeEstimator = fees.NewEstimator(lggr, feeEstimatorCfg, tonClient)
+	txManager.FeeEstimator = ch.feeEstimator
+	ch.txm = txManager
+
+	logPollerCfg := logpoller.DefaultConfigSet
+	if cfg.LogPoller != nil {
+		logPollerCfg = *cfg.LogPoller
+	}
+	var lpORM logpoller.ORM
+	if ds != nil {
+		if err := logpoller.Migrate(ctx, lggr, ds); err != nil {
+			return nil, fmt.Errorf("failed to migrate log poller tables for chain ID %s: %w", cfg.ChainID, err)
+		}
+		lpORM = logpoller.NewORM(cfg.ChainID, ds, lggr)
+	}
+	ch.lp = logpoller.NewLogPoller(lggr, tonClient, lpORM, logPollerCfg)
 
 	return ch, nil
 }
@@ -138,9 +189,12 @@ func (c *chain) Start(ctx context.Context) error {
 	return c.starter.StartOnce("Chain", func() error {
 		c.lggr.Debug("Starting")
 		c.lggr.Debug("Starting txm")
+		c.lggr.Debug("Starting balance monitor")
+		c.lggr.Debug("Starting fee estimator")
+		c.lggr.Debug("Starting log poller")
 
 		var ms services.MultiStart
-		return ms.Start(ctx, c.txm)
+		return ms.Start(ctx, c.feeEstimator, c.txm, c.balanceMonitor, c.lp)
 	})
 }
 
@@ -148,18 +202,23 @@ func (c *chain) Close() error {
 	return c.starter.StopOnce("Chain", func() error {
 		c.lggr.Debug("Stopping")
 		c.lggr.Debug("Stopping txm")
-		return services.CloseAll(c.txm)
+		c.lggr.Debug("Stopping balance monitor")
+		c.lggr.Debug("Stopping fee estimator")
+		c.lggr.Debug("Stopping log poller")
+		return services.CloseAll(c.lp, c.txm, c.balanceMonitor, c.feeEstimator)
 	})
 }
 
 func (c *chain) Ready() error {
-	return errors.Join(c.starter.Ready(), c.txm.Ready())
+	return errors.Join(c.starter.Ready(), c.txm.Ready(), c.balanceMonitor.Ready(), c.feeEstimator.Ready(), c.lp.Ready())
 }
 
 func (c *chain) HealthReport() map[string]error {
 	report := map[string]error{c.Name(): c.starter.Healthy()}
 	services.CopyHealth(report, c.txm.HealthReport())
-	// TODO: Add balance monitor health report once implemented
+	services.CopyHealth(report, c.balanceMonitor.HealthReport())
+	services.CopyHealth(report, c.feeEstimator.HealthReport())
+	services.CopyHealth(report, c.lp.HealthReport())
 	return report
 }
 
@@ -226,9 +285,68 @@ func (c *chain) ListNodeStatuses(ctx context.Context, pageSize int32, pageToken
 	return chains.ListNodeStatuses(int(pageSize), pageToken, c.listNodeStatuses)
 }
 
+// Transact sends amount nanotons from a sender wallet to an address, see Transfer.
 func (c *chain) Transact(ctx context.Context, from, to string, amount *big.Int, balanceCheck bool) error {
-	// TODO(NONEVM-1460): implement
-	return errors.ErrUnsupported
+	id, err := c.Transfer(ctx, from, to, amount, balanceCheck)
+	if err != nil {
+		return err
+	}
+	c.lggr.Infow("Transfer enqueued", "id", id, "from", from, "to", to, "amount", amount.String())
+	return nil
+}
+
+// Transfer enqueues a plain transfer of amount nanotons and returns the TXM transaction ID to track it.
+// from is the keystore account or the wallet address of a sender, to a raw or user-friendly address.
+// With balanceCheck the transfer is refused when the last known balance of the sender does not cover it.
+func (c *chain) Transfer(ctx context.Context, from, to string, amount *big.Int, balanceCheck bool) (string, error) {
+	sender, err := c.resolveSender(from)
+	if err != nil {
+		return "", err
+	}
+	toAddr, err := parseAddress(to)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination address %s: %w", to, err)
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return "", fmt.Errorf("invalid amount %v: must be positive", amount)
+	}
+
+	// a raw address carries no bounce flag, parseAddress makes it non-bounceable so that a wallet that
+	// is not deployed yet keeps the funds
+	return c.txm.Enqueue(txm.Request{
+		Mode:             wallet.PayGasSeparately,
+		From:             sender,
+		ContractAddress:  *toAddr,
+		Amount:           tlb.FromNanoTON(amount),
+		Bounce:           toAddr.IsBounceable(),
+		SkipBalanceCheck: !balanceCheck,
+	})
+}
+
+// resolveSender returns the wallet address of the sender matching from, either its keystore account
+// or its wallet address.
+func (c *chain) resolveSender(from string) (*address.Address, error) {
+	fromAddr, _ := parseAddress(from)
+	for _, s := range c.senders {
+		if strings.EqualFold(s.Account, from) || (fromAddr != nil && s.Wallet.Address().Equals(fromAddr)) {
+			return s.Wallet.Address(), nil
+		}
+	}
+	return nil, fmt.Errorf("no keystore wallet for sender %s", from)
+}
+
+// parseAddress parses a raw (workchain:hex) or user-friendly address, in URL-safe or standard base64.
+// A raw address carries no flags, it is parsed non-bounceable.
+func parseAddress(s string) (*address.Address, error) {
+	if strings.Contains(s, ":") {
+		addr, err := address.ParseRawAddr(s)
+		if err != nil {
+			return nil, err
+		}
+		addr.SetBounce(false)
+		return addr, nil
+	}
+	return address.ParseAddr(strings.NewReplacer("+", "-", "/", "_").Replace(s))
 }
 
 func (c *chain) Replay(ctx context.Context, fromBlock string, args map[string]any) error {
@@ -240,13 +358,16 @@ func (c *chain) ID() string {
 	return c.id
 }
 
+func (c *chain) Config() *config.TOMLConfig {
+	return c.cfg
+}
+
 func (c *chain) TxManager() TxManager {
 	return c.txm
 }
 
 func (c *chain) FeeEstimator() fees.Estimator {
-	// TODO(NONEVM-1460): implement
-	return nil
+	return c.feeEstimator
 }
 
 func (c *chain) LogPoller() logpoller.LogPoller {
@@ -0,0 +1,157 @@
+package relay
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+
+	tonconfig "github.com/smartcontractkit/chainlink-ton/pkg/ton/config"
+	"github.com/smartcontractkit/chainlink-ton/pkg/txm"
+)
+
+// enqueuedRequests records the requests enqueued, the other TxManager methods are not implemented.
+type enqueuedRequests struct {
+	TxManager
+	requests []txm.Request
+}
+
+func (m *enqueuedRequests) Enqueue(request txm.Request) (string, error) {
+	m.requests = append(m.requests, request)
+	return "tx-id", nil
+}
+
+func testSender(t *testing.T, seed byte) txm.Sender {
+	key := ed25519.NewKeyFromSeed(append(make([]byte, ed25519.SeedSize-1), seed))
+	w, err := wallet.FromPrivateKey(nil, key, tonconfig.WalletVersion)
+	require.NoError(t, err)
+	return txm.Sender{Wallet: w, Account: hex.EncodeToString(key.Public().(ed25519.PublicKey))}
+}
+
+func testDestination() *address.Address {
+	data := make([]byte, 32)
+	for i := range data {
+		data[i] = 0xfb
+	}
+	return address.NewAddress(0, 0, data)
+}
+
+func TestParseAddress(t *testing.T) {
+	dst := testDestination()
+	raw := "0:" + hex.EncodeToString(dst.Data())
+
+	testCases := []struct {
+		name       string
+		addr       string
+		bounceable bool
+		err        bool
+	}{
+		{name: "raw", addr: raw},
+		{name: "raw with upper case hex", addr: strings.ToUpper(raw)},
+		{name: "bounceable", addr: dst.Bounce(true).String(), bounceable: true},
+		{name: "non-bounceable", addr: dst.Bounce(false).String()},
+		{name: "standard base64", addr: strings.NewReplacer("-", "+", "_", "/").Replace(dst.Bounce(true).String()), bounceable: true},
+		{name: "raw with a short hash", addr: "0:fbfb", err: true},
+		{name: "raw with an invalid workchain", addr: "main:" + hex.EncodeToString(dst.Data()), err: true},
+		{name: "invalid checksum", addr: dst.String()[:len(dst.String())-2] + "AA", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := parseAddress(tc.addr)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, dst.Equals(addr))
+			require.Equal(t, tc.bounceable, addr.IsBounceable())
+		})
+	}
+}
+
+func TestChain_ResolveSender(t *testing.T) {
+	senders := []txm.Sender{testSender(t, 1), testSender(t, 2)}
+	c := &chain{senders: senders}
+	second := senders[1].Wallet.Address()
+
+	testCases := []struct {
+		name string
+		from string
+		err  bool
+	}{
+		{name: "keystore account", from: senders[1].Account},
+		{name: "keystore account in upper case", from: strings.ToUpper(senders[1].Account)},
+		{name: "user-friendly wallet address", from: second.String()},
+		{name: "non-bounceable wallet address", from: second.Bounce(false).String()},
+		{name: "raw wallet address", from: second.StringRaw()},
+		{name: "unknown account", from: hex.EncodeToString(make([]byte, ed25519.PublicKeySize)), err: true},
+		{name: "unknown wallet address", from: testDestination().String(), err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := c.resolveSender(tc.from)
+			if tc.err {
+				require.ErrorContains(t, err, "no keystore wallet")
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, second.Equals(addr))
+		})
+	}
+}
+
+func TestChain_Transfer(t *testing.T) {
+	sender := testSender(t, 1)
+	dst := testDestination()
+
+	testCases := []struct {
+		name         string
+		to           string
+		amount       *big.Int
+		balanceCheck bool
+		bounce       bool
+		err          string
+	}{
+		// a wallet that is not deployed yet keeps a top-up sent to its raw address
+		{name: "raw destination", to: dst.StringRaw(), amount: big.NewInt(1_000), balanceCheck: true},
+		{name: "bounceable destination", to: dst.Bounce(true).String(), amount: big.NewInt(1_000), bounce: true},
+		{name: "non-bounceable destination", to: dst.Bounce(false).String(), amount: big.NewInt(1_000)},
+		{name: "invalid destination", to: "0:fbfb", amount: big.NewInt(1_000), err: "invalid destination address"},
+		{name: "zero amount", to: dst.StringRaw(), amount: big.NewInt(0), err: "invalid amount"},
+		{name: "no amount", to: dst.StringRaw(), err: "invalid amount"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			txManager := &enqueuedRequests{}
+			c := &chain{lggr: logger.Test(t), senders: []txm.Sender{sender}, txm: txManager}
+
+			id, err := c.Transfer(t.Context(), sender.Account, tc.to, tc.amount, tc.balanceCheck)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				require.Empty(t, txManager.requests)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "tx-id", id)
+			require.Len(t, txManager.requests, 1)
+
+			request := txManager.requests[0]
+			require.True(t, sender.Wallet.Address().Equals(request.From))
+			require.True(t, dst.Equals(&request.ContractAddress))
+			require.Equal(t, tc.amount.Uint64(), request.Amount.Nano().Uint64())
+			require.Equal(t, tc.bounce, request.Bounce)
+			require.Equal(t, !tc.balanceCheck, request.SkipBalanceCheck)
+			require.Equal(t, uint8(wallet.PayGasSeparately), request.Mode)
+		})
+	}
+
+	_, err := (&chain{senders: []txm.Sender{sender}}).Transfer(t.Context(), "unknown", dst.StringRaw(), big.NewInt(1), false)
+	require.ErrorContains(t, err, "no keystore wallet")
+}
@@ -23,10 +23,14 @@ var _ TxManager = (*txm.Txm)(nil)
 type TxManager interface {
 	services.Service
 
-	Enqueue(request txm.Request) error
+	Enqueue(request txm.Request) (string, error)
 	GetTransactionStatus(ctx context.Context, lt uint64) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error)
+	GetTransactionStatusByID(ctx context.Context, id string) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error)
+	GetTransactionFailure(ctx context.Context, id string) (*txm.Failure, error)
 	GetClient() tracetracking.SignedAPIClient
-	InflightCount() (int, int)
+	InflightCount() (txm.QueueDepths, int)
+	RetentionCount() (int, int)
+	Subscribe(ctx context.Context, id string) (<-chan txm.TxStatusUpdate, func(), error)
 }
 
 var _ commontypes.Relayer = &Relayer{}
@@ -49,7 +53,7 @@ func (r *Relayer) TON() (commontypes.TONService, error) {
 }
 
 func (r *Relayer) NewCCIPProvider(ctx context.Context, rargs commontypes.RelayArgs) (commontypes.CCIPProvider, error) {
-	return provider.NewCCIPProvider(r.lggr, r.chain.TxManager())
+	return provider.NewCCIPProvider(r.lggr, r.chain.TxManager(), *r.chain.Config().Transmitter)
 }
 
 func NewRelayer(lggr logger.Logger, chain Chain, tonService Service, _ core.CapabilitiesRegistry) *Relayer {
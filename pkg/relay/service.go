@@ -119,12 +119,10 @@ func (s *Service) SendTx(ctx context.Context, msg tontypes.Message) error {
 	amount := tlb.MustFromTON(msg.Amount)
 
 	txManager := s.chain.TxManager()
-	signedClient := txManager.GetClient()
-	fromWallet := signedClient.Wallet
 
+	// the sender wallet is picked from the pool by the TXM
 	request := txm.Request{
 		Mode:            msg.Mode,
-		FromWallet:      fromWallet,
 		ContractAddress: *toAddr,
 		Amount:          amount,
 		Body:            body,
@@ -132,7 +130,8 @@ func (s *Service) SendTx(ctx context.Context, msg tontypes.Message) error {
 		Bounce:          msg.Bounce,
 	}
 
-	return txManager.Enqueue(request)
+	_, err = txManager.Enqueue(request)
+	return err
 }
 
 func (s *Service) GetTxStatus(ctx context.Context, lt uint64) (commontypes.TransactionStatus, tontypes.ExitCode, error) {
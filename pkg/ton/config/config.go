@@ -7,9 +7,25 @@ import (
 	"github.com/xssnick/tonutils-go/ton/wallet"
 )
 
+type queryIDKey struct{}
+
+type queryID struct {
+	id        uint32
+	createdAt int64
+}
+
+// WithQueryID returns a context making the MessageBuilder of WalletVersion use the given query ID
+// and creation time for the next external message, instead of deriving them from the current time.
+func WithQueryID(ctx context.Context, id uint32, createdAt int64) context.Context {
+	return context.WithValue(ctx, queryIDKey{}, queryID{id: id, createdAt: createdAt})
+}
+
 var WalletVersion = wallet.ConfigHighloadV3{
 	MessageTTL: 120, // 2 minutes TTL
 	MessageBuilder: func(ctx context.Context, subWalletId uint32) (id uint32, createdAt int64, err error) {
+		if q, ok := ctx.Value(queryIDKey{}).(queryID); ok {
+			return q.id, q.createdAt, nil
+		}
 		tm := time.Now().Unix() - 30
 		return uint32(10000 + tm%(1<<23)), tm, nil
 	},
@@ -1,8 +1,10 @@
 package tracetracking
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
@@ -37,6 +39,7 @@ type SentMessage struct {
 	Amount      *big.Int
 	LamportTime uint64   // Lamport time of sender when emitting the message
 	FwdFee      *big.Int // Of sending this message. This is paid by the sender of the message. It is 0 on external messages.
+	ScannedLT   uint64   // Lamport time of the latest recipient transaction FindReceivedMessage checked, the ones before it are not checked again
 }
 
 // NewSentMessage creates a SentMessage from an internal message.
@@ -62,21 +65,27 @@ type ReceivedMessage struct {
 	Amount      *big.Int
 	ExternalMsg *tlb.ExternalMessageIn
 	LamportTime uint64   // Lamport time of sender when emitting the message
+	TxHash      []byte   // Hash of the transaction that received the message
 	ImportFee   *big.Int // Import fee of the message. This is paid by the receiver of the message when calling acceptMessage(). It is 0 on internal messages.
 	FwdFee      *big.Int // Of sending this message. This is paid by the sender of the message. It is 0 on external messages.
 
 	// Received step
 
-	StorageFeeCharged                *big.Int           // Rent due at the moment of sending the message (charged to receiver)
-	MsgFeesChargedToSender           *big.Int           // Forward fees
-	TotalActionFees                  *big.Int           // Fees charged to the sender for sending messages. This + the fwdFee of each outgoing msg forms the total charged in the action phase.
-	GasFee                           *big.Int           // Fees charged to the receiver for processing the message.
-	MagicFee                         *big.Int           // Unknown origin fee
-	EmittedBouncedMessage            bool               // Indicates if the transaction was bounced
-	Success                          bool               // Indicates if the transaction was successful
-	ExitCode                         tvm.ExitCode       // Exit code of the transaction execution
-	OutgoingInternalSentMessages     []*SentMessage     // Internal messages sent as a result of this message
-	OutgoingInternalReceivedMessages []*ReceivedMessage // Internal messages that have been received by their recipients
+	StorageFeeCharged                *big.Int                  // Rent due at the moment of sending the message (charged to receiver)
+	MsgFeesChargedToSender           *big.Int                  // Forward fees
+	TotalActionFees                  *big.Int                  // Fees charged to the sender for sending messages. This + the fwdFee of each outgoing msg forms the total charged in the action phase.
+	GasFee                           *big.Int                  // Fees charged to the receiver for processing the message.
+	MagicFee                         *big.Int                  // Unknown origin fee
+	EmittedBouncedMessage            bool                      // Indicates if the transaction was bounced
+	Success                          bool                      // Indicates if the transaction was successful
+	ExitCode                         tvm.ExitCode              // Exit code of the transaction execution
+	ComputeSkipReason                tlb.ComputeSkipReasonType // Reason the compute phase was skipped, empty when it ran
+	ActionFailed                     bool                      // Indicates if the action phase ran and failed
+	ActionResultCode                 int32                     // Result code of the action phase, 0 when it succeeded
+	ActionNoFunds                    bool                      // Indicates if the action phase failed for lack of funds to send the messages
+	Aborted                          bool                      // Indicates if the transaction was aborted
+	OutgoingInternalSentMessages     []*SentMessage            // Internal messages sent as a result of this message
+	OutgoingInternalReceivedMessages []*ReceivedMessage        // Internal messages that have been received by their recipients
 	OutgoingExternalMessages         []OutgoingExternalMessages
 }
 
@@ -208,6 +217,7 @@ func MapToReceivedMessage(txOnReceived *tlb.Transaction) (ReceivedMessage, error
 		Amount:                           amount,
 		ExternalMsg:                      externalMessage,
 		LamportTime:                      txOnReceived.LT,
+		TxHash:                           txOnReceived.Hash,
 		ImportFee:                        importFee,
 		FwdFee:                           fwdFee,
 		MsgFeesChargedToSender:           big.NewInt(0),
@@ -247,6 +257,7 @@ func MapToReceivedMessage(txOnReceived *tlb.Transaction) (ReceivedMessage, error
 	// the msg is malformed, which wont happen using Tact.
 
 	if dsc, ok := txOnReceived.Description.(tlb.TransactionDescriptionOrdinary); ok {
+		res.Aborted = dsc.Aborted
 		if dsc.BouncePhase != nil {
 			if _, ok = dsc.BouncePhase.Phase.(tlb.BouncePhaseOk); ok {
 				// transaction was bounced, and coins were returned to sender
@@ -254,6 +265,9 @@ func MapToReceivedMessage(txOnReceived *tlb.Transaction) (ReceivedMessage, error
 				res.EmittedBouncedMessage = true
 			}
 		}
+		if skipped, ok := dsc.ComputePhase.Phase.(tlb.ComputePhaseSkipped); ok {
+			res.ComputeSkipReason = skipped.Reason.Type
+		}
 		computePhase, ok := dsc.ComputePhase.Phase.(tlb.ComputePhaseVM)
 		if ok {
 			res.Success = computePhase.Success
@@ -266,6 +280,9 @@ func MapToReceivedMessage(txOnReceived *tlb.Transaction) (ReceivedMessage, error
 			res.MagicFee.Sub(res.MagicFee, res.StorageFeeCharged)
 		}
 		if dsc.ActionPhase != nil {
+			res.ActionFailed = !dsc.ActionPhase.Success
+			res.ActionResultCode = dsc.ActionPhase.ResultCode
+			res.ActionNoFunds = dsc.ActionPhase.NoFunds
 			if dsc.ActionPhase.TotalActionFees != nil {
 				res.TotalActionFees = dsc.ActionPhase.TotalActionFees.Nano()
 				res.MagicFee.Sub(res.MagicFee, res.TotalActionFees)
@@ -433,6 +450,69 @@ func (m *ReceivedMessage) WaitForTrace(c *SignedAPIClient) error {
 	return nil
 }
 
+// PollTrace advances the execution trace of a message by one step without
+// blocking. Every message of the trace with outgoing messages that were not
+// received yet is checked once against the recipients' transactions as of the
+// latest masterchain block, received messages are moved to
+// OutgoingInternalReceivedMessages and checked in the same step. The recipients'
+// transactions checked by a call are skipped by the next ones.
+//
+// Returns true once the entire trace is finalized. Unlike WaitForTrace it can be
+// called repeatedly, each call picks up where the previous one stopped.
+func (m *ReceivedMessage) PollTrace(ctx context.Context, c *SignedAPIClient) (bool, error) {
+	block, err := c.Client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	finalized := true
+	pendingMessages := []*ReceivedMessage{m}
+	for len(pendingMessages) != 0 {
+		msg := pendingMessages[0]
+		pendingMessages = pendingMessages[1:]
+
+		notReceived := make([]*SentMessage, 0, len(msg.OutgoingInternalSentMessages))
+		for i, sentMessage := range msg.OutgoingInternalSentMessages {
+			receivedMessage, err := c.FindReceivedMessage(ctx, block, sentMessage)
+			if err != nil {
+				msg.OutgoingInternalSentMessages = append(notReceived, msg.OutgoingInternalSentMessages[i:]...)
+				return false, fmt.Errorf("failed to poll outgoing message: %w", err)
+			}
+			if receivedMessage == nil {
+				notReceived = append(notReceived, sentMessage)
+				continue
+			}
+			msg.OutgoingInternalReceivedMessages = append(msg.OutgoingInternalReceivedMessages, receivedMessage)
+		}
+		msg.OutgoingInternalSentMessages = notReceived
+
+		if len(notReceived) != 0 {
+			finalized = false
+		}
+		pendingMessages = append(pendingMessages, msg.OutgoingInternalReceivedMessages...)
+	}
+	return finalized, nil
+}
+
+// Clone returns a copy of the trace of a message that PollTrace can advance
+// without modifying the original. The messages and transactions the trace is
+// made of are never modified once mapped and are shared with the original.
+func (m *ReceivedMessage) Clone() ReceivedMessage {
+	clone := *m
+	clone.OutgoingExternalMessages = slices.Clone(m.OutgoingExternalMessages)
+	clone.OutgoingInternalSentMessages = slices.Clone(m.OutgoingInternalSentMessages)
+	for i, sentMessage := range clone.OutgoingInternalSentMessages {
+		sentClone := *sentMessage
+		clone.OutgoingInternalSentMessages[i] = &sentClone
+	}
+	clone.OutgoingInternalReceivedMessages = slices.Clone(m.OutgoingInternalReceivedMessages)
+	for i, receivedMessage := range clone.OutgoingInternalReceivedMessages {
+		receivedClone := receivedMessage.Clone()
+		clone.OutgoingInternalReceivedMessages[i] = &receivedClone
+	}
+	return clone
+}
+
 // OutcomeExitCode returns the first non-success exit code found in this message
 // or any of its outgoing internal messages. If all messages succeeded, it returns
 // the success exit code.
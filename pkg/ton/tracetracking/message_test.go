@@ -0,0 +1,139 @@
+package tracetracking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// accountTransactions serves the transactions of accounts, the other client methods are not implemented.
+type accountTransactions struct {
+	ton.APIClientWrapped
+	txs    map[string][]*tlb.Transaction // by address, oldest first
+	listed []uint64                      // LT every ListTransactions call started from
+}
+
+func (c *accountTransactions) CurrentMasterchainInfo(context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{Workchain: -1}, nil
+}
+
+func (c *accountTransactions) GetAccount(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+	txs := c.txs[addr.String()]
+	if len(txs) == 0 {
+		return &tlb.Account{}, nil
+	}
+	last := txs[len(txs)-1]
+	return &tlb.Account{IsActive: true, LastTxLT: last.LT, LastTxHash: last.Hash}, nil
+}
+
+func (c *accountTransactions) ListTransactions(_ context.Context, addr *address.Address, num uint32, lt uint64, _ []byte) ([]*tlb.Transaction, error) {
+	c.listed = append(c.listed, lt)
+	var page []*tlb.Transaction
+	for _, tx := range c.txs[addr.String()] {
+		if tx.LT <= lt {
+			page = append(page, tx)
+		}
+	}
+	if len(page) == 0 {
+		return nil, ton.ErrNoTransactionsWereFound
+	}
+	return page[max(0, len(page)-int(num)):], nil
+}
+
+// receive appends the transaction in which dst received a message sent by src at createdLT.
+func (c *accountTransactions) receive(src, dst *address.Address, createdLT uint64) {
+	var prevLT uint64
+	if txs := c.txs[dst.String()]; len(txs) > 0 {
+		prevLT = txs[len(txs)-1].LT
+	}
+	lt := max(prevLT, createdLT) + 1
+	tx := &tlb.Transaction{LT: lt, Hash: []byte{byte(lt >> 8), byte(lt)}, PrevTxLT: prevLT, TotalFees: tlb.CurrencyCollection{Coins: tlb.ZeroCoins}}
+	tx.IO.In = &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{
+		SrcAddr: src, DstAddr: dst, CreatedLT: createdLT, Amount: tlb.ZeroCoins, FwdFee: tlb.ZeroCoins,
+	}}
+	c.txs[dst.String()] = append(c.txs[dst.String()], tx)
+}
+
+func testAddress(b byte) *address.Address {
+	data := make([]byte, 32)
+	data[31] = b
+	return address.NewAddress(0, 0, data)
+}
+
+func TestReceivedMessage_PollTrace(t *testing.T) {
+	sender, recipient, other := testAddress(1), testAddress(2), testAddress(3)
+	const sentLT = 100
+	client := &accountTransactions{txs: map[string][]*tlb.Transaction{}}
+	sent := NewSentMessage(&tlb.InternalMessage{SrcAddr: sender, DstAddr: recipient, CreatedLT: sentLT, Amount: tlb.ZeroCoins, FwdFee: tlb.ZeroCoins})
+	trace := ReceivedMessage{
+		LamportTime:                      sentLT - 1,
+		OutgoingInternalSentMessages:     []*SentMessage{&sent},
+		OutgoingInternalReceivedMessages: []*ReceivedMessage{},
+	}
+
+	// every step polls the trace once more, after the recipient received the messages of the step
+	testCases := []struct {
+		name      string
+		received  []*address.Address // senders of the messages received before the poll
+		listed    []uint64           // LT the recipient transactions were listed from
+		finalized bool
+	}{
+		{name: "recipient without transactions"},
+		{name: "other messages received", received: repeat(other, 20), listed: []uint64{120, 104}},
+		{name: "nothing new received"},
+		{name: "message received after others", received: append(repeat(other, 3), sender), listed: []uint64{124}, finalized: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, src := range tc.received {
+				client.receive(src, recipient, sentLT)
+			}
+			client.listed = nil
+
+			finalized, err := trace.PollTrace(t.Context(), &SignedAPIClient{Client: client})
+			require.NoError(t, err)
+			require.Equal(t, tc.finalized, finalized)
+			require.Equal(t, tc.listed, client.listed)
+			if !finalized {
+				require.Len(t, trace.OutgoingInternalSentMessages, 1)
+				require.Empty(t, trace.OutgoingInternalReceivedMessages)
+				return
+			}
+			require.Empty(t, trace.OutgoingInternalSentMessages)
+			require.Len(t, trace.OutgoingInternalReceivedMessages, 1)
+			require.Equal(t, uint64(124), trace.OutgoingInternalReceivedMessages[0].LamportTime)
+			require.Equal(t, Finalized, trace.Status())
+		})
+	}
+}
+
+func TestReceivedMessage_Clone(t *testing.T) {
+	sent := &SentMessage{LamportTime: 11}
+	received := &ReceivedMessage{LamportTime: 12, OutgoingInternalSentMessages: []*SentMessage{{LamportTime: 13}}}
+	m := ReceivedMessage{
+		LamportTime:                      10,
+		OutgoingInternalSentMessages:     []*SentMessage{sent},
+		OutgoingInternalReceivedMessages: []*ReceivedMessage{received},
+	}
+
+	clone := m.Clone()
+	require.Equal(t, m, clone)
+	clone.OutgoingInternalSentMessages[0].ScannedLT = 20
+	clone.OutgoingInternalSentMessages = nil
+	clone.OutgoingInternalReceivedMessages[0].OutgoingInternalSentMessages = nil
+
+	require.Equal(t, []*SentMessage{{LamportTime: 11}}, m.OutgoingInternalSentMessages)
+	require.Equal(t, []*SentMessage{{LamportTime: 13}}, received.OutgoingInternalSentMessages)
+}
+
+func repeat(addr *address.Address, n int) []*address.Address {
+	addrs := make([]*address.Address, n)
+	for i := range addrs {
+		addrs[i] = addr
+	}
+	return addrs
+}
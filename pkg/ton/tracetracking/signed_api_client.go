@@ -2,6 +2,7 @@ package tracetracking
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,6 +12,10 @@ import (
 	"github.com/xssnick/tonutils-go/ton/wallet"
 )
 
+// listTransactionsPageSize is the number of transactions fetched per lite server query when
+// walking an account's history.
+const listTransactionsPageSize = 16
+
 // SignedAPIClient provides a high-level interface for interacting with the TON blockchain.
 // It wraps the low-level TON API client and wallet functionality to provide
 // convenient messages for sending transactions, deploying contracts, and monitoring
@@ -90,3 +95,50 @@ func (c *SignedAPIClient) SubscribeToTransactions(address address.Address, lt ui
 	go c.Client.SubscribeOnTransactions(context.Background(), &address, lt, transactionsReceived)
 	return transactionsReceived
 }
+
+// FindReceivedMessage looks up, without waiting, the transaction in which the
+// recipient of sentMessage received it, walking the recipient's transactions as
+// of the given masterchain block back to the Lamport time of the sent message,
+// or to the latest transaction checked by a previous lookup. Returns nil if the
+// message has not been received yet.
+func (c *SignedAPIClient) FindReceivedMessage(ctx context.Context, block *ton.BlockIDExt, sentMessage *SentMessage) (*ReceivedMessage, error) {
+	dstAddr := sentMessage.InternalMsg.DstAddr
+	account, err := c.Client.GetAccount(ctx, block, dstAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account %s: %w", dstAddr.String(), err)
+	}
+
+	checkedLT := max(sentMessage.LamportTime, sentMessage.ScannedLT)
+	lt, txHash := account.LastTxLT, account.LastTxHash
+walk:
+	for lt > checkedLT {
+		txs, err := c.Client.ListTransactions(ctx, dstAddr, listTransactionsPageSize, lt, txHash)
+		if errors.Is(err, ton.ErrNoTransactionsWereFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions of %s: %w", dstAddr.String(), err)
+		}
+
+		// transactions are ordered from oldest to newest
+		for i := len(txs) - 1; i >= 0; i-- {
+			tx := txs[i]
+			if tx.LT <= checkedLT {
+				break walk
+			}
+			if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+				continue
+			}
+			receivedMessage, err := sentMessage.MapToReceivedMessageIfMatches(tx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process incoming message: %w", err)
+			}
+			if receivedMessage != nil {
+				return receivedMessage, nil
+			}
+		}
+		lt, txHash = txs[0].PrevTxLT, txs[0].PrevTxHash
+	}
+	sentMessage.ScannedLT = max(checkedLT, account.LastTxLT)
+	return nil, nil
+}
@@ -0,0 +1,111 @@
+package txm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/fees"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
+)
+
+var ErrAmountEstimationUnavailable = errors.New("amount estimation unavailable")
+
+// sizeAmount sets the amount of a request asking the Txm to size it: the value requested to be
+// delivered on top of the fees the destination is expected to pay out of the attached value, the
+// compute fee for the expected gas, the storage fee of the state init it deploys, plus the forward
+// fee when it is not paid separately, increased by the safety margin. Returns the estimated fees
+// before the margin. When the fees can not be estimated the fallback amount is attached on top
+// instead, and no estimate is returned.
+func (t *Txm) sizeAmount(tx *Tx, expectedGas uint64) (tlb.Coins, error) {
+	estimate, err := t.estimateFees(tx, expectedGas)
+	if err != nil {
+		if t.Config.AmountFallbackNano == 0 {
+			return tlb.ZeroCoins, err
+		}
+		t.Logger.Warnw("failed to estimate fees, attaching the fallback amount", "to", tx.To.String(),
+			"fallback", tlb.FromNanoTONU(t.Config.AmountFallbackNano).String(), "err", err)
+		tx.Amount = tlb.FromNanoTON(new(big.Int).Add(tx.Amount.Nano(), new(big.Int).SetUint64(t.Config.AmountFallbackNano)))
+		return tlb.ZeroCoins, nil
+	}
+
+	estimatedFee := new(big.Int).Add(estimate.ComputeFee.Nano(), estimate.StorageFee.Nano())
+	if tx.Mode&wallet.PayGasSeparately == 0 {
+		estimatedFee.Add(estimatedFee, estimate.ForwardFee.Nano())
+	}
+
+	withMargin := new(big.Int).Mul(estimatedFee, big.NewInt(int64(100+t.Config.AmountSafetyMarginPercent))) //nolint:gosec // percentage
+	withMargin.Div(withMargin, big.NewInt(100))
+	tx.Amount = tlb.FromNanoTON(withMargin.Add(withMargin, tx.Amount.Nano()))
+	return tlb.FromNanoTON(estimatedFee), nil
+}
+
+// estimateFees estimates the fees of a transaction, failing with ErrAmountEstimationUnavailable without
+// an estimator or before it loaded the fee prices.
+func (t *Txm) estimateFees(tx *Tx, expectedGas uint64) (fees.Estimate, error) {
+	if t.FeeEstimator == nil {
+		return fees.Estimate{}, fmt.Errorf("%w: no fee estimator", ErrAmountEstimationUnavailable)
+	}
+
+	estimate, err := t.FeeEstimator.Estimate(fees.Message{
+		Workchain:       tx.From.Workchain(),
+		DestWorkchain:   tx.To.Workchain(),
+		Body:            tx.Body,
+		StateInit:       tx.StateInit,
+		GasUsed:         expectedGas,
+		StorageDuration: t.Config.StorageFeeDuration,
+	})
+	if err != nil {
+		return fees.Estimate{}, fmt.Errorf("%w: %w", ErrAmountEstimationUnavailable, err)
+	}
+	return estimate, nil
+}
+
+// traceFees returns the fees paid out of the value attached to a transaction: the fees charged to
+// every transaction of its trace after the wallet one, including forwarding the messages they sent,
+// and forwarding the message of the transaction itself when it is not paid separately.
+func traceFees(tx *Tx) *big.Int {
+	total := big.NewInt(0)
+	add := func(v *big.Int) {
+		if v != nil {
+			total.Add(total, v)
+		}
+	}
+
+	var visit func(m *tracetracking.ReceivedMessage)
+	visit = func(m *tracetracking.ReceivedMessage) {
+		add(m.ImportFee)
+		add(m.GasFee)
+		add(m.StorageFeeCharged)
+		add(m.TotalActionFees)
+		add(m.MagicFee)
+		for _, sent := range m.OutgoingInternalSentMessages {
+			add(sent.FwdFee)
+		}
+		for _, received := range m.OutgoingInternalReceivedMessages {
+			add(received.FwdFee)
+			visit(received)
+		}
+	}
+
+	// the wallet transaction, and the internal transfers it sends itself, are paid by the sender
+	// balance, not by the attached value
+	var visitWallet func(m *tracetracking.ReceivedMessage)
+	visitWallet = func(m *tracetracking.ReceivedMessage) {
+		for _, received := range m.OutgoingInternalReceivedMessages {
+			if isInternalTransfer(received.InternalMsg) {
+				visitWallet(received)
+				continue
+			}
+			if tx.Mode&wallet.PayGasSeparately == 0 {
+				add(received.FwdFee)
+			}
+			visit(received)
+		}
+	}
+	visitWallet(&tx.ReceivedMessage)
+	return total
+}
@@ -5,19 +5,57 @@ import (
 )
 
 type Config struct {
-	BroadcastChanSize        uint          // Size of the broadcast queue
-	ConfirmPollSecs          uint          // Interval to poll for transaction confirmations
-	SendRetryDelay           time.Duration // Delay between send retry attempts
-	MaxSendRetryAttempts     uint          // Max retries before giving up broadcasting
-	TxExpirationMins         uint          // Time (in minutes) after which an unconfirmed transaction is considered expired
-	StickyNodeContextEnabled bool          // Whether to use sticky context (single node per lifecycle)
+	BroadcastChanSize         uint            // Size of each broadcast priority lane of a sender
+	ConfirmPollSecs           uint            // Interval to poll for transaction confirmations
+	SendRetryDelay            time.Duration   // Delay between send retry attempts
+	MaxSendRetryAttempts      uint            // Max retries before giving up broadcasting
+	TxExpirationMins          uint            // Time (in minutes) after which an unconfirmed transaction is considered expired
+	StickyNodeContextEnabled  bool            // Whether to use sticky context (single node per lifecycle)
+	ResubmitExpired           bool            // Whether to rebuild and resend a transaction whose external message expired without landing
+	MaxResubmitAttempts       uint            // Max resubmissions of an expired transaction before giving up
+	TracePollTimeout          time.Duration   // Upper bound for advancing a single trace by one step
+	MaxConcurrentTracePolls   uint            // Max number of traces advanced concurrently per confirmation poll
+	WalletSelectionPolicy     SelectionPolicy // How a sender wallet is picked for requests that do not name one
+	MaxBatchSize              uint            // Max queued transactions sent together in one highload external message
+	FeeReserveNano            uint64          // Fees expected per transaction for the sender wallet on top of its amount and forward fee, reserved when checking the sender balance
+	RetentionPeriod           time.Duration   // How long finalized, errored and expired transactions are kept in memory, 0 keeps them regardless of age
+	MaxRetainedTxs            uint            // Max finalized, errored and expired transactions kept in memory per sender, 0 for no limit
+	PruneInterval             time.Duration   // Interval between prunes of the transactions past retention
+	AmountSafetyMarginPercent uint            // Margin added to the estimated fees when sizing the amount of an AutoAmount request
+	AmountFallbackNano        uint64          // Fees attached to an AutoAmount request when they can not be estimated, 0 refuses the request
+	StorageFeeDuration        time.Duration   // How long the storage fees of a state init deployed by an AutoAmount request are prepaid for
+	RebroadcastInterval       time.Duration   // Interval between sends of an external message not included yet to the next lite server, 0 disables rebroadcasts
+	ExpiredMsgGracePeriod     time.Duration   // How long past the expiration of an external message it is still looked up before resubmitting, covers lite server lag and clock skew
+	DrainTimeout              time.Duration   // How long Close waits for queued transactions to be sent and their traces to finalize, 0 stops immediately
+	HighPriorityWeight        uint            // Share of the broadcast batches given to the high priority lane when several lanes have queued transactions
+	NormalPriorityWeight      uint            // Share of the broadcast batches given to the normal priority lane
+	LowPriorityWeight         uint            // Share of the broadcast batches given to the low priority lane
 }
 
 var DefaultConfigSet = Config{
-	BroadcastChanSize:        100,
-	ConfirmPollSecs:          5,
-	SendRetryDelay:           3 * time.Second,
-	MaxSendRetryAttempts:     5,
-	TxExpirationMins:         5,
-	StickyNodeContextEnabled: true,
+	BroadcastChanSize:         100,
+	ConfirmPollSecs:           5,
+	SendRetryDelay:            3 * time.Second,
+	MaxSendRetryAttempts:      5,
+	TxExpirationMins:          5,
+	StickyNodeContextEnabled:  true,
+	ResubmitExpired:           true,
+	MaxResubmitAttempts:       3,
+	TracePollTimeout:          10 * time.Second,
+	MaxConcurrentTracePolls:   32,
+	WalletSelectionPolicy:     SelectLeastLoaded,
+	MaxBatchSize:              32,
+	FeeReserveNano:            10_000_000, // 0.01 TON
+	RetentionPeriod:           time.Hour,
+	MaxRetainedTxs:            1000,
+	PruneInterval:             time.Minute,
+	AmountSafetyMarginPercent: 50,
+	AmountFallbackNano:        50_000_000, // 0.05 TON
+	StorageFeeDuration:        30 * 24 * time.Hour,
+	RebroadcastInterval:       10 * time.Second,
+	ExpiredMsgGracePeriod:     30 * time.Second,
+	DrainTimeout:              30 * time.Second,
+	HighPriorityWeight:        6,
+	NormalPriorityWeight:      3,
+	LowPriorityWeight:         1,
 }
@@ -0,0 +1,104 @@
+package txm
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+// FailurePhase is the phase of the transaction that failed a trace.
+type FailurePhase string
+
+const (
+	FailurePhaseCompute FailurePhase = "compute" // the contract threw, ran out of gas, or could not run
+	FailurePhaseAction  FailurePhase = "action"  // the contract ran, but the messages it sent or its other actions failed
+)
+
+// Failure describes where and why the trace of a transaction failed.
+type Failure struct {
+	Hop        int          `json:"hop"`                  // depth of the failed transaction in the trace, 0 for the wallet transaction
+	Account    string       `json:"account"`              // address of the account whose transaction failed
+	TxHash     []byte       `json:"txHash"`               // hash of the failed transaction
+	Phase      FailurePhase `json:"phase"`                // phase that failed
+	ExitCode   tvm.ExitCode `json:"exitCode"`             // compute exit code, or action phase result code
+	SkipReason string       `json:"skipReason,omitempty"` // why the compute phase did not run, when it did not
+	Bounced    bool         `json:"bounced"`              // whether the value was bounced back to the sender of the failed hop
+	// whether sending again with more attached value would plausibly succeed, the failed hop ran out of
+	// gas or of funds for the messages it sends
+	RetryWithMoreValue bool `json:"retryWithMoreValue"`
+}
+
+func (f *Failure) String() string {
+	reason := f.ExitCode.Describe()
+	if f.SkipReason != "" {
+		reason = "skipped: " + f.SkipReason
+	}
+	return fmt.Sprintf("%s phase failed at hop %d (%s): %s (bounced: %t, retry with more value: %t)",
+		f.Phase, f.Hop, f.Account, reason, f.Bounced, f.RetryWithMoreValue)
+}
+
+// classifyFailure returns the first failure in the trace of a transaction, depth first in message
+// order, or nil when the trace succeeded. The internal transfers the wallet sends itself belong to
+// the wallet hop.
+func classifyFailure(m *tracetracking.ReceivedMessage) *Failure {
+	return classifyHop(m, 0)
+}
+
+func classifyHop(m *tracetracking.ReceivedMessage, hop int) *Failure {
+	if failure := hopFailure(m, hop); failure != nil {
+		return failure
+	}
+	for _, received := range m.OutgoingInternalReceivedMessages {
+		next := hop + 1
+		if isInternalTransfer(received.InternalMsg) {
+			next = hop
+		}
+		if failure := classifyHop(received, next); failure != nil {
+			return failure
+		}
+	}
+	return nil
+}
+
+// hopFailure classifies the transaction of a single hop, the wallet balance is not topped up by
+// the attached value, so that retrying with more value never helps the wallet transaction.
+func hopFailure(m *tracetracking.ReceivedMessage, hop int) *Failure {
+	failure := &Failure{Hop: hop, Account: hopAccount(m), TxHash: m.TxHash, Bounced: m.EmittedBouncedMessage}
+
+	switch {
+	case m.ComputeSkipReason != "":
+		// value sent to an account that is not deployed yet is credited to it unless bounced back
+		if m.ComputeSkipReason == tlb.ComputeSkipReasonNoState && !m.EmittedBouncedMessage {
+			return nil
+		}
+		failure.Phase = FailurePhaseCompute
+		failure.SkipReason = string(m.ComputeSkipReason)
+		failure.RetryWithMoreValue = hop > 0 && m.ComputeSkipReason == tlb.ComputeSkipReasonNoGas
+	case !m.Success:
+		failure.Phase = FailurePhaseCompute
+		failure.ExitCode = m.ExitCode
+		failure.RetryWithMoreValue = hop > 0 && (m.ExitCode == tvm.ExitCodeOutOfGasError || m.ExitCode == tvm.ExitCodeOutOfGasErrorVariant)
+	case m.ActionFailed:
+		failure.Phase = FailurePhaseAction
+		failure.ExitCode = tvm.ExitCode(m.ActionResultCode)
+		failure.RetryWithMoreValue = hop > 0 && (m.ActionNoFunds ||
+			failure.ExitCode == tvm.ExitCodeNotEnoughToncoin || failure.ExitCode == tvm.ExitCodeCannotProcessAMessage)
+	default:
+		return nil
+	}
+	return failure
+}
+
+func hopAccount(m *tracetracking.ReceivedMessage) string {
+	switch {
+	case m.InternalMsg != nil && m.InternalMsg.DstAddr != nil:
+		return m.InternalMsg.DstAddr.String()
+	case m.ExternalMsg != nil && m.ExternalMsg.DstAddr != nil:
+		return m.ExternalMsg.DstAddr.String()
+	default:
+		return ""
+	}
+}
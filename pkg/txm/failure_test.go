@@ -0,0 +1,159 @@
+package txm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+// testTrace is the trace of a batch sent by a highload wallet: the external message received by the
+// wallet, the internal transfer it sends itself, two recipients of the batch and a message the first
+// recipient sends on.
+type testTrace struct {
+	wallet, transfer, recipient, other, next *tracetracking.ReceivedMessage
+}
+
+func newTestTrace() testTrace {
+	wallet, recipient, other, next := testAddress(1), testAddress(2), testAddress(3), testAddress(4)
+	hop := func(msg *tlb.InternalMessage, outgoing ...*tracetracking.ReceivedMessage) *tracetracking.ReceivedMessage {
+		return &tracetracking.ReceivedMessage{InternalMsg: msg, TxHash: []byte(msg.DstAddr.String()), Success: true, OutgoingInternalReceivedMessages: outgoing}
+	}
+	internal := func(src, dst *address.Address, body *cell.Cell) *tlb.InternalMessage {
+		return &tlb.InternalMessage{SrcAddr: src, DstAddr: dst, Body: body}
+	}
+
+	tr := testTrace{
+		next:  hop(internal(recipient, next, cell.BeginCell().EndCell())),
+		other: hop(internal(wallet, other, cell.BeginCell().EndCell())),
+	}
+	tr.recipient = hop(internal(wallet, recipient, cell.BeginCell().EndCell()), tr.next)
+	tr.transfer = hop(internal(wallet, wallet, cell.BeginCell().MustStoreUInt(highloadInternalTransferOp, 32).EndCell()), tr.recipient, tr.other)
+	tr.wallet = &tracetracking.ReceivedMessage{
+		ExternalMsg:                      &tlb.ExternalMessageIn{DstAddr: wallet},
+		TxHash:                           []byte(wallet.String()),
+		Success:                          true,
+		OutgoingInternalReceivedMessages: []*tracetracking.ReceivedMessage{tr.transfer},
+	}
+	return tr
+}
+
+func TestClassifyFailure(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fail    func(tr testTrace) *tracetracking.ReceivedMessage // fails hops of the trace, returns the one expected to be reported
+		failure Failure
+	}{
+		{name: "trace succeeded", fail: func(testTrace) *tracetracking.ReceivedMessage { return nil }},
+		{
+			name: "wallet out of gas",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.wallet.Success, tr.wallet.ExitCode = false, tvm.ExitCodeOutOfGasError
+				return tr.wallet
+			},
+			failure: Failure{Hop: 0, Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeOutOfGasError},
+		},
+		{
+			name: "internal transfer without funds for the batch",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.transfer.ActionFailed, tr.transfer.ActionResultCode, tr.transfer.ActionNoFunds = true, int32(tvm.ExitCodeNotEnoughToncoin), true
+				return tr.transfer
+			},
+			// the internal transfer belongs to the wallet hop, more value attached to the batch does not fund it
+			failure: Failure{Hop: 0, Phase: FailurePhaseAction, ExitCode: tvm.ExitCodeNotEnoughToncoin},
+		},
+		{
+			name: "recipient out of gas",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.recipient.Success, tr.recipient.ExitCode, tr.recipient.EmittedBouncedMessage = false, tvm.ExitCodeOutOfGasErrorVariant, true
+				return tr.recipient
+			},
+			failure: Failure{Hop: 1, Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeOutOfGasErrorVariant, Bounced: true, RetryWithMoreValue: true},
+		},
+		{
+			name: "recipient threw",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.recipient.Success, tr.recipient.ExitCode = false, tvm.ExitCodeTolkUnmatchedOpcode
+				return tr.recipient
+			},
+			failure: Failure{Hop: 1, Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeTolkUnmatchedOpcode},
+		},
+		{
+			name: "recipient not deployed",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.other.ComputeSkipReason = tlb.ComputeSkipReasonNoState
+				return nil
+			},
+		},
+		{
+			name: "recipient not deployed bounced the value",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.other.ComputeSkipReason, tr.other.EmittedBouncedMessage = tlb.ComputeSkipReasonNoState, true
+				return tr.other
+			},
+			failure: Failure{Hop: 1, Phase: FailurePhaseCompute, SkipReason: string(tlb.ComputeSkipReasonNoState), Bounced: true},
+		},
+		{
+			name: "recipient without gas to run",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.other.ComputeSkipReason = tlb.ComputeSkipReasonNoGas
+				return tr.other
+			},
+			failure: Failure{Hop: 1, Phase: FailurePhaseCompute, SkipReason: string(tlb.ComputeSkipReasonNoGas), RetryWithMoreValue: true},
+		},
+		{
+			name: "recipient without funds to send on",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.recipient.ActionFailed, tr.recipient.ActionResultCode = true, int32(tvm.ExitCodeCannotProcessAMessage)
+				return tr.recipient
+			},
+			failure: Failure{Hop: 1, Phase: FailurePhaseAction, ExitCode: tvm.ExitCodeCannotProcessAMessage, RetryWithMoreValue: true},
+		},
+		{
+			name: "recipient action list invalid",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.recipient.ActionFailed, tr.recipient.ActionResultCode = true, int32(tvm.ExitCodeActionListIsInvalid)
+				return tr.recipient
+			},
+			failure: Failure{Hop: 1, Phase: FailurePhaseAction, ExitCode: tvm.ExitCodeActionListIsInvalid},
+		},
+		{
+			name: "second hop failed",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.next.Success, tr.next.ExitCode = false, tvm.ExitCodeIntegerOverflow
+				return tr.next
+			},
+			failure: Failure{Hop: 2, Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeIntegerOverflow},
+		},
+		{
+			name: "first failure depth first",
+			fail: func(tr testTrace) *tracetracking.ReceivedMessage {
+				tr.next.Success, tr.next.ExitCode = false, tvm.ExitCodeIntegerOverflow
+				tr.other.Success, tr.other.ExitCode = false, tvm.ExitCodeOutOfGasError
+				return tr.next
+			},
+			failure: Failure{Hop: 2, Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeIntegerOverflow},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTestTrace()
+			failed := tc.fail(tr)
+
+			failure := classifyFailure(tr.wallet)
+			if failed == nil {
+				require.Nil(t, failure)
+				return
+			}
+			expected := tc.failure
+			expected.Account, expected.TxHash = hopAccount(failed), failed.TxHash
+			require.Equal(t, &expected, failure)
+			require.NotEmpty(t, failure.Account)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package txm
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+var (
+	promQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ton_txm_queue_depth",
+		Help: "Transactions waiting in a broadcast lane of a sender, by priority",
+	}, []string{"chainID", "sender", "priority"})
+	promInflight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ton_txm_inflight",
+		Help: "Transactions of a sender included on-chain and awaiting trace finalization",
+	}, []string{"chainID", "sender"})
+	promBroadcastAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ton_txm_broadcast_attempts_total",
+		Help: "Attempts to send a transaction to a lite server",
+	}, []string{"chainID", "sender"})
+	promBroadcastRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ton_txm_broadcast_retries_total",
+		Help: "Transactions sent again, after a failed send, to other lite servers while not included, or after their external message expired",
+	}, []string{"chainID", "sender", "reason"})
+	promEnqueueToBroadcast = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ton_txm_enqueue_to_broadcast_seconds",
+		Help:    "Time from a transaction being enqueued to its external message being sent",
+		Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300},
+	}, []string{"chainID", "sender"})
+	promBroadcastToFinalized = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ton_txm_broadcast_to_finalized_seconds",
+		Help:    "Time from the external message of a transaction being sent to its trace finalizing",
+		Buckets: []float64{1, 5, 10, 20, 30, 60, 120, 300, 600},
+	}, []string{"chainID", "sender"})
+	promFeesSpent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ton_txm_fees_spent_ton_total",
+		Help: "Fees spent by finalized transactions in TON, forwarding their message and along their trace",
+	}, []string{"chainID", "sender", "kind"})
+	promFinalized = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ton_txm_finalized_total",
+		Help: "Transactions whose trace finalized",
+	}, []string{"chainID", "sender"})
+	promFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ton_txm_failed_total",
+		Help: "Transactions whose trace finalized with a failure, by the exit code of the failed transaction",
+	}, []string{"chainID", "sender", "exitCode"})
+	promDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ton_txm_dropped_total",
+		Help: "Transactions that errored before landing or expired, by terminal state",
+	}, []string{"chainID", "sender", "state"})
+)
+
+// Reasons a transaction is sent again.
+const (
+	retryReasonSendFailed  = "send_failed"
+	retryReasonExpired     = "expired"
+	retryReasonRebroadcast = "rebroadcast"
+)
+
+// txmMetrics records the metrics of the Txm of a chain.
+type txmMetrics struct {
+	chainID string
+}
+
+func (m txmMetrics) setQueueDepth(sender string, priority Priority, depth int) {
+	promQueueDepth.WithLabelValues(m.chainID, sender, priority.String()).Set(float64(depth))
+}
+
+func (m txmMetrics) setInflight(sender string, count int) {
+	promInflight.WithLabelValues(m.chainID, sender).Set(float64(count))
+}
+
+func (m txmMetrics) broadcastAttempts(sender string, txs int) {
+	promBroadcastAttempts.WithLabelValues(m.chainID, sender).Add(float64(txs))
+}
+
+func (m txmMetrics) broadcastRetries(sender string, reason string, txs int) {
+	promBroadcastRetries.WithLabelValues(m.chainID, sender, reason).Add(float64(txs))
+}
+
+func (m txmMetrics) broadcasted(sender string, tx *Tx) {
+	promEnqueueToBroadcast.WithLabelValues(m.chainID, sender).Observe(time.Since(tx.CreatedAt).Seconds())
+}
+
+func (m txmMetrics) finalized(sender string, tx *Tx, succeeded bool, exitCode tvm.ExitCode) {
+	// broadcast time is not persisted, transactions resumed after a restart are not observed
+	if !tx.BroadcastAt.IsZero() {
+		promBroadcastToFinalized.WithLabelValues(m.chainID, sender).Observe(time.Since(tx.BroadcastAt).Seconds())
+	}
+
+	// forwarding the message of the transaction, unless paid out of the attached value and part of the
+	// trace fees, the wallet fees are shared by the transactions of a batch and not attributed
+	if tx.Mode&wallet.PayGasSeparately != 0 {
+		forwardFee := big.NewInt(0)
+		for _, received := range tx.ReceivedMessage.OutgoingInternalReceivedMessages {
+			if received.FwdFee != nil {
+				forwardFee.Add(forwardFee, received.FwdFee)
+			}
+		}
+		promFeesSpent.WithLabelValues(m.chainID, sender, "forward").Add(toTON(tlb.FromNanoTON(forwardFee)))
+	}
+	promFeesSpent.WithLabelValues(m.chainID, sender, "trace").Add(toTON(tlb.FromNanoTON(traceFees(tx))))
+
+	promFinalized.WithLabelValues(m.chainID, sender).Inc()
+	if !succeeded {
+		promFailed.WithLabelValues(m.chainID, sender, strconv.Itoa(int(exitCode))).Inc()
+	}
+}
+
+func (m txmMetrics) dropped(sender string, state TxState) {
+	promDropped.WithLabelValues(m.chainID, sender, string(state)).Inc()
+}
+
+func toTON(c tlb.Coins) float64 {
+	ton, _ := new(big.Float).Quo(new(big.Float).SetInt(c.Nano()), big.NewFloat(1e9)).Float64()
+	return ton
+}
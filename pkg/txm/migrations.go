@@ -0,0 +1,33 @@
+package txm
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/sqlutil"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/migrate"
+)
+
+// migrationsTable records the versions of the Txm migrations that were applied.
+const migrationsTable = "ton_txm_migrations"
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns the goose migrations of the tables the DSORM persists transactions to,
+// for hosts applying the migrations of the relayer themselves.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err) // the directory is embedded
+	}
+	return sub
+}
+
+// Migrate applies the pending migrations of the tables the DSORM persists transactions to.
+func Migrate(ctx context.Context, lggr logger.Logger, ds sqlutil.DataSource) error {
+	return migrate.Up(ctx, lggr, ds, Migrations(), migrationsTable)
+}
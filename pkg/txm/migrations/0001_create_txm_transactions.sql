@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE SCHEMA IF NOT EXISTS ton;
+
+CREATE TABLE ton.txm_transactions (
+    id                TEXT NOT NULL,
+    chain_id          TEXT NOT NULL,
+    state             TEXT NOT NULL,
+    from_address      TEXT NOT NULL,
+    to_address        TEXT NOT NULL,
+    amount            NUMERIC(78, 0) NOT NULL,
+    mode              SMALLINT NOT NULL,
+    bounce            BOOLEAN NOT NULL,
+    body              BYTEA,
+    state_init        BYTEA,
+    lt                BIGINT,
+    tx_hash           BYTEA,
+    exit_code         INTEGER,
+    trace_succeeded   BOOLEAN,
+    total_action_fees NUMERIC(78, 0),
+    error             TEXT,
+    expires_at        TIMESTAMPTZ NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL,
+    updated_at        TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chain_id, id)
+);
+
+CREATE INDEX idx_txm_transactions_chain_state ON ton.txm_transactions (chain_id, state);
+CREATE UNIQUE INDEX idx_txm_transactions_chain_from_lt ON ton.txm_transactions (chain_id, from_address, lt) WHERE lt IS NOT NULL;
+
+-- +goose Down
+DROP TABLE ton.txm_transactions;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE ton.txm_transactions
+    ADD COLUMN in_msg_hash    BYTEA,
+    ADD COLUMN msg_expires_at TIMESTAMPTZ,
+    ADD COLUMN attempts       INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE ton.txm_transactions
+    DROP COLUMN in_msg_hash,
+    DROP COLUMN msg_expires_at,
+    DROP COLUMN attempts;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- transactions sent in one highload batch share the wallet transaction, each is identified by the LT of its outgoing message
+ALTER TABLE ton.txm_transactions ADD COLUMN msg_lt BIGINT;
+
+DROP INDEX ton.idx_txm_transactions_chain_from_lt;
+CREATE UNIQUE INDEX idx_txm_transactions_chain_from_msg_lt ON ton.txm_transactions (chain_id, from_address, msg_lt) WHERE msg_lt IS NOT NULL;
+
+-- +goose Down
+DROP INDEX ton.idx_txm_transactions_chain_from_msg_lt;
+CREATE UNIQUE INDEX idx_txm_transactions_chain_from_lt ON ton.txm_transactions (chain_id, from_address, lt) WHERE lt IS NOT NULL;
+
+ALTER TABLE ton.txm_transactions DROP COLUMN msg_lt;
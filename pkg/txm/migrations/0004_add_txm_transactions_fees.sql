@@ -0,0 +1,8 @@
+-- +goose Up
+-- fees an automatically sized amount was estimated for, against the fees actually paid out of it along the trace
+ALTER TABLE ton.txm_transactions ADD COLUMN estimated_fee NUMERIC(78, 0);
+ALTER TABLE ton.txm_transactions ADD COLUMN trace_fees NUMERIC(78, 0);
+
+-- +goose Down
+ALTER TABLE ton.txm_transactions DROP COLUMN trace_fees;
+ALTER TABLE ton.txm_transactions DROP COLUMN estimated_fee;
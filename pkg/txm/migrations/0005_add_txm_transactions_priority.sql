@@ -0,0 +1,6 @@
+-- +goose Up
+-- broadcast lane of the transaction, kept so that resumed transactions are queued in the same lane
+ALTER TABLE ton.txm_transactions ADD COLUMN priority SMALLINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE ton.txm_transactions DROP COLUMN priority;
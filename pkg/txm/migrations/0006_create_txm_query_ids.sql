@@ -0,0 +1,12 @@
+-- +goose Up
+-- end of the highload query ID range reserved by each sender wallet, allocation resumes from it after a restart
+CREATE TABLE ton.txm_query_ids (
+    chain_id       TEXT NOT NULL,
+    wallet_address TEXT NOT NULL,
+    cursor         BIGINT NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chain_id, wallet_address)
+);
+
+-- +goose Down
+DROP TABLE ton.txm_query_ids;
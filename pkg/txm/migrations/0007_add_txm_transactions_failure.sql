@@ -0,0 +1,6 @@
+-- +goose Up
+-- where and why the trace of a finalized transaction failed, see txm.Failure
+ALTER TABLE ton.txm_transactions ADD COLUMN failure JSONB;
+
+-- +goose Down
+ALTER TABLE ton.txm_transactions DROP COLUMN failure;
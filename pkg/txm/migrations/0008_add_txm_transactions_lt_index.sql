@@ -0,0 +1,6 @@
+-- +goose Up
+-- transactions are looked up by the LT of the wallet transaction that sent them, shared by a batch
+CREATE INDEX idx_txm_transactions_chain_from_lt ON ton.txm_transactions (chain_id, from_address, lt) WHERE lt IS NOT NULL;
+
+-- +goose Down
+DROP INDEX ton.idx_txm_transactions_chain_from_lt;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- the lite servers the external message of a broadcasting transaction is sent again to rotate with the count
+ALTER TABLE ton.txm_transactions ADD COLUMN rebroadcasts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE ton.txm_transactions DROP COLUMN rebroadcasts;
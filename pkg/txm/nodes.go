@@ -0,0 +1,80 @@
+package txm
+
+import (
+	"context"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// nodePinner is implemented by the lite server pools that pin a context to a lite server by ID, requests
+// pinned to a lite server that left the pool are sent to another one.
+type nodePinner interface {
+	StickyContextWithNodeID(ctx context.Context, nodeID uint32) context.Context
+}
+
+var _ nodePinner = (*liteclient.ConnectionPool)(nil)
+
+// stickyContext pins ctx to a lite server when sticky node contexts are enabled, so that the reads
+// following the broadcast of a transaction see what that lite server saw. Without a lite server yet,
+// e.g. for a transaction resumed after a restart, one is picked by the pool.
+// Returns the pinned context and its lite server, 0 when not pinned.
+func (t *Txm) stickyContext(ctx context.Context, nodeID uint32) (context.Context, uint32) {
+	if !t.Config.StickyNodeContextEnabled {
+		return ctx, 0
+	}
+	client := t.Client.Client.Client()
+
+	if pinner, ok := client.(nodePinner); ok && nodeID != 0 {
+		return pinner.StickyContextWithNodeID(ctx, nodeID), nodeID
+	}
+	pinned := client.StickyContext(ctx)
+	return pinned, client.StickyNodeID(pinned)
+}
+
+// failover returns the lite server to use once nodeID failed: the next one in the order of the pool,
+// wrapping around, so that failing over is deterministic and goes through every lite server.
+func (t *Txm) failover(ctx context.Context, nodeID uint32) uint32 {
+	if !t.Config.StickyNodeContextEnabled {
+		return 0
+	}
+	client := t.Client.Client.Client()
+
+	var first uint32
+	found := false
+	pinned := ctx
+	for {
+		next, err := client.StickyContextNextNode(pinned)
+		if err != nil {
+			// nodeID was the last lite server or left the pool
+			return first
+		}
+		id := client.StickyNodeID(next)
+		if found {
+			return id
+		}
+		if first == 0 {
+			first = id
+		}
+		found = id == nodeID
+		pinned = next
+	}
+}
+
+// nodeContext returns a context pinned to the n-th lite server of the pool, in the order of the pool,
+// wrapping around once every lite server was used.
+func nodeContext(ctx context.Context, client ton.LiteClient, n int) context.Context {
+	pinned := ctx
+	for i := 0; i <= n; i++ {
+		next, err := client.StickyContextNextNode(pinned)
+		if err != nil {
+			if i == 0 {
+				// no active lite server, let the balancer pick one
+				return ctx
+			}
+			return nodeContext(ctx, client, n%i)
+		}
+		pinned = next
+	}
+	return pinned
+}
@@ -0,0 +1,185 @@
+package txm
+
+import (
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tlb"
+
+	commontypes "github.com/smartcontractkit/chainlink-common/pkg/types"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+// statusChanSize bounds the updates buffered per subscription. When a subscriber falls behind the
+// oldest buffered update is dropped, so that the latest state of the transaction is always delivered.
+const statusChanSize = 16
+
+// TxStatusUpdate is a lifecycle transition of a transaction, emitted to its subscribers.
+type TxStatusUpdate struct {
+	ID              string
+	State           TxState
+	Status          commontypes.TransactionStatus
+	ExitCode        tvm.ExitCode                   // set once the transaction is finalized
+	TotalActionFees tlb.Coins                      // set once the transaction is finalized
+	Failure         *Failure                       // set once the transaction is finalized, when its trace failed
+	ReceivedMessage *tracetracking.ReceivedMessage // trace of the transaction, set once it is included on-chain
+	Reason          string                         // why the transaction errored or expired
+}
+
+// Terminal returns whether the transaction reached a final state, no update follows a terminal one.
+func (u TxStatusUpdate) Terminal() bool {
+	return u.State == TxStateFinalized || u.State == TxStateErrored || u.State == TxStateExpired
+}
+
+// transactionStatus translates the lifecycle state of a transaction to chainlink common statuses.
+func transactionStatus(state TxState, traceSucceeded bool) commontypes.TransactionStatus {
+	switch state {
+	case TxStateEnqueued, TxStateBroadcasting:
+		return commontypes.Pending
+	case TxStateUnconfirmed:
+		return commontypes.Unconfirmed
+	case TxStateFinalized:
+		if traceSucceeded {
+			return commontypes.Finalized
+		}
+		return commontypes.Failed
+	case TxStateErrored, TxStateExpired:
+		return commontypes.Fatal
+	default:
+		return commontypes.Unknown
+	}
+}
+
+type statusSubscription struct {
+	ch       chan TxStatusUpdate
+	received bool // whether an update was delivered, the initial snapshot is skipped when one was
+}
+
+// statusNotifier fans the transitions of transactions out to the subscribers of each transaction ID.
+type statusNotifier struct {
+	lock   sync.Mutex
+	subs   map[string][]*statusSubscription // subscriptions by transaction ID
+	closed bool
+}
+
+func newStatusNotifier() *statusNotifier {
+	return &statusNotifier{subs: map[string][]*statusSubscription{}}
+}
+
+// subscribe registers a subscription for a transaction, the returned func unregisters it.
+func (n *statusNotifier) subscribe(id string) (*statusSubscription, func()) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	sub := &statusSubscription{ch: make(chan TxStatusUpdate, statusChanSize)}
+	if n.closed {
+		close(sub.ch)
+		return sub, func() {}
+	}
+	n.subs[id] = append(n.subs[id], sub)
+	return sub, func() { n.unsubscribe(id, sub) }
+}
+
+func (n *statusNotifier) unsubscribe(id string, sub *statusSubscription) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.remove(id, sub)
+}
+
+// snapshot delivers the state of the transaction read after subscribing, unless a transition was
+// delivered in the meantime which is at least as recent.
+func (n *statusNotifier) snapshot(sub *statusSubscription, update TxStatusUpdate) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if sub.received || !n.isSubscribed(update.ID, sub) {
+		return
+	}
+	n.deliver(sub, update)
+	if update.Terminal() {
+		n.remove(update.ID, sub)
+	}
+}
+
+// publish delivers a transition to the subscribers of the transaction, closing their channels
+// once the transaction reached a terminal state.
+func (n *statusNotifier) publish(update TxStatusUpdate) {
+	if n == nil {
+		return
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	subs, exists := n.subs[update.ID]
+	if !exists {
+		return
+	}
+	for _, sub := range subs {
+		n.deliver(sub, update)
+	}
+	if update.Terminal() {
+		for _, sub := range subs {
+			close(sub.ch)
+		}
+		delete(n.subs, update.ID)
+	}
+}
+
+// close closes every subscription, no update is delivered afterwards.
+func (n *statusNotifier) close() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for id, subs := range n.subs {
+		for _, sub := range subs {
+			close(sub.ch)
+		}
+		delete(n.subs, id)
+	}
+	n.closed = true
+}
+
+func (n *statusNotifier) deliver(sub *statusSubscription, update TxStatusUpdate) {
+	sub.received = true
+	select {
+	case sub.ch <- update:
+		return
+	default:
+	}
+
+	// drop the oldest update to make room for the latest one
+	select {
+	case <-sub.ch:
+	default:
+	}
+	select {
+	case sub.ch <- update:
+	default:
+	}
+}
+
+func (n *statusNotifier) isSubscribed(id string, sub *statusSubscription) bool {
+	for _, s := range n.subs[id] {
+		if s == sub {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *statusNotifier) remove(id string, sub *statusSubscription) {
+	subs := n.subs[id]
+	for i, s := range subs {
+		if s == sub {
+			close(sub.ch)
+			n.subs[id] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(n.subs[id]) == 0 {
+		delete(n.subs, id)
+	}
+}
@@ -0,0 +1,160 @@
+package txm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// drain returns the updates buffered in a subscription, and whether its channel was closed.
+func drain(sub *statusSubscription) ([]TxState, bool) {
+	var states []TxState
+	for {
+		select {
+		case update, ok := <-sub.ch:
+			if !ok {
+				return states, true
+			}
+			states = append(states, update.State)
+		default:
+			return states, false
+		}
+	}
+}
+
+func TestStatusNotifier(t *testing.T) {
+	update := func(id string, state TxState) TxStatusUpdate { return TxStatusUpdate{ID: id, State: state} }
+
+	testCases := []struct {
+		name   string
+		run    func(n *statusNotifier, unsubscribe func())
+		states []TxState
+		closed bool
+	}{
+		{
+			name: "transitions until a terminal one",
+			run: func(n *statusNotifier, _ func()) {
+				n.publish(update("tx", TxStateBroadcasting))
+				n.publish(update("tx", TxStateUnconfirmed))
+				n.publish(update("tx", TxStateFinalized))
+				n.publish(update("tx", TxStateFinalized))
+			},
+			states: []TxState{TxStateBroadcasting, TxStateUnconfirmed, TxStateFinalized},
+			closed: true,
+		},
+		{
+			name: "transitions of other transactions",
+			run: func(n *statusNotifier, _ func()) {
+				n.publish(update("other", TxStateFinalized))
+			},
+		},
+		{
+			name: "terminal transition only",
+			run: func(n *statusNotifier, _ func()) {
+				n.publish(update("tx", TxStateExpired))
+			},
+			states: []TxState{TxStateExpired},
+			closed: true,
+		},
+		{
+			name: "unsubscribed",
+			run: func(n *statusNotifier, unsubscribe func()) {
+				n.publish(update("tx", TxStateBroadcasting))
+				unsubscribe()
+				n.publish(update("tx", TxStateUnconfirmed))
+				unsubscribe()
+			},
+			states: []TxState{TxStateBroadcasting},
+			closed: true,
+		},
+		{
+			name: "slow subscriber keeps the latest transitions",
+			run: func(n *statusNotifier, _ func()) {
+				for range statusChanSize {
+					n.publish(update("tx", TxStateBroadcasting))
+				}
+				n.publish(update("tx", TxStateUnconfirmed))
+			},
+			states: append(repeatState(TxStateBroadcasting, statusChanSize-1), TxStateUnconfirmed),
+		},
+		{
+			name: "notifier closed",
+			run: func(n *statusNotifier, _ func()) {
+				n.close()
+				n.publish(update("tx", TxStateFinalized))
+			},
+			closed: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newStatusNotifier()
+			sub, unsubscribe := n.subscribe("tx")
+			tc.run(n, unsubscribe)
+
+			states, closed := drain(sub)
+			require.Equal(t, tc.states, states)
+			require.Equal(t, tc.closed, closed)
+			if closed {
+				require.NotContains(t, n.subs, "tx")
+			}
+		})
+	}
+}
+
+func TestStatusNotifier_Snapshot(t *testing.T) {
+	testCases := []struct {
+		name      string
+		published []TxState // transitions published between subscribing and the snapshot
+		snapshot  TxState
+		states    []TxState
+		closed    bool
+	}{
+		{name: "no transition in the meantime", snapshot: TxStateUnconfirmed, states: []TxState{TxStateUnconfirmed}},
+		{name: "terminal state", snapshot: TxStateFinalized, states: []TxState{TxStateFinalized}, closed: true},
+		{
+			name:      "transition in the meantime",
+			published: []TxState{TxStateUnconfirmed},
+			snapshot:  TxStateBroadcasting,
+			states:    []TxState{TxStateUnconfirmed},
+		},
+		{
+			name:      "terminal transition in the meantime",
+			published: []TxState{TxStateErrored},
+			snapshot:  TxStateUnconfirmed,
+			states:    []TxState{TxStateErrored},
+			closed:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newStatusNotifier()
+			sub, _ := n.subscribe("tx")
+			for _, state := range tc.published {
+				n.publish(TxStatusUpdate{ID: "tx", State: state})
+			}
+			n.snapshot(sub, TxStatusUpdate{ID: "tx", State: tc.snapshot})
+
+			states, closed := drain(sub)
+			require.Equal(t, tc.states, states)
+			require.Equal(t, tc.closed, closed)
+		})
+	}
+
+	// subscribing to a closed notifier returns a closed subscription
+	n := newStatusNotifier()
+	n.close()
+	sub, unsubscribe := n.subscribe("tx")
+	unsubscribe()
+	states, closed := drain(sub)
+	require.Empty(t, states)
+	require.True(t, closed)
+}
+
+func repeatState(state TxState, n int) []TxState {
+	states := make([]TxState, n)
+	for i := range states {
+		states[i] = state
+	}
+	return states
+}
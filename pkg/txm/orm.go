@@ -0,0 +1,566 @@
+package txm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/sqlutil"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+var ErrTxNotFound = errors.New("transaction not found")
+
+// ORM persists the lifecycle of transactions managed by the Txm, so that
+// in-flight transactions survive a node restart.
+type ORM interface {
+	// InsertTx records a transaction that was accepted by Enqueue.
+	// Returns ErrTxAlreadyExists if a transaction with the same ID was already recorded.
+	InsertTx(ctx context.Context, tx *Tx) error
+	// MarkBroadcasting records that the external message for a transaction is being sent,
+	// along with the hash of the external message and the time after which the wallet rejects it.
+	MarkBroadcasting(ctx context.Context, id string, inMsgHash []byte, msgExpiresAt time.Time) error
+	// MarkUnconfirmed records the on-chain inclusion of a transaction, identified by the LT of the outgoing
+	// message the wallet emitted for it, along with the LT and hash of the wallet transaction.
+	MarkUnconfirmed(ctx context.Context, id string, msgLT uint64, lt uint64, txHash []byte) error
+	// MarkFinalized records the outcome of a finalized trace, along with the fees paid out of the attached value
+	// and the failure of the trace, nil when it succeeded.
+	MarkFinalized(ctx context.Context, id string, succeeded bool, exitCode tvm.ExitCode, totalActionFees tlb.Coins, traceFees tlb.Coins, failure *Failure) error
+	// MarkErrored records that a transaction could not be broadcast.
+	MarkErrored(ctx context.Context, id string, reason string) error
+	// MarkExpired records that a transaction did not land or finalize before its expiration.
+	MarkExpired(ctx context.Context, id string, reason string) error
+	// MarkRebroadcast records the times the external message of a broadcasting transaction was sent again.
+	MarkRebroadcast(ctx context.Context, id string, rebroadcasts uint) error
+	// MarkResubmitted moves an expired broadcast back to the queue, to be rebuilt with a fresh query ID.
+	MarkResubmitted(ctx context.Context, id string, attempts uint) error
+	// RetryTx replaces a transaction that errored, expired or whose trace failed with a new one under the
+	// same ID, recorded as accepted by Enqueue. Returns ErrTxNotFound if no such transaction was recorded.
+	RetryTx(ctx context.Context, tx *Tx) error
+	// GetPendingTxs returns every transaction that has not reached a terminal state.
+	GetPendingTxs(ctx context.Context) ([]*PersistedTx, error)
+	// GetTxByID returns a transaction by ID.
+	GetTxByID(ctx context.Context, id string) (*PersistedTx, error)
+	// GetTxsByLT returns the transactions sent by the given account in the wallet transaction with the given LT,
+	// several for a batch, in the order of their outgoing messages.
+	GetTxsByLT(ctx context.Context, fromAddress string, lt uint64) ([]*PersistedTx, error)
+	// GetQueryIDCursor returns the end of the query ID range reserved by a wallet, 0 if none was reserved.
+	GetQueryIDCursor(ctx context.Context, walletAddress string) (uint64, error)
+	// SetQueryIDCursor records the end of the query ID range reserved by a wallet.
+	SetQueryIDCursor(ctx context.Context, walletAddress string, cursor uint64) error
+}
+
+// PersistedTx is a transaction loaded back from the ORM together with its lifecycle state.
+type PersistedTx struct {
+	Tx              *Tx
+	State           TxState
+	LT              uint64       // LT of the wallet transaction, set once the transaction is unconfirmed
+	TxHash          []byte       // hash of the wallet transaction, set once the transaction is unconfirmed
+	MsgLT           uint64       // LT of the outgoing message of the transaction, set once the transaction is unconfirmed
+	ExitCode        tvm.ExitCode // set once the transaction is finalized
+	TraceSucceeded  bool         // set once the transaction is finalized
+	TotalActionFees tlb.Coins    // set once the transaction is finalized
+	TraceFees       tlb.Coins    // set once the transaction is finalized
+	Failure         *Failure     // set once the transaction is finalized, when its trace failed
+	Error           string       // set once the transaction is errored
+}
+
+var _ ORM = (*DSORM)(nil)
+
+// DSORM is the SQL implementation of ORM, backed by the ton.txm_transactions table.
+type DSORM struct {
+	chainID string
+	ds      sqlutil.DataSource
+	lggr    logger.Logger
+}
+
+// NewORM creates a DSORM scoped to a single chain.
+func NewORM(chainID string, ds sqlutil.DataSource, lggr logger.Logger) *DSORM {
+	return &DSORM{
+		chainID: chainID,
+		ds:      ds,
+		lggr:    logger.Named(lggr, "TxmORM"),
+	}
+}
+
+// dbTx is the row representation of a transaction in ton.txm_transactions.
+type dbTx struct {
+	ID              string         `db:"id"`
+	ChainID         string         `db:"chain_id"`
+	State           string         `db:"state"`
+	FromAddress     string         `db:"from_address"`
+	ToAddress       string         `db:"to_address"`
+	Amount          string         `db:"amount"`
+	Mode            int16          `db:"mode"`
+	Priority        int16          `db:"priority"`
+	Bounce          bool           `db:"bounce"`
+	Body            []byte         `db:"body"`
+	StateInit       []byte         `db:"state_init"`
+	LT              sql.NullInt64  `db:"lt"`
+	TxHash          []byte         `db:"tx_hash"`
+	MsgLT           sql.NullInt64  `db:"msg_lt"`
+	ExitCode        sql.NullInt32  `db:"exit_code"`
+	TraceSucceeded  sql.NullBool   `db:"trace_succeeded"`
+	TotalActionFees sql.NullString `db:"total_action_fees"`
+	Error           sql.NullString `db:"error"`
+	InMsgHash       []byte         `db:"in_msg_hash"`
+	MsgExpiresAt    sql.NullTime   `db:"msg_expires_at"`
+	Attempts        int32          `db:"attempts"`
+	Rebroadcasts    int32          `db:"rebroadcasts"`
+	EstimatedFee    sql.NullString `db:"estimated_fee"`
+	TraceFees       sql.NullString `db:"trace_fees"`
+	Failure         []byte         `db:"failure"`
+	ExpiresAt       time.Time      `db:"expires_at"`
+	CreatedAt       time.Time      `db:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at"`
+}
+
+func (o *DSORM) InsertTx(ctx context.Context, tx *Tx) error {
+	var body, stateInit []byte
+	if tx.Body != nil {
+		body = tx.Body.ToBOC()
+	}
+	if tx.StateInit != nil {
+		stateInit = tx.StateInit.ToBOC()
+	}
+	var estimatedFee sql.NullString
+	if tx.EstimatedFee.Nano().Sign() > 0 {
+		estimatedFee = sql.NullString{String: tx.EstimatedFee.Nano().String(), Valid: true}
+	}
+
+	query := `INSERT INTO ton.txm_transactions
+		(id, chain_id, state, from_address, to_address, amount, mode, priority, bounce, body, state_init, estimated_fee, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW())
+		ON CONFLICT (chain_id, id) DO NOTHING`
+	res, err := o.ds.ExecContext(ctx, query,
+		tx.ID, o.chainID, TxStateEnqueued, tx.From.String(), tx.To.String(), tx.Amount.Nano().String(),
+		int16(tx.Mode), int16(tx.Priority), tx.Bounceable, body, stateInit, estimatedFee, tx.Expiration, tx.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert tx %s: %w", tx.ID, err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for tx %s: %w", tx.ID, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrTxAlreadyExists, tx.ID)
+	}
+	return nil
+}
+
+func (o *DSORM) MarkBroadcasting(ctx context.Context, id string, inMsgHash []byte, msgExpiresAt time.Time) error {
+	query := `UPDATE ton.txm_transactions
+		SET state = $3, in_msg_hash = $4, msg_expires_at = $5, rebroadcasts = 0, updated_at = NOW()
+		WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, TxStateBroadcasting, inMsgHash, msgExpiresAt)
+}
+
+func (o *DSORM) MarkRebroadcast(ctx context.Context, id string, rebroadcasts uint) error {
+	query := `UPDATE ton.txm_transactions SET rebroadcasts = $3, updated_at = NOW() WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, int32(rebroadcasts)) //nolint:gosec // bounded by the message TTL
+}
+
+func (o *DSORM) MarkUnconfirmed(ctx context.Context, id string, msgLT uint64, lt uint64, txHash []byte) error {
+	query := `UPDATE ton.txm_transactions SET state = $3, msg_lt = $4, lt = $5, tx_hash = $6, updated_at = NOW() WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, TxStateUnconfirmed, msgLT, lt, txHash)
+}
+
+func (o *DSORM) MarkFinalized(ctx context.Context, id string, succeeded bool, exitCode tvm.ExitCode, totalActionFees tlb.Coins, traceFees tlb.Coins, failure *Failure) error {
+	var failureJSON []byte
+	if failure != nil {
+		var err error
+		if failureJSON, err = json.Marshal(failure); err != nil {
+			return fmt.Errorf("failed to encode failure of tx %s: %w", id, err)
+		}
+	}
+
+	query := `UPDATE ton.txm_transactions
+		SET state = $3, trace_succeeded = $4, exit_code = $5, total_action_fees = $6, trace_fees = $7, failure = $8, updated_at = NOW()
+		WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, TxStateFinalized, succeeded, int32(exitCode), totalActionFees.Nano().String(), traceFees.Nano().String(), failureJSON)
+}
+
+func (o *DSORM) MarkErrored(ctx context.Context, id string, reason string) error {
+	query := `UPDATE ton.txm_transactions SET state = $3, error = $4, updated_at = NOW() WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, TxStateErrored, reason)
+}
+
+func (o *DSORM) MarkExpired(ctx context.Context, id string, reason string) error {
+	query := `UPDATE ton.txm_transactions SET state = $3, error = $4, updated_at = NOW() WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, TxStateExpired, reason)
+}
+
+func (o *DSORM) MarkResubmitted(ctx context.Context, id string, attempts uint) error {
+	query := `UPDATE ton.txm_transactions
+		SET state = $3, in_msg_hash = NULL, msg_expires_at = NULL, attempts = $4, updated_at = NOW()
+		WHERE chain_id = $1 AND id = $2`
+	return o.update(ctx, id, query, id, TxStateEnqueued, int32(attempts)) //nolint:gosec // bounded by MaxResubmitAttempts
+}
+
+func (o *DSORM) RetryTx(ctx context.Context, tx *Tx) error {
+	var body, stateInit []byte
+	if tx.Body != nil {
+		body = tx.Body.ToBOC()
+	}
+	if tx.StateInit != nil {
+		stateInit = tx.StateInit.ToBOC()
+	}
+	var estimatedFee sql.NullString
+	if tx.EstimatedFee.Nano().Sign() > 0 {
+		estimatedFee = sql.NullString{String: tx.EstimatedFee.Nano().String(), Valid: true}
+	}
+
+	query := `UPDATE ton.txm_transactions
+		SET state = $3, from_address = $4, to_address = $5, amount = $6, mode = $7, priority = $8, bounce = $9, body = $10,
+			state_init = $11, estimated_fee = $12, expires_at = $13, created_at = $14,
+			lt = NULL, tx_hash = NULL, msg_lt = NULL, exit_code = NULL, trace_succeeded = NULL, total_action_fees = NULL,
+			trace_fees = NULL, failure = NULL, error = NULL, in_msg_hash = NULL, msg_expires_at = NULL, attempts = 0,
+			rebroadcasts = 0, updated_at = NOW()
+		WHERE chain_id = $1 AND id = $2 AND (state IN ($15, $16) OR (state = $17 AND trace_succeeded = FALSE))`
+	return o.update(ctx, tx.ID, query, tx.ID, TxStateEnqueued, tx.From.String(), tx.To.String(), tx.Amount.Nano().String(),
+		int16(tx.Mode), int16(tx.Priority), tx.Bounceable, body, stateInit, estimatedFee, tx.Expiration, tx.CreatedAt,
+		TxStateErrored, TxStateExpired, TxStateFinalized)
+}
+
+func (o *DSORM) update(ctx context.Context, id string, query string, args ...any) error {
+	res, err := o.ds.ExecContext(ctx, query, append([]any{o.chainID}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update tx %s: %w", id, err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for tx %s: %w", id, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrTxNotFound, id)
+	}
+	return nil
+}
+
+func (o *DSORM) GetPendingTxs(ctx context.Context) ([]*PersistedTx, error) {
+	var rows []dbTx
+	query := `SELECT * FROM ton.txm_transactions WHERE chain_id = $1 AND state IN ($2, $3, $4) ORDER BY created_at ASC`
+	err := o.ds.SelectContext(ctx, &rows, query, o.chainID, TxStateEnqueued, TxStateBroadcasting, TxStateUnconfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending txs: %w", err)
+	}
+
+	pending := make([]*PersistedTx, 0, len(rows))
+	for _, row := range rows {
+		persisted, err := row.toPersistedTx()
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, persisted)
+	}
+	return pending, nil
+}
+
+func (o *DSORM) GetTxByID(ctx context.Context, id string) (*PersistedTx, error) {
+	var row dbTx
+	query := `SELECT * FROM ton.txm_transactions WHERE chain_id = $1 AND id = $2`
+	err := o.ds.GetContext(ctx, &row, query, o.chainID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrTxNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx %s: %w", id, err)
+	}
+	return row.toPersistedTx()
+}
+
+func (o *DSORM) GetTxsByLT(ctx context.Context, fromAddress string, lt uint64) ([]*PersistedTx, error) {
+	var rows []dbTx
+	query := `SELECT * FROM ton.txm_transactions WHERE chain_id = $1 AND from_address = $2 AND lt = $3 ORDER BY msg_lt`
+	if err := o.ds.SelectContext(ctx, &rows, query, o.chainID, fromAddress, lt); err != nil {
+		return nil, fmt.Errorf("failed to get txs with lt %d: %w", lt, err)
+	}
+	txs := make([]*PersistedTx, 0, len(rows))
+	for _, row := range rows {
+		tx, err := row.toPersistedTx()
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func (o *DSORM) GetQueryIDCursor(ctx context.Context, walletAddress string) (uint64, error) {
+	var cursor int64
+	query := `SELECT cursor FROM ton.txm_query_ids WHERE chain_id = $1 AND wallet_address = $2`
+	err := o.ds.GetContext(ctx, &cursor, query, o.chainID, walletAddress)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get query ID cursor of %s: %w", walletAddress, err)
+	}
+	return uint64(cursor), nil //nolint:gosec // cursor is stored from a uint64
+}
+
+func (o *DSORM) SetQueryIDCursor(ctx context.Context, walletAddress string, cursor uint64) error {
+	query := `INSERT INTO ton.txm_query_ids (chain_id, wallet_address, cursor, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (chain_id, wallet_address) DO UPDATE SET cursor = EXCLUDED.cursor, updated_at = NOW()`
+	if _, err := o.ds.ExecContext(ctx, query, o.chainID, walletAddress, int64(cursor)); err != nil { //nolint:gosec // cursors stay far below 2^63
+		return fmt.Errorf("failed to set query ID cursor of %s: %w", walletAddress, err)
+	}
+	return nil
+}
+
+func (r dbTx) toPersistedTx() (*PersistedTx, error) {
+	from, err := address.ParseAddr(r.FromAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address for tx %s: %w", r.ID, err)
+	}
+	to, err := address.ParseAddr(r.ToAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to address for tx %s: %w", r.ID, err)
+	}
+	amount, ok := new(big.Int).SetString(r.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount for tx %s: %s", r.ID, r.Amount)
+	}
+
+	var body, stateInit *cell.Cell
+	if len(r.Body) > 0 {
+		if body, err = cell.FromBOC(r.Body); err != nil {
+			return nil, fmt.Errorf("invalid body for tx %s: %w", r.ID, err)
+		}
+	}
+	if len(r.StateInit) > 0 {
+		if stateInit, err = cell.FromBOC(r.StateInit); err != nil {
+			return nil, fmt.Errorf("invalid state init for tx %s: %w", r.ID, err)
+		}
+	}
+
+	fees, err := parseCoins(r.TotalActionFees)
+	if err != nil {
+		return nil, fmt.Errorf("invalid total action fees for tx %s: %w", r.ID, err)
+	}
+	estimatedFee, err := parseCoins(r.EstimatedFee)
+	if err != nil {
+		return nil, fmt.Errorf("invalid estimated fee for tx %s: %w", r.ID, err)
+	}
+	traceFees, err := parseCoins(r.TraceFees)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace fees for tx %s: %w", r.ID, err)
+	}
+	var failure *Failure
+	if r.Failure != nil {
+		failure = &Failure{}
+		if err := json.Unmarshal(r.Failure, failure); err != nil {
+			return nil, fmt.Errorf("invalid failure for tx %s: %w", r.ID, err)
+		}
+	}
+
+	return &PersistedTx{
+		Tx: &Tx{
+			ID:            r.ID,
+			Mode:          uint8(r.Mode),        //nolint:gosec // mode is stored from a uint8
+			Priority:      Priority(r.Priority), //nolint:gosec // priority is stored from a uint8
+			From:          *from,
+			To:            *to,
+			Amount:        tlb.FromNanoTON(amount),
+			Body:          body,
+			StateInit:     stateInit,
+			Bounceable:    r.Bounce,
+			CreatedAt:     r.CreatedAt,
+			Expiration:    r.ExpiresAt,
+			InMsgHash:     r.InMsgHash,
+			MsgExpiration: r.MsgExpiresAt.Time,
+			Attempts:      uint(r.Attempts),     //nolint:gosec // attempts is stored from a uint
+			Rebroadcasts:  uint(r.Rebroadcasts), //nolint:gosec // rebroadcasts is stored from a uint
+			EstimatedFee:  estimatedFee,
+		},
+		State:           TxState(r.State),
+		LT:              uint64(r.LT.Int64), //nolint:gosec // LT is stored from a uint64
+		TxHash:          r.TxHash,
+		MsgLT:           uint64(r.MsgLT.Int64), //nolint:gosec // LT is stored from a uint64
+		ExitCode:        tvm.ExitCode(r.ExitCode.Int32),
+		TraceSucceeded:  r.TraceSucceeded.Bool,
+		TotalActionFees: fees,
+		TraceFees:       traceFees,
+		Failure:         failure,
+		Error:           r.Error.String,
+	}, nil
+}
+
+// parseCoins parses a nanoton amount stored as NUMERIC, NULL is zero.
+func parseCoins(s sql.NullString) (tlb.Coins, error) {
+	if !s.Valid {
+		return tlb.ZeroCoins, nil
+	}
+	nano, ok := new(big.Int).SetString(s.String, 10)
+	if !ok {
+		return tlb.ZeroCoins, fmt.Errorf("not a number: %s", s.String)
+	}
+	return tlb.FromNanoTON(nano), nil
+}
+
+var _ ORM = nopORM{}
+
+// nopORM is used when no datasource is configured, transactions are then only tracked in memory.
+type nopORM struct{}
+
+// persistent returns whether an ORM keeps transactions across restarts.
+func persistent(orm ORM) bool {
+	switch orm.(type) {
+	case nopORM, *memoryORM:
+		return false
+	default:
+		return true
+	}
+}
+
+func (nopORM) InsertTx(context.Context, *Tx) error { return nil }
+func (nopORM) MarkBroadcasting(context.Context, string, []byte, time.Time) error {
+	return nil
+}
+func (nopORM) MarkUnconfirmed(context.Context, string, uint64, uint64, []byte) error {
+	return nil
+}
+func (nopORM) MarkFinalized(context.Context, string, bool, tvm.ExitCode, tlb.Coins, tlb.Coins, *Failure) error {
+	return nil
+}
+func (nopORM) MarkErrored(context.Context, string, string) error   { return nil }
+func (nopORM) MarkExpired(context.Context, string, string) error   { return nil }
+func (nopORM) MarkRebroadcast(context.Context, string, uint) error { return nil }
+func (nopORM) MarkResubmitted(context.Context, string, uint) error { return nil }
+func (nopORM) RetryTx(context.Context, *Tx) error                  { return nil }
+func (nopORM) GetPendingTxs(context.Context) ([]*PersistedTx, error) {
+	return nil, nil
+}
+func (nopORM) GetTxByID(_ context.Context, id string) (*PersistedTx, error) {
+	return nil, fmt.Errorf("%w: %s", ErrTxNotFound, id)
+}
+func (nopORM) GetTxsByLT(context.Context, string, uint64) ([]*PersistedTx, error) {
+	return nil, nil
+}
+func (nopORM) GetQueryIDCursor(context.Context, string) (uint64, error) { return 0, nil }
+func (nopORM) SetQueryIDCursor(context.Context, string, uint64) error   { return nil }
+
+// prunedTxKeeper is implemented by the ORMs that do not persist transactions, the transactions pruned
+// from memory are handed to them instead, oldest first, with the limits they were pruned with.
+type prunedTxKeeper interface {
+	keepPruned(fromAddress string, txs []*PersistedTx, now time.Time, retention time.Duration, maxKept uint)
+}
+
+var (
+	_ ORM            = (*memoryORM)(nil)
+	_ prunedTxKeeper = (*memoryORM)(nil)
+)
+
+// memoryORM is used when no datasource is configured, transactions are then only tracked in memory.
+// The outcome of the transactions pruned from memory is kept, without their message or trace, so their
+// status can still be looked up and their ID, an idempotency key, is not enqueued again. Outcomes are
+// bounded like the transactions they come from: they are dropped once kept for another retention
+// period, and beyond the max retained per sender.
+type memoryORM struct {
+	nopORM
+	lock sync.RWMutex
+	byID map[string]*PersistedTx
+	byLT map[prunedWalletTx][]*PersistedTx
+	kept map[string][]keptTx // by sender, oldest first
+}
+
+type keptTx struct {
+	tx     *PersistedTx
+	keptAt time.Time
+}
+
+// prunedWalletTx identifies the wallet transaction that sent pruned transactions.
+type prunedWalletTx struct {
+	fromAddress string
+	lt          uint64
+}
+
+func newMemoryORM() *memoryORM {
+	return &memoryORM{
+		byID: map[string]*PersistedTx{},
+		byLT: map[prunedWalletTx][]*PersistedTx{},
+		kept: map[string][]keptTx{},
+	}
+}
+
+func (o *memoryORM) GetTxByID(ctx context.Context, id string) (*PersistedTx, error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	if tx, exists := o.byID[id]; exists {
+		return tx, nil
+	}
+	return o.nopORM.GetTxByID(ctx, id)
+}
+
+func (o *memoryORM) GetTxsByLT(_ context.Context, fromAddress string, lt uint64) ([]*PersistedTx, error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	return slices.Clone(o.byLT[prunedWalletTx{fromAddress, lt}]), nil
+}
+
+// RetryTx forgets the outcome kept for the transaction replaced by the retry.
+func (o *memoryORM) RetryTx(_ context.Context, tx *Tx) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	delete(o.byID, tx.ID)
+	return nil
+}
+
+func (o *memoryORM) keepPruned(fromAddress string, txs []*PersistedTx, now time.Time, retention time.Duration, maxKept uint) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	kept := o.kept[fromAddress]
+	for _, tx := range txs {
+		o.byID[tx.Tx.ID] = tx
+		if tx.LT != 0 {
+			key := prunedWalletTx{fromAddress, tx.LT}
+			o.byLT[key] = append(o.byLT[key], tx)
+		}
+		kept = append(kept, keptTx{tx, now})
+	}
+
+	dropCount := 0
+	if retention > 0 {
+		cutoff := now.Add(-retention)
+		for dropCount < len(kept) && kept[dropCount].keptAt.Before(cutoff) {
+			dropCount++
+		}
+	}
+	if maxKept > 0 && uint(len(kept)-dropCount) > maxKept {
+		dropCount = len(kept) - int(maxKept) //nolint:gosec // bounded by len(kept)
+	}
+	for _, k := range kept[:dropCount] {
+		// a retry under the same ID may have been pruned since
+		if o.byID[k.tx.Tx.ID] == k.tx {
+			delete(o.byID, k.tx.Tx.ID)
+		}
+		key := prunedWalletTx{fromAddress, k.tx.LT}
+		if txs := slices.DeleteFunc(o.byLT[key], func(tx *PersistedTx) bool { return tx == k.tx }); len(txs) > 0 {
+			o.byLT[key] = txs
+		} else {
+			delete(o.byLT, key)
+		}
+	}
+	if kept = kept[dropCount:]; len(kept) > 0 {
+		o.kept[fromAddress] = slices.Clone(kept)
+	} else {
+		delete(o.kept, fromAddress)
+	}
+}
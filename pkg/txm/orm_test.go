@@ -0,0 +1,329 @@
+package txm
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-common/pkg/sqlutil/sqltest"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/migrate"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+// newTestORM migrates a test database and returns an ORM scoped to chainID, the schema needs Postgres.
+func newTestORM(t *testing.T, chainIDs ...string) []*DSORM {
+	sqltest.SkipInMemory(t)
+	db := sqltest.NewDB(t, sqltest.TestURL(t))
+	lggr := logger.Test(t)
+	require.NoError(t, Migrate(t.Context(), lggr, db))
+	// migrations that were applied are skipped
+	require.NoError(t, Migrate(t.Context(), lggr, db))
+
+	orms := make([]*DSORM, 0, len(chainIDs))
+	for _, chainID := range chainIDs {
+		orms = append(orms, NewORM(chainID, db, lggr))
+	}
+	return orms
+}
+
+func testAddress(b byte) *address.Address {
+	data := make([]byte, 32)
+	data[31] = b
+	return address.NewAddress(0, 0, data)
+}
+
+func testTx(id string) *Tx {
+	now := time.Now().Truncate(time.Microsecond)
+	return &Tx{
+		ID:           id,
+		Mode:         3,
+		Priority:     PriorityHigh,
+		From:         *testAddress(1),
+		To:           *testAddress(2),
+		Amount:       tlb.MustFromTON("1.5"),
+		Body:         cell.BeginCell().MustStoreUInt(0xdeadbeef, 32).EndCell(),
+		StateInit:    cell.BeginCell().MustStoreUInt(1, 8).EndCell(),
+		Bounceable:   true,
+		CreatedAt:    now,
+		Expiration:   now.Add(time.Hour),
+		EstimatedFee: tlb.MustFromTON("0.01"),
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := migrate.Load(Migrations())
+	require.NoError(t, err)
+	require.Len(t, migrations, 9)
+	for i, m := range migrations {
+		require.Equal(t, int64(i+1), m.Version, m.Name)
+		require.NotEmpty(t, m.Up, m.Name)
+	}
+}
+
+func TestDSORM_Lifecycle(t *testing.T) {
+	orms := newTestORM(t, "-239", "-3")
+	orm, other := orms[0], orms[1]
+	ctx := t.Context()
+
+	tx := testTx("tx-1")
+	require.NoError(t, orm.InsertTx(ctx, tx))
+	require.ErrorIs(t, orm.InsertTx(ctx, tx), ErrTxAlreadyExists)
+	// transactions are scoped to their chain
+	require.NoError(t, other.InsertTx(ctx, tx))
+	_, err := other.GetTxByID(ctx, "tx-2")
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	persisted, err := orm.GetTxByID(ctx, tx.ID)
+	require.NoError(t, err)
+	require.Equal(t, TxStateEnqueued, persisted.State)
+	require.Equal(t, tx.ID, persisted.Tx.ID)
+	require.Equal(t, tx.Mode, persisted.Tx.Mode)
+	require.Equal(t, tx.Priority, persisted.Tx.Priority)
+	require.True(t, tx.From.Equals(&persisted.Tx.From))
+	require.True(t, tx.To.Equals(&persisted.Tx.To))
+	require.Equal(t, tx.Amount.Nano(), persisted.Tx.Amount.Nano())
+	require.Equal(t, tx.Body.Hash(), persisted.Tx.Body.Hash())
+	require.Equal(t, tx.StateInit.Hash(), persisted.Tx.StateInit.Hash())
+	require.Equal(t, tx.Bounceable, persisted.Tx.Bounceable)
+	require.True(t, tx.CreatedAt.Equal(persisted.Tx.CreatedAt))
+	require.True(t, tx.Expiration.Equal(persisted.Tx.Expiration))
+	require.Equal(t, tx.EstimatedFee.Nano(), persisted.Tx.EstimatedFee.Nano())
+
+	msgExpiration := time.Now().Add(2 * time.Minute).Truncate(time.Microsecond)
+	require.NoError(t, orm.MarkBroadcasting(ctx, tx.ID, []byte{1, 2, 3}, msgExpiration))
+	require.NoError(t, orm.MarkRebroadcast(ctx, tx.ID, 2))
+	require.ErrorIs(t, orm.MarkRebroadcast(ctx, "unknown", 1), ErrTxNotFound)
+	require.NoError(t, orm.MarkUnconfirmed(ctx, tx.ID, 101, 100, []byte{4, 5, 6}))
+
+	pending, err := orm.GetPendingTxs(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, TxStateUnconfirmed, pending[0].State)
+	require.Equal(t, []byte{1, 2, 3}, pending[0].Tx.InMsgHash)
+	require.True(t, msgExpiration.Equal(pending[0].Tx.MsgExpiration))
+	require.Equal(t, uint(2), pending[0].Tx.Rebroadcasts)
+	require.Equal(t, uint64(100), pending[0].LT)
+	require.Equal(t, uint64(101), pending[0].MsgLT)
+	require.Equal(t, []byte{4, 5, 6}, pending[0].TxHash)
+
+	// transactions are looked up by the LT of the wallet transaction that sent them
+	byLT, err := orm.GetTxsByLT(ctx, tx.From.String(), 100)
+	require.NoError(t, err)
+	require.Len(t, byLT, 1)
+	require.Equal(t, tx.ID, byLT[0].Tx.ID)
+	byLT, err = orm.GetTxsByLT(ctx, tx.From.String(), 101)
+	require.NoError(t, err)
+	require.Empty(t, byLT)
+
+	failure := &Failure{Hop: 1, Account: tx.To.String(), Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeOutOfGasError, RetryWithMoreValue: true}
+	require.NoError(t, orm.MarkFinalized(ctx, tx.ID, false, tvm.ExitCodeOutOfGasError, tlb.MustFromTON("0.002"), tlb.MustFromTON("0.003"), failure))
+
+	finalized, err := orm.GetTxByID(ctx, tx.ID)
+	require.NoError(t, err)
+	require.Equal(t, TxStateFinalized, finalized.State)
+	require.False(t, finalized.TraceSucceeded)
+	require.Equal(t, tvm.ExitCodeOutOfGasError, finalized.ExitCode)
+	require.Equal(t, tlb.MustFromTON("0.002").Nano(), finalized.TotalActionFees.Nano())
+	require.Equal(t, tlb.MustFromTON("0.003").Nano(), finalized.TraceFees.Nano())
+	require.Equal(t, failure, finalized.Failure)
+
+	pending, err = orm.GetPendingTxs(ctx)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestDSORM_TerminalStates(t *testing.T) {
+	orm := newTestORM(t, "-239")[0]
+	ctx := t.Context()
+
+	testCases := []struct {
+		name     string
+		mark     func(id string) error
+		state    TxState
+		errorMsg string
+		attempts uint
+	}{
+		{
+			name:     "errored",
+			mark:     func(id string) error { return orm.MarkErrored(ctx, id, "failed to build message") },
+			state:    TxStateErrored,
+			errorMsg: "failed to build message",
+		},
+		{
+			name:     "expired",
+			mark:     func(id string) error { return orm.MarkExpired(ctx, id, "external message expired without landing") },
+			state:    TxStateExpired,
+			errorMsg: "external message expired without landing",
+		},
+		{
+			name:     "resubmitted",
+			mark:     func(id string) error { return orm.MarkResubmitted(ctx, id, 2) },
+			state:    TxStateEnqueued,
+			attempts: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := testTx(tc.name)
+			require.NoError(t, orm.InsertTx(ctx, tx))
+			require.NoError(t, orm.MarkBroadcasting(ctx, tx.ID, []byte{1}, time.Now()))
+			require.NoError(t, tc.mark(tx.ID))
+
+			persisted, err := orm.GetTxByID(ctx, tx.ID)
+			require.NoError(t, err)
+			require.Equal(t, tc.state, persisted.State)
+			require.Equal(t, tc.errorMsg, persisted.Error)
+			require.Equal(t, tc.attempts, persisted.Tx.Attempts)
+			if tc.state == TxStateEnqueued {
+				// a resubmitted transaction is rebuilt with a new external message
+				require.Nil(t, persisted.Tx.InMsgHash)
+				require.True(t, persisted.Tx.MsgExpiration.IsZero())
+			}
+
+			require.ErrorIs(t, tc.mark("unknown"), ErrTxNotFound)
+		})
+	}
+}
+
+func TestDSORM_RetryTx(t *testing.T) {
+	orm := newTestORM(t, "-239")[0]
+	ctx := t.Context()
+
+	testCases := []struct {
+		name  string
+		mark  func(id string) error
+		retry bool
+	}{
+		{name: "enqueued", mark: func(string) error { return nil }},
+		{name: "errored", mark: func(id string) error { return orm.MarkErrored(ctx, id, "failed to build message") }, retry: true},
+		{name: "expired", mark: func(id string) error { return orm.MarkExpired(ctx, id, "expired") }, retry: true},
+		{
+			name: "trace failed",
+			mark: func(id string) error {
+				return orm.MarkFinalized(ctx, id, false, tvm.ExitCodeOutOfGasError, tlb.ZeroCoins, tlb.ZeroCoins, &Failure{Hop: 1})
+			},
+			retry: true,
+		},
+		{
+			name: "trace succeeded",
+			mark: func(id string) error {
+				return orm.MarkFinalized(ctx, id, true, tvm.ExitCodeSuccess, tlb.ZeroCoins, tlb.ZeroCoins, nil)
+			},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := testTx(tc.name)
+			lt := uint64(10 * (i + 1))
+			require.NoError(t, orm.InsertTx(ctx, tx))
+			require.NoError(t, orm.MarkBroadcasting(ctx, tx.ID, []byte{1}, time.Now()))
+			require.NoError(t, orm.MarkUnconfirmed(ctx, tx.ID, lt+1, lt, []byte{2}))
+			require.NoError(t, tc.mark(tx.ID))
+			before, err := orm.GetTxByID(ctx, tx.ID)
+			require.NoError(t, err)
+
+			retry := testTx(tc.name)
+			retry.From, retry.Amount = *testAddress(3), tlb.MustFromTON("2")
+			err = orm.RetryTx(ctx, retry)
+			persisted, getErr := orm.GetTxByID(ctx, tx.ID)
+			require.NoError(t, getErr)
+			if !tc.retry {
+				require.ErrorIs(t, err, ErrTxNotFound)
+				require.Equal(t, before.State, persisted.State)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, TxStateEnqueued, persisted.State)
+			require.True(t, retry.From.Equals(&persisted.Tx.From))
+			require.Equal(t, retry.Amount.Nano(), persisted.Tx.Amount.Nano())
+			require.Zero(t, persisted.LT)
+			require.Nil(t, persisted.Tx.InMsgHash)
+			require.Nil(t, persisted.Failure)
+			require.Empty(t, persisted.Error)
+
+			// the wallet transaction of the failed attempt no longer leads to the transaction
+			byLT, err := orm.GetTxsByLT(ctx, tx.From.String(), lt)
+			require.NoError(t, err)
+			require.Empty(t, byLT)
+		})
+	}
+}
+
+func TestDSORM_QueryIDCursor(t *testing.T) {
+	orms := newTestORM(t, "-239", "-3")
+	orm, other := orms[0], orms[1]
+	ctx := t.Context()
+	wallet := testAddress(1).String()
+
+	cursor, err := orm.GetQueryIDCursor(ctx, wallet)
+	require.NoError(t, err)
+	require.Zero(t, cursor)
+
+	require.NoError(t, orm.SetQueryIDCursor(ctx, wallet, 1024))
+	require.NoError(t, orm.SetQueryIDCursor(ctx, wallet, 2048))
+	cursor, err = orm.GetQueryIDCursor(ctx, wallet)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2048), cursor)
+
+	cursor, err = other.GetQueryIDCursor(ctx, wallet)
+	require.NoError(t, err)
+	require.Zero(t, cursor)
+}
+
+func TestMemoryORM_KeepPruned(t *testing.T) {
+	sender, other := testAddress(1).String(), testAddress(2).String()
+	pruned := func(id string, lt uint64) *PersistedTx {
+		return &PersistedTx{Tx: &Tx{ID: id}, State: TxStateFinalized, LT: lt}
+	}
+
+	testCases := []struct {
+		name      string
+		retention time.Duration
+		maxKept   uint
+		kept      []string
+	}{
+		{name: "no limits", kept: []string{"first", "second", "third", "latest", "other"}},
+		{name: "kept past retention", retention: time.Hour, kept: []string{"latest", "other"}},
+		{name: "beyond max kept", maxKept: 2, kept: []string{"third", "latest", "other"}},
+		{name: "beyond max kept within retention", retention: 3 * time.Hour, maxKept: 1, kept: []string{"latest", "other"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			now := time.Now()
+			o := newMemoryORM()
+			// first and second were sent by the same wallet transaction
+			o.keepPruned(sender, []*PersistedTx{pruned("first", 10), pruned("second", 10), pruned("third", 0)}, now.Add(-2*time.Hour), tc.retention, tc.maxKept)
+			o.keepPruned(other, []*PersistedTx{pruned("other", 10)}, now.Add(-2*time.Hour), tc.retention, tc.maxKept)
+			o.keepPruned(sender, []*PersistedTx{pruned("latest", 20)}, now, tc.retention, tc.maxKept)
+
+			for _, id := range []string{"first", "second", "third", "latest", "other"} {
+				tx, err := o.GetTxByID(ctx, id)
+				if !slices.Contains(tc.kept, id) {
+					require.ErrorIs(t, err, ErrTxNotFound, id)
+					continue
+				}
+				require.NoError(t, err, id)
+				require.Equal(t, id, tx.Tx.ID)
+			}
+
+			var keptByLT []string
+			for _, lt := range []uint64{10, 20} {
+				txs, err := o.GetTxsByLT(ctx, sender, lt)
+				require.NoError(t, err)
+				for _, tx := range txs {
+					keptByLT = append(keptByLT, tx.Tx.ID)
+				}
+			}
+			require.Equal(t, slices.DeleteFunc(slices.Clone(tc.kept), func(id string) bool { return id == "third" || id == "other" }), keptByLT)
+			require.Len(t, o.byID, len(tc.kept))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package txm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrQueueFull = errors.New("broadcast queue full")
+
+// Priority selects the broadcast lane of a request. Lanes are served with weighted fairness, so that
+// bursts of low priority sends do not hold back time-critical ones, nor starve them completely.
+type Priority uint8
+
+const (
+	PriorityNormal Priority = iota // default lane
+	PriorityHigh                   // time-critical sends, such as OCR transmissions
+	PriorityLow                    // bulk sends, such as top-ups
+
+	numPriorities = 3
+)
+
+// Priorities lists the lanes from the highest priority to the lowest.
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(p))
+	}
+}
+
+func (p Priority) valid() bool {
+	return p < numPriorities
+}
+
+// QueueDepths is the number of transactions waiting to be broadcast in each priority lane.
+type QueueDepths map[Priority]int
+
+// Total returns the number of transactions waiting to be broadcast across the lanes.
+func (d QueueDepths) Total() int {
+	total := 0
+	for _, depth := range d {
+		total += depth
+	}
+	return total
+}
+
+// weight returns the share of a lane when several lanes have queued transactions.
+func (c Config) weight(p Priority) int {
+	switch p {
+	case PriorityHigh:
+		return int(c.HighPriorityWeight) //nolint:gosec // weights are small
+	case PriorityLow:
+		return int(c.LowPriorityWeight) //nolint:gosec // weights are small
+	default:
+		return int(c.NormalPriorityWeight) //nolint:gosec // weights are small
+	}
+}
+
+// lanes are the broadcast queues of a sender, one per priority.
+type lanes struct {
+	queues  [numPriorities]chan *Tx
+	wake    chan struct{}      // signals the broadcast loop that a lane has transactions
+	credits [numPriorities]int // smooth weighted round-robin state, only used by the broadcast loop
+}
+
+func newLanes(size uint) *lanes {
+	l := &lanes{wake: make(chan struct{}, 1)}
+	for i := range l.queues {
+		l.queues[i] = make(chan *Tx, size)
+	}
+	return l
+}
+
+// push queues a transaction in the lane of its priority, failing when the lane is full.
+func (l *lanes) push(tx *Tx) error {
+	select {
+	case l.queues[tx.Priority] <- tx:
+		l.signal()
+		return nil
+	default:
+		return fmt.Errorf("%w: %s priority lane", ErrQueueFull, tx.Priority)
+	}
+}
+
+func (l *lanes) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// len returns the number of transactions queued in a lane.
+func (l *lanes) len(p Priority) int {
+	return len(l.queues[p])
+}
+
+// total returns the number of transactions queued in every lane.
+func (l *lanes) total() int {
+	total := 0
+	for _, q := range l.queues {
+		total += len(q)
+	}
+	return total
+}
+
+// pop takes the next transaction by smooth weighted round-robin across the lanes that have
+// transactions queued: each of them gains its weight in credits, the one with the most credits is
+// served and pays back the weights of all of them. Returns nil when every lane is empty.
+func (l *lanes) pop(cfg Config) *Tx {
+	picked, total := -1, 0
+	for _, p := range Priorities {
+		if len(l.queues[p]) == 0 {
+			// an idle lane does not bank credits
+			l.credits[p] = 0
+			continue
+		}
+		weight := max(cfg.weight(p), 1)
+		l.credits[p] += weight
+		total += weight
+		if picked == -1 || l.credits[p] > l.credits[picked] {
+			picked = int(p)
+		}
+	}
+	if picked == -1 {
+		return nil
+	}
+
+	select {
+	case tx := <-l.queues[picked]:
+		l.credits[picked] -= total
+		return tx
+	default:
+		// only the broadcast loop takes transactions, a lane seen non-empty stays so
+		return nil
+	}
+}
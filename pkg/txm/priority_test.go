@@ -0,0 +1,119 @@
+package txm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanes_Push(t *testing.T) {
+	l := newLanes(2)
+	for i := range 2 {
+		require.NoError(t, l.push(&Tx{ID: fmt.Sprint("high-", i), Priority: PriorityHigh}))
+	}
+	require.ErrorIs(t, l.push(&Tx{ID: "high-2", Priority: PriorityHigh}), ErrQueueFull)
+	// a full lane does not hold back the others
+	require.NoError(t, l.push(&Tx{ID: "low-0", Priority: PriorityLow}))
+
+	require.Equal(t, 2, l.len(PriorityHigh))
+	require.Equal(t, 0, l.len(PriorityNormal))
+	require.Equal(t, 1, l.len(PriorityLow))
+	require.Equal(t, 3, l.total())
+	require.Len(t, l.wake, 1)
+}
+
+func TestLanes_Pop(t *testing.T) {
+	testCases := []struct {
+		name     string
+		weights  [numPriorities]uint // by priority
+		queued   [numPriorities]int  // by priority
+		expected []Priority          // lanes served, in order
+		shares   map[Priority]int    // transactions served by lane, when the order is not checked
+	}{
+		{
+			name:    "every lane queued",
+			weights: weights(6, 3, 1),
+			queued:  queued(10, 10, 10),
+			expected: []Priority{
+				PriorityHigh, PriorityNormal, PriorityHigh, PriorityHigh, PriorityNormal,
+				PriorityHigh, PriorityLow, PriorityHigh, PriorityNormal, PriorityHigh,
+			},
+		},
+		{
+			name:    "shares over several rounds",
+			weights: weights(6, 3, 1),
+			queued:  queued(40, 40, 40),
+			shares:  map[Priority]int{PriorityHigh: 30, PriorityNormal: 15, PriorityLow: 5},
+		},
+		{
+			name:     "single lane queued",
+			weights:  weights(6, 3, 1),
+			queued:   queued(0, 0, 3),
+			expected: []Priority{PriorityLow, PriorityLow, PriorityLow},
+		},
+		{
+			name:     "drained lane leaves its share to the others",
+			weights:  weights(1, 1, 1),
+			queued:   queued(1, 3, 0),
+			expected: []Priority{PriorityHigh, PriorityNormal, PriorityNormal, PriorityNormal},
+		},
+		{
+			name:     "zero weight still served",
+			weights:  weights(1, 0, 0),
+			queued:   queued(2, 2, 0),
+			expected: []Priority{PriorityHigh, PriorityNormal, PriorityHigh, PriorityNormal},
+		},
+		{name: "nothing queued", weights: weights(6, 3, 1)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfigSet
+			cfg.HighPriorityWeight, cfg.NormalPriorityWeight, cfg.LowPriorityWeight = tc.weights[PriorityHigh], tc.weights[PriorityNormal], tc.weights[PriorityLow]
+
+			l := newLanes(100)
+			for _, p := range Priorities {
+				for i := range tc.queued[p] {
+					require.NoError(t, l.push(&Tx{ID: fmt.Sprintf("%s-%d", p, i), Priority: p}))
+				}
+			}
+
+			pops := len(tc.expected)
+			for _, n := range tc.shares {
+				pops += n
+			}
+			served := map[Priority]int{}
+			var order []Priority
+			for range pops {
+				tx := l.pop(cfg)
+				require.NotNil(t, tx)
+				// a lane is served in the order its transactions were queued
+				require.Equal(t, fmt.Sprintf("%s-%d", tx.Priority, served[tx.Priority]), tx.ID)
+				served[tx.Priority]++
+				order = append(order, tx.Priority)
+			}
+
+			if tc.shares != nil {
+				// while every lane has transactions queued, they are served in proportion to their weights
+				require.Equal(t, tc.shares, served)
+				return
+			}
+			require.Equal(t, tc.expected, order)
+			if l.total() == 0 {
+				require.Nil(t, l.pop(cfg))
+			}
+		})
+	}
+}
+
+func weights(high, normal, low uint) [numPriorities]uint {
+	var w [numPriorities]uint
+	w[PriorityHigh], w[PriorityNormal], w[PriorityLow] = high, normal, low
+	return w
+}
+
+func queued(high, normal, low int) [numPriorities]int {
+	var q [numPriorities]int
+	q[PriorityHigh], q[PriorityNormal], q[PriorityLow] = high, normal, low
+	return q
+}
@@ -0,0 +1,183 @@
+package txm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrQueryIDsExhausted = errors.New("highload query IDs exhausted")
+
+const (
+	// highload v3 query IDs are 23 bits, a 13 bits shift followed by a 10 bits bit number
+	maxQueryID = 1 << 23
+	// IDs sharing a shift, the wallet keeps the ones it processed in a bitmap of a cell per shift
+	queryIDsPerShift = 1 << 10
+	// IDs reserved in the store at a time, the unused rest of a reservation is skipped after a restart
+	queryIDReserve = 1024
+	// the creation time of a message is backdated to tolerate lite servers with a clock behind ours
+	queryIDBackdate = 30 * time.Second
+)
+
+// queryIDAllocator hands out the query IDs of the external messages of a highload wallet.
+// IDs come from a counter that only moves forward, taken modulo the query ID space, so an ID is only
+// reused after every other one was. The wallet remembers the IDs of the messages it processed for up
+// to two TTLs, an ID handed out within that window is never handed out again. The store keeps the
+// end of the range reserved so far, allocation resumes past it after a restart.
+type queryIDAllocator struct {
+	wallet     string
+	orm        ORM
+	window     time.Duration                           // how long the wallet may remember an ID after it was handed out
+	remembered func(context.Context) ([]uint32, error) // IDs the wallet remembers on-chain
+
+	mu       sync.Mutex
+	next     uint64                                   // counter of the next ID
+	reserved uint64                                   // end of the range reserved in the store
+	shift    int                                      // shift of the last ID handed out, -1 before the first one
+	issuedAt [maxQueryID / queryIDsPerShift]time.Time // when an ID of every shift was last handed out
+}
+
+func newQueryIDAllocator(wallet string, orm ORM, messageTTL time.Duration, remembered func(context.Context) ([]uint32, error)) *queryIDAllocator {
+	return &queryIDAllocator{
+		wallet:     wallet,
+		orm:        orm,
+		window:     2*messageTTL + queryIDBackdate,
+		remembered: remembered,
+	}
+}
+
+// load resumes allocation from the cursor in the store. Without one, allocation starts after the
+// latest ID the wallet remembers on-chain, so that a Txm keeping no store does not reuse the IDs of
+// its previous run.
+func (a *queryIDAllocator) load(ctx context.Context) error {
+	cursor, err := a.orm.GetQueryIDCursor(ctx, a.wallet)
+	if err != nil {
+		return fmt.Errorf("failed to load query ID cursor of %s: %w", a.wallet, err)
+	}
+	if cursor == 0 {
+		remembered, err := a.remembered(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load query IDs remembered by %s: %w", a.wallet, err)
+		}
+		cursor = nextQueryID(remembered)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.next, a.reserved = cursor, cursor
+	a.shift = -1
+	a.issuedAt = [maxQueryID / queryIDsPerShift]time.Time{}
+	return nil
+}
+
+// allocate returns a query ID unused within the window, and the creation time of the message to send with it.
+func (a *queryIDAllocator) allocate(ctx context.Context) (uint32, int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// the bitmap of a shift only holds 1023 bits, the last bit number is not a valid ID
+	if a.next%queryIDsPerShift == queryIDsPerShift-1 {
+		a.next++
+	}
+	now := time.Now()
+	shift := int(a.next % maxQueryID / queryIDsPerShift) //nolint:gosec // bounded by maxQueryID
+	if shift != a.shift && now.Sub(a.issuedAt[shift]) <= a.window {
+		// IDs are handed out in order, the ones of a shift were last handed out one lap ago
+		return 0, 0, fmt.Errorf("%w: every ID was handed out within %s", ErrQueryIDsExhausted, a.window)
+	}
+
+	if a.next >= a.reserved {
+		reserved := a.next + queryIDReserve
+		if err := a.orm.SetQueryIDCursor(ctx, a.wallet, reserved); err != nil {
+			return 0, 0, fmt.Errorf("failed to reserve query IDs of %s: %w", a.wallet, err)
+		}
+		a.reserved = reserved
+	}
+
+	id := uint32(a.next % maxQueryID) //nolint:gosec // bounded by maxQueryID
+	a.next++
+	a.shift = shift
+	a.issuedAt[shift] = now
+	return id, now.Add(-queryIDBackdate).Unix(), nil
+}
+
+// nextQueryID returns the ID following the latest of the IDs remembered by a wallet. IDs are handed
+// out in order modulo the query ID space, the latest one is the one preceding the largest gap between
+// them.
+func nextQueryID(remembered []uint32) uint64 {
+	if len(remembered) == 0 {
+		return 0
+	}
+	ids := slices.Clone(remembered)
+	slices.Sort(ids)
+
+	latest := ids[len(ids)-1]
+	largestGap := uint64(ids[0]) + maxQueryID - uint64(latest)
+	for i := 1; i < len(ids); i++ {
+		if gap := uint64(ids[i] - ids[i-1]); gap > largestGap {
+			largestGap, latest = gap, ids[i-1]
+		}
+	}
+	return (uint64(latest) + 1) % maxQueryID
+}
+
+// walletQueryIDs returns the query IDs a highload v3 wallet remembers it processed, as of the latest block.
+func walletQueryIDs(ctx context.Context, client ton.APIClientWrapped, wallet *address.Address) ([]uint32, error) {
+	block, err := client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	account, err := client.WaitForBlock(block.SeqNo).GetAccount(ctx, block, wallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if !account.IsActive || account.Data == nil {
+		return nil, nil
+	}
+	return parseWalletQueryIDs(account.Data)
+}
+
+// parseWalletQueryIDs reads the query IDs in the data of a highload v3 wallet: its public key and
+// subwallet ID, then the IDs processed before and since its last cleanup, as a dictionary from the
+// shift of the IDs to a cell holding the bitmap of their bit numbers.
+func parseWalletQueryIDs(data *cell.Cell) ([]uint32, error) {
+	s := data.BeginParse()
+	if _, err := s.LoadSlice(256 + 32); err != nil {
+		return nil, fmt.Errorf("failed to load wallet keys: %w", err)
+	}
+
+	var ids []uint32
+	for _, name := range []string{"old queries", "queries"} {
+		queries, err := s.LoadDict(13)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", name, err)
+		}
+		entries, err := queries.LoadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", name, err)
+		}
+		for _, entry := range entries {
+			shift, err := entry.Key.LoadUInt(13)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s shift: %w", name, err)
+			}
+			bitmap, err := entry.Value.LoadRef()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s bitmap: %w", name, err)
+			}
+			for bitNumber := uint32(0); bitmap.BitsLeft() > 0; bitNumber++ {
+				if bitmap.MustLoadBoolBit() {
+					ids = append(ids, uint32(shift)*queryIDsPerShift+bitNumber) //nolint:gosec // 13 bits
+				}
+			}
+		}
+	}
+	return ids, nil
+}
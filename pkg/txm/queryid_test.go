@@ -0,0 +1,154 @@
+package txm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// cursorORM keeps the query ID cursor of a single wallet, the other methods do not persist anything.
+type cursorORM struct {
+	nopORM
+	cursor uint64
+}
+
+func (o *cursorORM) GetQueryIDCursor(context.Context, string) (uint64, error) { return o.cursor, nil }
+func (o *cursorORM) SetQueryIDCursor(_ context.Context, _ string, cursor uint64) error {
+	o.cursor = cursor
+	return nil
+}
+
+func rememberedIDs(ids ...uint32) func(context.Context) ([]uint32, error) {
+	return func(context.Context) ([]uint32, error) { return ids, nil }
+}
+
+func TestNextQueryID(t *testing.T) {
+	testCases := []struct {
+		name       string
+		remembered []uint32
+		next       uint64
+	}{
+		{name: "nothing remembered", next: 0},
+		{name: "single ID", remembered: []uint32{5}, next: 6},
+		{name: "unordered IDs", remembered: []uint32{12, 10, 20, 11}, next: 21},
+		{name: "last ID of the space", remembered: []uint32{maxQueryID - 2, maxQueryID - 1}, next: 0},
+		{name: "IDs across the wrap-around", remembered: []uint32{maxQueryID - 2, 1, maxQueryID - 1, 0}, next: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.next, nextQueryID(tc.remembered))
+		})
+	}
+}
+
+func TestQueryIDAllocator_Load(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cursor     uint64
+		remembered func(context.Context) ([]uint32, error)
+		first      uint32
+		err        string
+	}{
+		{name: "stored cursor", cursor: 4096, remembered: rememberedIDs(10), first: 4096},
+		{name: "wallet state without a cursor", remembered: rememberedIDs(10, 11), first: 12},
+		{name: "new wallet", remembered: rememberedIDs(), first: 0},
+		{
+			name:       "wallet state unavailable",
+			remembered: func(context.Context) ([]uint32, error) { return nil, errors.New("lite server unavailable") },
+			err:        "lite server unavailable",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			orm := &cursorORM{cursor: tc.cursor}
+			a := newQueryIDAllocator("wallet", orm, time.Minute, tc.remembered)
+			err := a.load(t.Context())
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			id, _, err := a.allocate(t.Context())
+			require.NoError(t, err)
+			require.Equal(t, tc.first, id)
+			// a range is reserved past the ID before it is handed out
+			require.Equal(t, uint64(tc.first)+queryIDReserve, orm.cursor)
+		})
+	}
+}
+
+func TestQueryIDAllocator_WrapAround(t *testing.T) {
+	ctx := t.Context()
+	a := newQueryIDAllocator("wallet", &cursorORM{}, time.Minute, rememberedIDs(maxQueryID-4))
+	require.NoError(t, a.load(ctx))
+
+	// the last bit number of a shift is skipped, then allocation wraps around to the start of the space
+	ids := make([]uint32, 0, 4)
+	for range 4 {
+		id, _, err := a.allocate(ctx)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	require.Equal(t, []uint32{maxQueryID - 3, maxQueryID - 2, 0, 1}, ids)
+
+	// IDs of the next shift were handed out one lap ago, within the window
+	a.next = queryIDsPerShift - 2
+	_, _, err := a.allocate(ctx)
+	require.NoError(t, err)
+	a.issuedAt[1] = time.Now().Add(-time.Minute)
+	_, _, err = a.allocate(ctx)
+	require.ErrorIs(t, err, ErrQueryIDsExhausted)
+
+	a.issuedAt[1] = time.Now().Add(-a.window - time.Second)
+	id, _, err := a.allocate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint32(queryIDsPerShift), id)
+}
+
+func TestParseWalletQueryIDs(t *testing.T) {
+	// bitmaps of the IDs remembered from before the last cleanup, and since
+	oldQueries := map[int64][]uint{0: {0, 1022}, 3: {7}}
+	queries := map[int64][]uint{maxQueryID/queryIDsPerShift - 1: {5}}
+	dict := func(queries map[int64][]uint) *cell.Dictionary {
+		d := cell.NewDict(13)
+		for shift, bitNumbers := range queries {
+			bitmap := make([]bool, queryIDsPerShift-1)
+			for _, bitNumber := range bitNumbers {
+				bitmap[bitNumber] = true
+			}
+			b := cell.BeginCell()
+			for _, set := range bitmap {
+				b.MustStoreBoolBit(set)
+			}
+			require.NoError(t, d.SetIntKey(big.NewInt(shift), cell.BeginCell().MustStoreRef(b.EndCell()).EndCell()))
+		}
+		return d
+	}
+	data := cell.BeginCell().
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(698983191, 32).
+		MustStoreDict(dict(oldQueries)).
+		MustStoreDict(dict(queries)).
+		MustStoreUInt(uint64(time.Now().Unix()), 64). //nolint:gosec // unix time is positive
+		MustStoreUInt(60, 22).
+		EndCell()
+
+	ids, err := parseWalletQueryIDs(data)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uint32{0, 1022, 3*queryIDsPerShift + 7, maxQueryID - queryIDsPerShift + 5}, ids)
+
+	empty := cell.BeginCell().MustStoreSlice(make([]byte, 32), 256).MustStoreUInt(698983191, 32).
+		MustStoreDict(nil).MustStoreDict(nil).MustStoreUInt(0, 64).MustStoreUInt(60, 22).EndCell()
+	ids, err = parseWalletQueryIDs(empty)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	_, err = parseWalletQueryIDs(cell.BeginCell().MustStoreUInt(1, 8).EndCell())
+	require.ErrorContains(t, err, "wallet keys")
+}
@@ -0,0 +1,80 @@
+package txm
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+// SelectionPolicy decides which wallet of the pool sends a request that does not name one.
+type SelectionPolicy string
+
+const (
+	SelectRoundRobin  SelectionPolicy = "round-robin"  // cycle through the wallets in order
+	SelectLeastLoaded SelectionPolicy = "least-loaded" // pick the wallet with the fewest queued and in-flight transactions
+)
+
+// Sender is a wallet the Txm can send transactions from.
+type Sender struct {
+	Wallet  *wallet.Wallet // highload wallet sending the transactions
+	Account string         // Optional: keystore account signing for the wallet, checked on Enqueue when set
+}
+
+// sender is a Sender of the pool with its own broadcast lanes, drained by a dedicated broadcast loop
+// so that wallets send independently of each other.
+type sender struct {
+	Sender
+	lanes    *lanes
+	queryIDs *queryIDAllocator
+}
+
+// senderPool holds the wallets of the Txm, by address.
+type senderPool struct {
+	senders   []*sender
+	byAddress map[string]*sender
+	policy    SelectionPolicy
+	next      atomic.Uint64
+}
+
+// newSenderPool creates the pool, senders are expected to be validated by the caller.
+func newSenderPool(senders []Sender, laneSize uint, policy SelectionPolicy) *senderPool {
+	pool := &senderPool{
+		senders:   make([]*sender, 0, len(senders)),
+		byAddress: make(map[string]*sender, len(senders)),
+		policy:    policy,
+	}
+	for _, s := range senders {
+		entry := &sender{Sender: s, lanes: newLanes(laneSize)}
+		pool.senders = append(pool.senders, entry)
+		pool.byAddress[s.Wallet.Address().String()] = entry
+	}
+	return pool
+}
+
+// get returns the sender for a wallet address.
+func (p *senderPool) get(addr *address.Address) (*sender, error) {
+	s, exists := p.byAddress[addr.String()]
+	if !exists {
+		return nil, fmt.Errorf("no sender wallet for address %s", addr.String())
+	}
+	return s, nil
+}
+
+// pick selects a sender according to the selection policy, load returns the number of
+// transactions a sender is currently handling.
+func (p *senderPool) pick(load func(*sender) int) *sender {
+	if p.policy == SelectRoundRobin {
+		return p.senders[(p.next.Add(1)-1)%uint64(len(p.senders))]
+	}
+
+	picked := p.senders[0]
+	pickedLoad := load(picked)
+	for _, s := range p.senders[1:] {
+		if l := load(s); l < pickedLoad {
+			picked, pickedLoad = s, l
+		}
+	}
+	return picked
+}
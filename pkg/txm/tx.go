@@ -10,8 +10,22 @@ import (
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
+// TxState is the persisted lifecycle state of a transaction.
+type TxState string
+
+const (
+	TxStateEnqueued     TxState = "enqueued"     // accepted by Enqueue, waiting in the broadcast queue
+	TxStateBroadcasting TxState = "broadcasting" // external message handed to a lite server, inclusion not yet observed
+	TxStateUnconfirmed  TxState = "unconfirmed"  // included on-chain, waiting for the trace to finalize
+	TxStateFinalized    TxState = "finalized"    // trace finalized, see exit code for the outcome
+	TxStateErrored      TxState = "errored"      // could not be broadcast
+	TxStateExpired      TxState = "expired"      // did not land or finalize before its expiration
+)
+
 type Tx struct {
+	ID              string                        // unique identifier assigned on enqueue
 	Mode            uint8                         // send mode bitmask, controls how the TON message is processed
+	Priority        Priority                      // broadcast lane of the transaction
 	From            address.Address               // wallet used to send the message
 	To              address.Address               // destination address
 	Amount          tlb.Coins                     // amount to send
@@ -20,5 +34,14 @@ type Tx struct {
 	Bounceable      bool                          // whether the destination is bounceable
 	CreatedAt       time.Time                     // when the tx was first enqueued
 	Expiration      time.Time                     // expiration timestamp based on TTL
+	InMsgHash       []byte                        // hash of the signed external message of the current attempt
+	MsgExpiration   time.Time                     // after this the wallet no longer accepts the external message of the current attempt
+	BroadcastAt     time.Time                     // when the external message of the current attempt was handed to the lite servers, not persisted
+	ExternalMsg     *tlb.ExternalMessage          // signed external message of the current attempt, rebroadcast until it lands, not persisted
+	LastSentAt      time.Time                     // when the external message of the current attempt was last sent, not persisted
+	Rebroadcasts    uint                          // times the external message of the current attempt was sent again to other lite servers
+	NodeID          uint32                        // lite server the transaction is sent and confirmed through with sticky node contexts, not persisted
+	Attempts        uint                          // number of times the tx was rebuilt and resubmitted after its external message expired
+	EstimatedFee    tlb.Coins                     // fees the amount was sized for, zero unless the Txm sized the amount
 	ReceivedMessage tracetracking.ReceivedMessage // received message
 }
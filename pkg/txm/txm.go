@@ -1,14 +1,20 @@
 package txm
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 
@@ -18,18 +24,41 @@ import (
 	commontypes "github.com/smartcontractkit/chainlink-common/pkg/types"
 	commonutils "github.com/smartcontractkit/chainlink-common/pkg/utils"
 
+	"github.com/smartcontractkit/chainlink-ton/pkg/fees"
+	tonconfig "github.com/smartcontractkit/chainlink-ton/pkg/ton/config"
 	"github.com/smartcontractkit/chainlink-ton/pkg/ton/key"
 	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
 	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+
+	"golang.org/x/exp/maps"
 )
 
+// findTxMaxScan bounds how many wallet transactions are scanned when looking for a broadcast external message
+// that did not expire yet.
+const findTxMaxScan = 100
+
+// findTxPageSize is the number of wallet transactions fetched per lite server query when looking for a
+// broadcast external message.
+const findTxPageSize = 16
+
+// drainPollInterval is how often Close checks whether the transactions drained.
+const drainPollInterval = 500 * time.Millisecond
+
+// highloadInternalTransferOp is the op of the internal transfer the highload v3 wallet sends itself to
+// carry the messages of an external message it can not send directly.
+const highloadInternalTransferOp = 0xae42e5a4
+
 type TxManager interface {
 	services.Service
 
-	Enqueue(request Request) error
+	Enqueue(request Request) (string, error)
 	GetTransactionStatus(ctx context.Context, lt uint64) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error)
+	GetTransactionStatusByID(ctx context.Context, id string) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error)
+	GetTransactionFailure(ctx context.Context, id string) (*Failure, error)
 	GetClient() tracetracking.SignedAPIClient
-	InflightCount() (int, int)
+	InflightCount() (QueueDepths, int)
+	RetentionCount() (int, int)
+	Subscribe(ctx context.Context, id string) (<-chan TxStatusUpdate, func(), error)
 }
 
 var _ TxManager = (*Txm)(nil)
@@ -38,34 +67,114 @@ type Txm struct {
 	Logger   logger.Logger
 	Keystore loop.Keystore
 	Config   Config
+	ChainID  string // Optional: labels the metrics of the Txm
+
+	Client       tracetracking.SignedAPIClient // client of the default sender, the first wallet of the pool
+	Balances     BalanceReader                 // Optional: when set, Enqueue refuses transactions the sender can not afford
+	FeeEstimator fees.Estimator                // Optional: when set, the forward fee of a transaction is reserved when checking the sender balance
+	AccountStore *AccountStore
+	Starter      commonutils.StartStopOnce
+	Done         sync.WaitGroup
+	Stop         chan struct{}
 
-	Client        tracetracking.SignedAPIClient
-	BroadcastChan chan *Tx
-	AccountStore  *AccountStore
-	Starter       commonutils.StartStopOnce
-	Done          sync.WaitGroup
-	Stop          chan struct{}
+	senders  *senderPool
+	pruned   atomic.Int64 // transactions dropped from memory by the pruner since start
+	draining atomic.Bool  // set by Close, Enqueue refuses new transactions while the queues drain
+}
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrDraining            = errors.New("txm is draining, not accepting new transactions")
+)
+
+// BalanceReader provides the latest known balance of a sender wallet.
+type BalanceReader interface {
+	Balance(account string) (tlb.Coins, bool)
 }
 
 type Request struct {
-	Mode            uint8           // Send mode for TON message
-	FromWallet      wallet.Wallet   // Source wallet address
-	ContractAddress address.Address // Destination contract or wallet address
-	Body            *cell.Cell      // Encoded message body (method + params)
-	Amount          tlb.Coins       // Amount in nanotons
-	Bounce          bool            // Bounce on error (TON message flag)
-	StateInit       *cell.Cell      // Optional: contract deploy init
+	Mode             uint8            // Send mode for TON message
+	From             *address.Address // Optional: source wallet of the pool, picked by the WalletSelectionPolicy when nil
+	ContractAddress  address.Address  // Destination contract or wallet address
+	Body             *cell.Cell       // Encoded message body (method + params)
+	Amount           tlb.Coins        // Amount in nanotons
+	Bounce           bool             // Bounce on error (TON message flag)
+	StateInit        *cell.Cell       // Optional: contract deploy init
+	IdempotencyKey   string           // Optional: caller-assigned transaction ID, re-enqueuing the same key only sends again after a failure
+	SkipBalanceCheck bool             // Optional: enqueue without checking the sender balance covers the transaction
+	AutoAmount       bool             // Optional: size Amount from the estimated fees, Amount is then the value delivered on top of them
+	ExpectedGas      uint64           // Optional: gas the destination is expected to use, for AutoAmount
+	Priority         Priority         // Optional: broadcast lane, PriorityNormal by default
 }
 
+// New creates a Txm sending from the wallet of the client, that only keeps transactions in memory.
 func New(lgr logger.Logger, keystore loop.Keystore, client tracetracking.SignedAPIClient, config Config) *Txm {
+	return NewWithORM(lgr, keystore, client, newMemoryORM(), config)
+}
+
+// NewWithORM creates a Txm sending from the wallet of the client, that persists transactions
+// through the given ORM, pending transactions are reloaded and resumed on Start.
+func NewWithORM(lgr logger.Logger, keystore loop.Keystore, client tracetracking.SignedAPIClient, orm ORM, config Config) *Txm {
+	sender := Sender{Wallet: &client.Wallet}
+	if privateKey := client.Wallet.PrivateKey(); privateKey != nil {
+		if account, err := key.PublicKeyHex(privateKey.Public()); err == nil {
+			sender.Account = account
+		}
+	}
+
+	return newTxm(lgr, keystore, client, newSenderPool([]Sender{sender}, config.BroadcastChanSize, config.WalletSelectionPolicy), orm, config)
+}
+
+// NewWithSenders creates a Txm sending from a pool of wallets, each with its own broadcast queue
+// and TxStore. Requests that do not name a sender are spread over the pool by the WalletSelectionPolicy.
+// Transactions are persisted through the ORM, or only kept in memory when it is nil.
+func NewWithSenders(lgr logger.Logger, keystore loop.Keystore, client ton.APIClientWrapped, senders []Sender, orm ORM, config Config) (*Txm, error) {
+	if len(senders) == 0 {
+		return nil, errors.New("at least one sender is required")
+	}
+
+	switch config.WalletSelectionPolicy {
+	case SelectRoundRobin, SelectLeastLoaded:
+	default:
+		return nil, fmt.Errorf("unknown wallet selection policy: %q", config.WalletSelectionPolicy)
+	}
+
+	seen := make(map[string]struct{}, len(senders))
+	for _, s := range senders {
+		addr := s.Wallet.Address().String()
+		if _, exists := seen[addr]; exists {
+			return nil, fmt.Errorf("duplicate sender wallet: %s", addr)
+		}
+		seen[addr] = struct{}{}
+	}
+
+	if orm == nil {
+		orm = newMemoryORM()
+	}
+
+	defaultClient := tracetracking.NewSignedAPIClient(client, *senders[0].Wallet)
+	return newTxm(lgr, keystore, defaultClient, newSenderPool(senders, config.BroadcastChanSize, config.WalletSelectionPolicy), orm, config), nil
+}
+
+func newTxm(lgr logger.Logger, keystore loop.Keystore, client tracetracking.SignedAPIClient, senders *senderPool, orm ORM, config Config) *Txm {
 	txm := &Txm{
-		Logger:        logger.Named(lgr, "Txm"),
-		Keystore:      keystore,
-		Config:        config,
-		Client:        client,
-		BroadcastChan: make(chan *Tx, config.BroadcastChanSize),
-		AccountStore:  NewAccountStore(),
-		Stop:          make(chan struct{}),
+		Logger:       logger.Named(lgr, "Txm"),
+		Keystore:     keystore,
+		Config:       config,
+		Client:       client,
+		AccountStore: NewPersistentAccountStore(orm),
+		Stop:         make(chan struct{}),
+		senders:      senders,
+	}
+
+	// every sender has its own TxStore and query IDs
+	messageTTL := time.Duration(tonconfig.WalletVersion.MessageTTL) * time.Second
+	for _, s := range senders.senders {
+		wallet := s.Wallet.Address()
+		txm.AccountStore.GetTxStore(wallet.String())
+		s.queryIDs = newQueryIDAllocator(wallet.String(), txm.AccountStore.orm, messageTTL, func(ctx context.Context) ([]uint32, error) {
+			return walletQueryIDs(ctx, client.Client, wallet)
+		})
 	}
 
 	return txm
@@ -89,42 +198,153 @@ func (t *Txm) GetClient() tracetracking.SignedAPIClient {
 
 func (t *Txm) Start(ctx context.Context) error {
 	return t.Starter.StartOnce("Txm", func() error {
-		t.Done.Add(2) // waitgroup: broadcast loop and confirm loop
-		go t.broadcastLoop()
+		for _, s := range t.senders.senders {
+			if err := s.queryIDs.load(ctx); err != nil {
+				return err
+			}
+		}
+		if err := t.resumePending(ctx); err != nil {
+			return fmt.Errorf("failed to resume pending transactions: %w", err)
+		}
+
+		t.Done.Add(len(t.senders.senders) + 2) // waitgroup: broadcast loop per sender, confirm loop and prune loop
+		for _, s := range t.senders.senders {
+			go t.broadcastLoop(s)
+		}
 		go t.confirmLoop()
+		go t.pruneLoop()
 		return nil
 	})
 }
 
-func (t *Txm) InflightCount() (int, int) {
-	return len(t.BroadcastChan), t.AccountStore.GetTotalInflightCount()
+// InflightCount returns the number of transactions waiting to be broadcast in each priority lane, across
+// the senders of the pool, and the number of transactions broadcast but not finalized yet.
+func (t *Txm) InflightCount() (QueueDepths, int) {
+	depths := make(QueueDepths, len(Priorities))
+	for _, p := range Priorities {
+		for _, s := range t.senders.senders {
+			depths[p] += s.lanes.len(p)
+		}
+	}
+	return depths, t.AccountStore.GetTotalInflightCount()
+}
+
+// RetentionCount returns the number of finalized, errored and expired transactions kept in memory
+// and the number of transactions the pruner dropped from memory since start.
+func (t *Txm) RetentionCount() (int, int) {
+	return t.AccountStore.GetTotalRetainedCount(), int(t.pruned.Load())
 }
 
 func (t *Txm) Close() error {
 	return t.Starter.StopOnce("Txm", func() error {
+		t.draining.Store(true)
+		t.drain()
+
 		close(t.Stop)
 		t.Done.Wait()
+		t.reportUnfinished()
+		t.AccountStore.CloseSubscriptions()
 		return nil
 	})
 }
 
-// Enqueues a transaction for broadcasting.
-func (t *Txm) Enqueue(request Request) error {
-	// Ensure we can sign with the requested address
-	pubKey := request.FromWallet.PrivateKey().Public()
-	pubKeyHex, err := key.PublicKeyHex(pubKey)
+// drain waits up to the drain timeout for the queued transactions to be sent and for every sent
+// transaction to reach a terminal state, while the broadcast and confirm loops keep running.
+func (t *Txm) drain() {
+	if t.Config.DrainTimeout <= 0 {
+		return
+	}
+
+	deadline := time.After(t.Config.DrainTimeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	t.Logger.Infow("draining transactions before stopping", "timeout", t.Config.DrainTimeout)
+	for {
+		unfinished := 0
+		for _, txs := range t.AccountStore.GetAllUnfinished() {
+			unfinished += len(txs)
+		}
+		if unfinished == 0 {
+			t.Logger.Infow("drained all transactions")
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			t.Logger.Warnw("drain timed out", "unfinished", unfinished)
+			return
+		}
+	}
+}
+
+// reportUnfinished reports the transactions left unfinished when stopping. They are resumed on the
+// next start when persisted, and lost otherwise.
+func (t *Txm) reportUnfinished() {
+	persisted := persistent(t.AccountStore.orm)
+
+	for account, txs := range t.AccountStore.GetAllUnfinished() {
+		for id, state := range txs {
+			if !persisted {
+				t.Logger.Errorw("transaction unfinished on stop, not persisted and lost", "id", id, "state", state, "sender", account)
+			} else {
+				t.Logger.Warnw("transaction unfinished on stop, resumed on next start", "id", id, "state", state, "sender", account)
+			}
+		}
+	}
+}
+
+// Enqueues a transaction for broadcasting and returns its ID.
+// The ID is the request's idempotency key when set, if a transaction with that key was already
+// enqueued from any sender its ID is returned without sending it again. A transaction with the key
+// that errored, expired or whose trace failed is replaced by a new one instead, under the same ID.
+func (t *Txm) Enqueue(request Request) (string, error) {
+	if t.draining.Load() {
+		return "", ErrDraining
+	}
+	if !request.Priority.valid() {
+		return "", fmt.Errorf("invalid priority %s", request.Priority)
+	}
+
+	if request.IdempotencyKey != "" {
+		// the transaction enqueued with the key is retried when it did not succeed
+		state, finalized, err := t.AccountStore.GetTxStateByID(context.Background(), request.IdempotencyKey)
+		switch {
+		case errors.Is(err, ErrTxNotFound):
+		case err != nil:
+			return "", fmt.Errorf("failed to look idempotency key up: %w", err)
+		case !retriable(state, finalized):
+			t.Logger.Debugw("transaction with idempotency key already enqueued", "id", request.IdempotencyKey)
+			return request.IdempotencyKey, nil
+		default:
+			t.Logger.Infow("retrying transaction with idempotency key", "id", request.IdempotencyKey, "state", state)
+		}
+	}
+
+	s, err := t.selectSender(request.From)
 	if err != nil {
-		return fmt.Errorf("failed to convert public key to hex: %w", err)
+		return "", err
+	}
+
+	// Ensure we can sign with the selected wallet
+	if s.Account != "" {
+		if _, err := t.Keystore.Sign(context.Background(), s.Account, nil); err != nil {
+			return "", fmt.Errorf("failed to sign: %w", err)
+		}
 	}
 
-	if _, err := t.Keystore.Sign(context.Background(), pubKeyHex, nil); err != nil {
-		return fmt.Errorf("failed to sign: %w", err)
+	id := request.IdempotencyKey
+	if id == "" {
+		id = uuid.NewString()
 	}
 
 	txExpirationMins := time.Minute * time.Duration(t.Config.TxExpirationMins) //nolint:gosec // ignoring G115 overflow conversion
 	tx := &Tx{
+		ID:         id,
 		Mode:       request.Mode,
-		From:       *request.FromWallet.Address(),
+		Priority:   request.Priority,
+		From:       *s.Wallet.Address(),
 		To:         request.ContractAddress,
 		Amount:     request.Amount,
 		Body:       request.Body,
@@ -134,58 +354,200 @@ func (t *Txm) Enqueue(request Request) error {
 		Expiration: time.Now().Add(txExpirationMins),
 	}
 
-	select {
-	case t.BroadcastChan <- tx:
+	if request.AutoAmount {
+		if tx.EstimatedFee, err = t.sizeAmount(tx, request.ExpectedGas); err != nil {
+			return "", fmt.Errorf("failed to size amount: %w", err)
+		}
+	}
+
+	if !request.SkipBalanceCheck {
+		if err := t.checkBalance(tx); err != nil {
+			return "", err
+		}
+	}
+
+	txStore := t.AccountStore.GetTxStore(tx.From.String())
+	if err := t.AccountStore.AddEnqueued(context.Background(), tx); err != nil {
+		if errors.Is(err, ErrTxAlreadyExists) {
+			t.Logger.Debugw("transaction with idempotency key already enqueued", "id", tx.ID)
+			return tx.ID, nil
+		}
+		return "", fmt.Errorf("failed to persist transaction: %w", err)
+	}
+
+	if err := s.lanes.push(tx); err != nil {
+		t.markErrored(context.Background(), txStore, tx.ID, err.Error())
+		return "", fmt.Errorf("could not enqueue transaction: %w", err)
+	}
+	return tx.ID, nil
+}
+
+// checkBalance refuses a transaction when its amount and estimated fees, on top of the transactions
+// of the sender that were not included on-chain yet, exceed the latest known balance of the sender.
+func (t *Txm) checkBalance(tx *Tx) error {
+	if t.Balances == nil {
 		return nil
-	default:
-		return errors.New("broadcast channel full, could not enqueue transaction")
 	}
+	balance, ok := t.Balances.Balance(tx.From.String())
+	if !ok {
+		return nil
+	}
+
+	pendingCount, required := t.AccountStore.GetTxStore(tx.From.String()).PendingAmount()
+	if tx.Mode&wallet.CarryAllRemainingBalance == 0 {
+		required.Add(required, tx.Amount.Nano())
+	}
+	feeReserve := t.feeReserve(tx)
+	required.Add(required, feeReserve.Mul(feeReserve, big.NewInt(int64(pendingCount+1))))
+
+	if balance.Nano().Cmp(required) < 0 {
+		return fmt.Errorf("%w: sender %s has %s TON, %s TON required", ErrInsufficientBalance,
+			tx.From.String(), balance.String(), tlb.FromNanoTON(required).String())
+	}
+	return nil
+}
+
+// feeReserve returns the fees the sender is expected to pay for a transaction: the configured
+// reserve for the wallet itself, plus the forward fee of the message when prices are available.
+func (t *Txm) feeReserve(tx *Tx) *big.Int {
+	reserve := new(big.Int).SetUint64(t.Config.FeeReserveNano)
+	if t.FeeEstimator == nil {
+		return reserve
+	}
+
+	estimate, err := t.FeeEstimator.Estimate(fees.Message{
+		Workchain:     tx.From.Workchain(),
+		DestWorkchain: tx.To.Workchain(),
+		Body:          tx.Body,
+		StateInit:     tx.StateInit,
+	})
+	if err != nil {
+		t.Logger.Debugw("failed to estimate forward fee, reserving the configured fees only", "err", err)
+		return reserve
+	}
+	return reserve.Add(reserve, estimate.ForwardFee.Nano())
+}
+
+// selectSender returns the sender for the requested wallet, or picks one from the pool when none is requested.
+func (t *Txm) selectSender(from *address.Address) (*sender, error) {
+	if from != nil {
+		return t.senders.get(from)
+	}
+
+	return t.senders.pick(func(s *sender) int {
+		return s.lanes.total() + t.AccountStore.GetTxStore(s.Wallet.Address().String()).InflightCount()
+	}), nil
+}
+
+// requeue puts a transaction back in the broadcast lane of its sender,
+// it is marked as errored when the lane is full or the sender is no longer part of the pool.
+func (t *Txm) requeue(ctx context.Context, txStore *TxStore, tx *Tx) bool {
+	s, err := t.senders.get(&tx.From)
+	if err != nil {
+		t.markErrored(ctx, txStore, tx.ID, err.Error())
+		return false
+	}
+
+	if err := s.lanes.push(tx); err != nil {
+		t.markErrored(ctx, txStore, tx.ID, err.Error())
+		return false
+	}
+	return true
+}
+
+// resumePending reloads the transactions persisted before a restart. Enqueued transactions
+// are put back on the broadcast queue and unconfirmed ones resume trace confirmation.
+func (t *Txm) resumePending(ctx context.Context) error {
+	pending, err := t.AccountStore.GetPendingTxs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		tx := p.Tx
+		txStore := t.AccountStore.GetTxStore(tx.From.String())
+
+		switch p.State {
+		case TxStateEnqueued:
+			txStore.RestoreEnqueued(tx)
+			if t.requeue(ctx, txStore, tx) {
+				t.Logger.Infow("re-enqueued persisted transaction", "id", tx.ID, "to", tx.To.String())
+			}
+		case TxStateBroadcasting:
+			// the confirm loop looks the external message up by hash until it lands or expires
+			txStore.RestoreBroadcasting(tx)
+			t.Logger.Infow("resumed tracking of persisted broadcasting transaction", "id", tx.ID, "to", tx.To.String())
+		case TxStateUnconfirmed:
+			receivedMessage, err := t.fetchReceivedMessage(ctx, p)
+			if err != nil {
+				// left in the ORM so the next restart can try again
+				t.Logger.Errorw("failed to reload unconfirmed transaction", "id", tx.ID, "LT", p.LT, "err", err)
+				continue
+			}
+			tx.ReceivedMessage = *receivedMessage
+			txStore.RestoreUnconfirmed(p.MsgLT, expirationTimestampMs(tx), tx)
+			t.Logger.Infow("resumed confirmation of persisted transaction", "id", tx.ID, "LT", p.MsgLT)
+		default:
+			t.Logger.Warnw("skipping persisted transaction in unexpected state", "id", tx.ID, "state", p.State)
+		}
+	}
+
+	return nil
+}
+
+// fetchReceivedMessage loads the wallet transaction of a persisted unconfirmed transaction and
+// picks the outgoing message of the transaction from it, the trace is then rebuilt by the confirm loop.
+func (t *Txm) fetchReceivedMessage(ctx context.Context, p *PersistedTx) (*tracetracking.ReceivedMessage, error) {
+	txs, err := t.Client.Client.ListTransactions(ctx, &p.Tx.From, 1, p.LT, p.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("wallet transaction with lt %d not found", p.LT)
+	}
+
+	walletMessage, err := tracetracking.MapToReceivedMessage(txs[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to map transaction: %w", err)
+	}
+	hops, err := t.walletHops(ctx, &walletMessage)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range hops {
+		for _, sentMessage := range path[len(path)-1].OutgoingInternalSentMessages {
+			if sentMessage.LamportTime == p.MsgLT {
+				receivedMessage := messageTrace(path, sentMessage)
+				return &receivedMessage, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("outgoing message with lt %d not found in wallet transaction %d", p.MsgLT, p.LT)
 }
 
-// Continuously listens and broadcasts enqueued transactions
-func (t *Txm) broadcastLoop() {
+// Continuously listens and broadcasts the transactions enqueued for a sender,
+// queued transactions are drained into batches sent as one highload external message.
+func (t *Txm) broadcastLoop(s *sender) {
 	defer t.Done.Done()
 
 	ctx, cancel := commonutils.ContextFromChan(t.Stop)
 	defer cancel()
 
-	t.Logger.Debugw("broadcastLoop: started")
+	senderAddress := s.Wallet.Address().String()
+	t.Logger.Debugw("broadcastLoop: started", "sender", senderAddress)
 
 	for {
 		select {
-		case tx := <-t.BroadcastChan:
-			t.Logger.Debugw("broadcasting transaction", "to", tx.To.String(), "amount", tx.Amount.Nano().String())
-
-			var st tlb.StateInit
-			if tx.StateInit != nil {
-				err := tlb.LoadFromCell(&st, tx.StateInit.BeginParse())
-				if err != nil {
-					t.Logger.Errorw("load from cell failed", "err", err, "to", tx.To.String())
-					continue
-				}
-			}
-
-			internalMsg := &tlb.InternalMessage{
-				SrcAddr:     &tx.From,
-				DstAddr:     &tx.To,
-				Bounce:      tx.Bounceable,
-				IHRDisabled: true,
-				Amount:      tx.Amount,
-				StateInit:   &st,
-				Body:        tx.Body,
-				CreatedAt:   uint32(tx.CreatedAt.Unix()), //nolint:gosec // ignoring G115 overflow conversion
-			}
-
-			msg := &wallet.Message{
-				Mode:            tx.Mode,
-				InternalMessage: internalMsg,
+		case <-s.lanes.wake:
+			batch := t.drainBatch(s)
+			if len(batch) == 0 {
+				continue
 			}
 
-			// 3. Sign and send
-			err := t.broadcastWithRetry(ctx, tx, msg)
-			if err != nil {
-				t.Logger.Errorw("broadcast failed after retries", "err", err)
-				continue
+			t.broadcastBatch(ctx, s, batch)
+			if s.lanes.total() > 0 {
+				// more than a batch was queued, come back for the rest
+				s.lanes.signal()
 			}
 		case <-t.Stop:
 			t.Logger.Debugw("broadcastLoop: stopped")
@@ -194,61 +556,150 @@ func (t *Txm) broadcastLoop() {
 	}
 }
 
-// Attempts to broadcast a transaction with retries on failure.
-func (t *Txm) broadcastWithRetry(ctx context.Context, tx *Tx, msg *wallet.Message) error {
-	var receivedMessage *tracetracking.ReceivedMessage
-	var err error
+// drainBatch collects up to MaxBatchSize transactions from the lanes of a sender without waiting,
+// taking them from each lane in proportion to its priority weight.
+func (t *Txm) drainBatch(s *sender) []*Tx {
+	var batch []*Tx
+	for uint(len(batch)) < max(t.Config.MaxBatchSize, 1) {
+		tx := s.lanes.pop(t.Config)
+		if tx == nil {
+			break
+		}
+		batch = append(batch, tx)
+	}
+	return batch
+}
 
-	for attempt := uint(1); attempt <= t.Config.MaxSendRetryAttempts; attempt++ {
-		receivedMessage, _, err = t.Client.SendWaitTransaction(ctx, tx.To, msg)
+// broadcastBatch builds the wallet messages of a batch and sends them together,
+// transactions whose message can not be built are errored without holding back the rest.
+func (t *Txm) broadcastBatch(ctx context.Context, s *sender, batch []*Tx) {
+	txStore := t.AccountStore.GetTxStore(s.Wallet.Address().String())
 
-		if err == nil {
-			t.Logger.Infow("transaction broadcasted", "to", tx.To.String(), "amount", tx.Amount.Nano().String())
-			break
+	txs := make([]*Tx, 0, len(batch))
+	msgs := make([]*wallet.Message, 0, len(batch))
+	for _, tx := range batch {
+		t.Logger.Debugw("broadcasting transaction", "id", tx.ID, "to", tx.To.String(), "amount", tx.Amount.Nano().String(), "attempts", tx.Attempts)
+
+		msg, err := buildMessage(tx)
+		if err != nil {
+			t.Logger.Errorw("failed to build message", "id", tx.ID, "err", err, "to", tx.To.String())
+			t.markErrored(ctx, txStore, tx.ID, err.Error())
+			continue
 		}
+		txs = append(txs, tx)
+		msgs = append(msgs, msg)
+	}
+	if len(txs) == 0 {
+		return
+	}
 
-		t.Logger.Warnw("failed to broadcast tx, will retry", "attempt", attempt, "err", err, "to", tx.To.String())
+	// 3. Sign and send
+	if err := t.broadcastWithRetry(ctx, s, txs, msgs); err != nil {
+		t.Logger.Errorw("broadcast failed", "batchSize", len(txs), "err", err)
+		for _, tx := range txs {
+			t.markErrored(ctx, txStore, tx.ID, err.Error())
+		}
+	}
+}
 
-		select {
-		case <-time.After(t.Config.SendRetryDelay):
-		case <-t.Stop:
-			t.Logger.Debugw("broadcastWithRetry: stopped during retry delay")
-			return errors.New("broadcast aborted")
+// buildMessage builds the wallet message carrying a transaction.
+func buildMessage(tx *Tx) (*wallet.Message, error) {
+	internalMsg := &tlb.InternalMessage{
+		SrcAddr:     &tx.From,
+		DstAddr:     &tx.To,
+		Bounce:      tx.Bounceable,
+		IHRDisabled: true,
+		Amount:      tx.Amount,
+		Body:        tx.Body,
+		CreatedAt:   uint32(tx.CreatedAt.Unix()), //nolint:gosec // ignoring G115 overflow conversion
+	}
+	// the highload wallet only sends a lone message directly when it carries no state init
+	if tx.StateInit != nil {
+		var st tlb.StateInit
+		if err := tlb.LoadFromCell(&st, tx.StateInit.BeginParse()); err != nil {
+			return nil, fmt.Errorf("load from cell failed: %w", err)
 		}
+		internalMsg.StateInit = &st
 	}
 
+	return &wallet.Message{
+		Mode:            tx.Mode,
+		InternalMessage: internalMsg,
+	}, nil
+}
+
+// Signs one external message carrying a batch of transactions and sends it with retries on failure.
+// The same signed message is resent on every attempt, so at most one of them can land,
+// inclusion is then observed by the confirm loop using the message hash.
+func (t *Txm) broadcastWithRetry(ctx context.Context, s *sender, txs []*Tx, msgs []*wallet.Message) error {
+	queryID, createdAt, err := s.queryIDs.allocate(ctx)
 	if err != nil {
-		t.Logger.Errorw("failed to broadcast tx after retries", "err", err, "to", tx.To.String())
-		return err
+		return fmt.Errorf("failed to allocate query ID: %w", err)
+	}
+	// the wallet state read to build the message and the send go to the lite server the confirm loop
+	// then looks the batch up on
+	sendCtx, nodeID := t.stickyContext(ctx, 0)
+	ext, err := s.Wallet.BuildExternalMessageForMany(tonconfig.WithQueryID(sendCtx, queryID, createdAt), msgs)
+	if err != nil {
+		return fmt.Errorf("failed to build external message: %w", err)
 	}
 
-	// Save receivedMessage into tx
-	tx.ReceivedMessage = *receivedMessage
-
-	// Determine expiration
-	lamportTime := receivedMessage.LamportTime
-	lamportTimeSecs := lamportTime / 1000
-	expirationTimestampSecs := lamportTimeSecs + uint64(t.Config.SendRetryDelay.Seconds())
+	// the wallet rejects the message once its TTL has passed since its creation time
+	msgExpiration := time.Unix(createdAt, 0).Add(time.Duration(tonconfig.WalletVersion.MessageTTL) * time.Second)
+	t.Logger.Debugw("built external message", "queryID", queryID, "createdAt", createdAt, "batchSize", len(txs))
 
-	txStore := t.AccountStore.GetTxStore(t.Client.Wallet.Address().String())
-	if txStore == nil {
-		return fmt.Errorf("txStore not found for sender %s", t.Client.Wallet.Address().String())
+	txStore := t.AccountStore.GetTxStore(s.Wallet.Address().String())
+	for _, tx := range txs {
+		if err := txStore.MarkBroadcasting(ctx, tx.ID, ext, msgExpiration, nodeID); err != nil {
+			return fmt.Errorf("failed to mark tx %s as broadcasting: %w", tx.ID, err)
+		}
 	}
 
-	err = txStore.AddUnconfirmed(lamportTime, expirationTimestampSecs, tx)
-	if err != nil {
-		t.Logger.Errorf("AddUnconfirmed err: %v", err)
-		return err
+	sender := s.Wallet.Address().String()
+	for attempt := uint(1); attempt <= t.Config.MaxSendRetryAttempts; attempt++ {
+		t.metrics().broadcastAttempts(sender, len(txs))
+		if attempt > 1 {
+			t.metrics().broadcastRetries(sender, retryReasonSendFailed, len(txs))
+		}
+
+		err = t.Client.Client.SendExternalMessage(sendCtx, ext)
+		if err == nil {
+			for _, tx := range txs {
+				t.metrics().broadcasted(sender, tx)
+				t.Logger.Infow("transaction broadcasted", "id", tx.ID, "to", tx.To.String(), "amount", tx.Amount.Nano().String(), "batchSize", len(txs))
+			}
+			return nil
+		}
+
+		t.Logger.Warnw("failed to broadcast batch, will retry", "attempt", attempt, "err", err, "batchSize", len(txs), "node", nodeID)
+		// the confirm loop keeps looking the batch up on the first lite server, and fails over itself
+		sendCtx, nodeID = t.stickyContext(ctx, t.failover(ctx, nodeID))
+
+		select {
+		case <-time.After(t.Config.SendRetryDelay):
+		case <-t.Stop:
+			t.Logger.Debugw("broadcastWithRetry: stopped during retry delay")
+			return nil
+		}
 	}
 
+	// a failed send may still have reached a lite server, the txs stay broadcasting until
+	// the confirm loop either finds them on-chain or their external message expires
+	t.Logger.Errorw("failed to broadcast batch after retries, waiting for message expiration", "err", err, "batchSize", len(txs))
 	return nil
 }
 
+// expirationTimestampMs returns the unix time in milliseconds after which the transaction
+// is no longer tracked, TxExpirationMins after it was first enqueued.
+func expirationTimestampMs(tx *Tx) uint64 {
+	return uint64(tx.Expiration.UnixMilli()) //nolint:gosec // ignoring G115 overflow conversion
+}
+
 // Periodically checks unconfirmed transactions for finality.
 func (t *Txm) confirmLoop() {
 	defer t.Done.Done()
 
-	_, cancel := commonutils.ContextFromChan(t.Stop)
+	ctx, cancel := commonutils.ContextFromChan(t.Stop)
 	defer cancel()
 
 	pollDuration := time.Duration(t.Config.ConfirmPollSecs) * time.Second //nolint:gosec // ignoring G115 overflow conversion
@@ -261,7 +712,9 @@ func (t *Txm) confirmLoop() {
 		case <-tick:
 			start := time.Now()
 
-			t.checkUnconfirmed()
+			t.checkBroadcasting(ctx)
+			t.checkUnconfirmed(ctx)
+			t.reportQueueMetrics()
 
 			remaining := pollDuration - time.Since(start)
 			if remaining > 0 {
@@ -278,54 +731,450 @@ func (t *Txm) confirmLoop() {
 	}
 }
 
-// Validates the confirmation status of all unconfirmed transactions by resolving their traces.
-func (t *Txm) checkUnconfirmed() {
-	allUnconfirmedTxs := t.AccountStore.GetAllUnconfirmed()
+// markErrored records that a transaction could not be broadcast.
+func (t *Txm) markErrored(ctx context.Context, txStore *TxStore, id string, reason string) {
+	if err := txStore.MarkErrored(ctx, id, reason); err != nil {
+		t.Logger.Errorw("failed to mark tx as errored", "id", id, "err", err)
+		return
+	}
+	t.metrics().dropped(txStore.accountAddress, TxStateErrored)
+}
 
-	for accountAddress, unconfirmedTxs := range allUnconfirmedTxs {
-		txStore := t.AccountStore.GetTxStore(accountAddress)
+// markExpired records that a transaction did not land or finalize before expiring.
+func (t *Txm) markExpired(ctx context.Context, txStore *TxStore, id string, reason string) {
+	if err := txStore.MarkExpired(ctx, id, reason); err != nil {
+		t.Logger.Errorw("failed to mark tx as expired", "id", id, "err", err)
+		return
+	}
+	t.metrics().dropped(txStore.accountAddress, TxStateExpired)
+}
 
-		for _, unconfirmedTx := range unconfirmedTxs {
-			tx := unconfirmedTx.Tx
-			receivedMessage := tx.ReceivedMessage
+func (t *Txm) metrics() txmMetrics {
+	return txmMetrics{chainID: t.ChainID}
+}
 
-			err := receivedMessage.WaitForTrace(&t.Client)
+// reportQueueMetrics sets the queue depth and inflight count gauges of every sender.
+func (t *Txm) reportQueueMetrics() {
+	for _, s := range t.senders.senders {
+		sender := s.Wallet.Address().String()
+		for _, p := range Priorities {
+			t.metrics().setQueueDepth(sender, p, s.lanes.len(p))
+		}
+		t.metrics().setInflight(sender, t.AccountStore.GetTxStore(sender).InflightCount())
+	}
+}
+
+// Periodically drops finalized, errored and expired transactions past retention from memory.
+func (t *Txm) pruneLoop() {
+	defer t.Done.Done()
+
+	if t.Config.PruneInterval <= 0 {
+		t.Logger.Warnw("pruneLoop: disabled, no prune interval configured")
+		return
+	}
+
+	ticker := time.NewTicker(commonutils.WithJitter(t.Config.PruneInterval))
+	defer ticker.Stop()
+
+	t.Logger.Debugw("pruneLoop: started")
+
+	for {
+		select {
+		case <-ticker.C:
+			if pruned := t.AccountStore.Prune(time.Now(), t.Config.RetentionPeriod, t.Config.MaxRetainedTxs); pruned > 0 {
+				t.pruned.Add(int64(pruned))
+				t.Logger.Debugw("pruned transactions past retention", "count", pruned, "totalPruned", t.pruned.Load())
+			}
+		case <-t.Stop:
+			t.Logger.Debugw("pruneLoop: stopped")
+			return
+		}
+	}
+}
+
+// Looks up the wallet transaction of every broadcasting batch by the hash of its external message.
+// Found transactions move on to trace confirmation, the ones whose external message expired without
+// landing are resubmitted with a fresh query ID or marked as expired.
+func (t *Txm) checkBroadcasting(ctx context.Context) {
+	allBroadcastingTxs := t.AccountStore.GetAllBroadcasting()
+
+	for accountAddress, broadcastingTxs := range allBroadcastingTxs {
+		txStore := t.AccountStore.GetTxStore(accountAddress)
+
+		for _, batch := range groupByInMsgHash(broadcastingTxs) {
+			first := batch[0]
+			lookupCtx, nodeID := t.stickyContext(ctx, first.NodeID)
+			for _, tx := range batch {
+				txStore.UpdateNodeID(tx.ID, nodeID)
+			}
+			walletTx, err := t.findWalletTransaction(lookupCtx, first, findTxMaxScan)
 			if err != nil {
-				t.Logger.Errorw("failed to wait for trace", "LT", unconfirmedTx.LT, "error", err)
+				t.Logger.Warnw("failed to look up broadcasting batch", "id", first.ID, "err", err, "node", nodeID)
+				t.failoverBatch(ctx, txStore, batch, nodeID)
 				continue
 			}
+			if walletTx == nil {
+				if time.Now().Before(first.MsgExpiration) {
+					t.rebroadcast(ctx, txStore, accountAddress, batch)
+					continue
+				}
+				// the lite server may not have caught up with the block including the message yet,
+				// and its clock may run behind the one of this node
+				if time.Now().Before(first.MsgExpiration.Add(t.Config.ExpiredMsgGracePeriod)) {
+					continue
+				}
+				// the wallet can no longer accept the message, a last lookup not bounded by findTxMaxScan
+				// tells for certain whether it landed before the batch is resubmitted
+				if walletTx, err = t.findWalletTransaction(lookupCtx, first, 0); err != nil {
+					t.Logger.Warnw("failed to look up expired batch", "id", first.ID, "err", err, "node", nodeID)
+					t.failoverBatch(ctx, txStore, batch, nodeID)
+					continue
+				}
+			}
 
-			if receivedMessage.Status() != tracetracking.Finalized {
+			if walletTx != nil {
+				if err := t.addUnconfirmed(lookupCtx, txStore, batch, walletTx); err != nil {
+					t.Logger.Errorw("failed to track included batch", "LT", walletTx.LT, "err", err, "node", nodeID)
+				}
 				continue
 			}
+			for _, tx := range batch {
+				t.handleExpiredMessage(ctx, txStore, tx)
+			}
+		}
+	}
+}
+
+// failoverBatch moves the lookups of a batch to the next lite server after nodeID failed.
+func (t *Txm) failoverBatch(ctx context.Context, txStore *TxStore, batch []*Tx, nodeID uint32) {
+	next := t.failover(ctx, nodeID)
+	for _, tx := range batch {
+		txStore.UpdateNodeID(tx.ID, next)
+	}
+}
+
+// findWalletTransaction looks up the wallet transaction that processed the external message of a batch,
+// walking the wallet transactions back to the creation of the message, and through at most maxScan of
+// them unless maxScan is zero. The wallet rejects messages created after the time of the transaction
+// processing them, so older transactions can not include it. Returns nil if the message was not found.
+func (t *Txm) findWalletTransaction(ctx context.Context, tx *Tx, maxScan int) (*tlb.Transaction, error) {
+	block, err := t.Client.Client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	account, err := t.Client.Client.WaitForBlock(block.SeqNo).GetAccount(ctx, block, &tx.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if !account.IsActive {
+		return nil, nil
+	}
+
+	createdAt := tx.MsgExpiration.Add(-time.Duration(tonconfig.WalletVersion.MessageTTL) * time.Second).Unix()
+	lt, hash := account.LastTxLT, account.LastTxHash
+	scanned := 0
+	for lt != 0 {
+		walletTxs, err := t.Client.Client.ListTransactions(ctx, &tx.From, findTxPageSize, lt, hash)
+		if errors.Is(err, ton.ErrNoTransactionsWereFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		// transactions are ordered from oldest to newest
+		for i := len(walletTxs) - 1; i >= 0; i-- {
+			walletTx := walletTxs[i]
+			if int64(walletTx.Now) < createdAt || (maxScan > 0 && scanned == maxScan) {
+				return nil, nil
+			}
+			scanned++
+			if walletTx.IO.In != nil && walletTx.IO.In.MsgType == tlb.MsgTypeExternalIn &&
+				bytes.Equal(walletTx.IO.In.Msg.Payload().Hash(), tx.InMsgHash) {
+				return walletTx, nil
+			}
+		}
+		lt, hash = walletTxs[0].PrevTxLT, walletTxs[0].PrevTxHash
+	}
+	return nil, nil
+}
+
+// rebroadcast sends the signed external message of a batch not included yet again, to the next lite
+// server of the pool every RebroadcastInterval, in case the ones it was sent to did not relay it.
+// The message is identical and carries the same query ID, the wallet processes it at most once.
+func (t *Txm) rebroadcast(ctx context.Context, txStore *TxStore, sender string, batch []*Tx) {
+	first := batch[0]
+	if t.Config.RebroadcastInterval <= 0 || first.ExternalMsg == nil || time.Since(first.LastSentAt) < t.Config.RebroadcastInterval {
+		// external messages of transactions resumed after a restart are not kept
+		return
+	}
+
+	nodeCtx := nodeContext(ctx, t.Client.Client.Client(), int(first.Rebroadcasts)+1) //nolint:gosec // bounded by the message TTL
+	err := t.Client.Client.SendExternalMessage(nodeCtx, first.ExternalMsg)
+	for _, tx := range batch {
+		if markErr := txStore.MarkRebroadcast(ctx, tx.ID); markErr != nil {
+			t.Logger.Warnw("failed to record rebroadcast", "id", tx.ID, "err", markErr)
+		}
+	}
+	t.metrics().broadcastRetries(sender, retryReasonRebroadcast, len(batch))
+	if err != nil {
+		t.Logger.Warnw("failed to rebroadcast batch", "id", first.ID, "rebroadcasts", first.Rebroadcasts, "err", err, "batchSize", len(batch))
+		return
+	}
+	t.Logger.Debugw("rebroadcasted batch", "id", first.ID, "rebroadcasts", first.Rebroadcasts, "batchSize", len(batch))
+}
 
-			exitCode := receivedMessage.OutcomeExitCode()
-			traceSucceeded := receivedMessage.TraceSucceeded()
+// groupByInMsgHash groups transactions sent in the same external message.
+func groupByInMsgHash(txs []*Tx) [][]*Tx {
+	batches := map[string][]*Tx{}
+	for _, tx := range txs {
+		batches[string(tx.InMsgHash)] = append(batches[string(tx.InMsgHash)], tx)
+	}
+	return maps.Values(batches)
+}
 
-			if err := txStore.MarkFinalized(unconfirmedTx.LT, traceSucceeded, exitCode); err != nil {
-				t.Logger.Errorw("failed to mark tx as finalized in TxStore", "LT", unconfirmedTx.LT, "error", err)
+// addUnconfirmed tracks every transaction of a batch included in walletTx by the outgoing message
+// the wallet emitted for it, each trace then resolves independently of the rest of the batch.
+// A batch carried by internal transfers stays broadcasting until the wallet executed them.
+func (t *Txm) addUnconfirmed(ctx context.Context, txStore *TxStore, batch []*Tx, walletTx *tlb.Transaction) error {
+	walletMessage, err := tracetracking.MapToReceivedMessage(walletTx)
+	if err != nil {
+		return fmt.Errorf("failed to map transaction: %w", err)
+	}
+
+	hops, err := t.walletHops(ctx, &walletMessage)
+	if err != nil {
+		return err
+	}
+	if hops == nil {
+		t.Logger.Debugw("waiting for the internal transfer of the batch", "LT", walletTx.LT, "batchSize", len(batch))
+		return nil
+	}
+
+	claimed := make(map[*tracetracking.SentMessage]bool)
+	for _, tx := range batch {
+		path, sentMessage := claimSentMessage(hops, claimed, tx)
+		if sentMessage == nil {
+			// the wallet accepted the external but did not emit this message, e.g. its action failed
+			t.Logger.Warnw("transaction message was not sent by the wallet", "id", tx.ID, "LT", walletTx.LT)
+			t.markErrored(ctx, txStore, tx.ID, "message was not sent by the wallet")
+			continue
+		}
+
+		if err := txStore.AddUnconfirmed(ctx, sentMessage.LamportTime, expirationTimestampMs(tx), tx, messageTrace(path, sentMessage)); err != nil {
+			t.Logger.Errorw("failed to add unconfirmed tx", "id", tx.ID, "err", err)
+			continue
+		}
+
+		t.Logger.Infow("transaction included", "id", tx.ID, "LT", sentMessage.LamportTime, "walletLT", walletTx.LT)
+	}
+	return nil
+}
+
+// walletHops returns the wallet transactions that may have sent the messages of a batch, each as the
+// path of internal transfers leading to it from the wallet transaction of the external message.
+// The highload wallet sends a batch, or a message with a state init, in an internal transfer to
+// itself, whose transaction then sends the messages. Returns nil while a transfer was not executed yet.
+func (t *Txm) walletHops(ctx context.Context, walletMessage *tracetracking.ReceivedMessage) ([][]*tracetracking.ReceivedMessage, error) {
+	var block *ton.BlockIDExt
+	var hops [][]*tracetracking.ReceivedMessage
+	pending := [][]*tracetracking.ReceivedMessage{{walletMessage}}
+	for len(pending) != 0 {
+		path := pending[0]
+		pending = pending[1:]
+		hops = append(hops, path)
+
+		for _, sentMessage := range path[len(path)-1].OutgoingInternalSentMessages {
+			if !isInternalTransfer(sentMessage.InternalMsg) {
 				continue
 			}
+			if block == nil {
+				var err error
+				if block, err = t.Client.Client.CurrentMasterchainInfo(ctx); err != nil {
+					return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+				}
+			}
+			received, err := t.Client.FindReceivedMessage(ctx, block, sentMessage)
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up internal transfer: %w", err)
+			}
+			if received == nil {
+				return nil, nil
+			}
+			pending = append(pending, append(slices.Clone(path), received))
+		}
+	}
+	return hops, nil
+}
 
-			if traceSucceeded {
-				t.Logger.Infow("transaction confirmed", "LT", unconfirmedTx.LT, "exitCode", exitCode)
-			} else {
-				t.Logger.Warnw("transaction failed", "LT", unconfirmedTx.LT, "exitCode", exitCode)
+// isInternalTransfer reports whether msg is an internal transfer the highload wallet sent itself.
+func isInternalTransfer(msg *tlb.InternalMessage) bool {
+	if msg == nil || msg.Body == nil || msg.SrcAddr == nil || msg.DstAddr == nil || !msg.SrcAddr.Equals(msg.DstAddr) {
+		return false
+	}
+	op, err := msg.Body.BeginParse().LoadUInt(32)
+	return err == nil && op == highloadInternalTransferOp
+}
+
+// claimSentMessage returns the first outgoing message of the wallet, not claimed yet, that carries the
+// transaction, along with the path of the wallet transaction that sent it.
+func claimSentMessage(hops [][]*tracetracking.ReceivedMessage, claimed map[*tracetracking.SentMessage]bool, tx *Tx) ([]*tracetracking.ReceivedMessage, *tracetracking.SentMessage) {
+	for _, path := range hops {
+		for _, sentMessage := range path[len(path)-1].OutgoingInternalSentMessages {
+			if claimed[sentMessage] || isInternalTransfer(sentMessage.InternalMsg) || !sentMessage.InternalMsg.DstAddr.Equals(&tx.To) {
+				continue
 			}
+			if !bytes.Equal(bodyHash(sentMessage.InternalMsg.Body), bodyHash(tx.Body)) {
+				continue
+			}
+			claimed[sentMessage] = true
+			return path, sentMessage
 		}
 	}
+	return nil, nil
 }
 
-// GetTransactionStatus translates internal TON transaction state to chainlink common statuses.
-func (t *Txm) GetTransactionStatus(ctx context.Context, lt uint64) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error) {
-	txStore := t.AccountStore.GetTxStore(t.Client.Wallet.Address().String())
-	if txStore == nil {
-		return commontypes.Unknown, 0, tlb.ZeroCoins, fmt.Errorf("txStore not found for sender %s", t.Client.Wallet.Address().String())
+func bodyHash(body *cell.Cell) []byte {
+	if body == nil {
+		return cell.BeginCell().EndCell().Hash()
+	}
+	return body.Hash()
+}
+
+// messageTrace narrows the path of wallet transactions that sent the message of one transaction down
+// to that single outgoing message, so that transactions sent in the same batch are traced separately.
+// The wallet transaction stays the root of the trace, followed by the internal transfers it took.
+func messageTrace(path []*tracetracking.ReceivedMessage, sentMessage *tracetracking.SentMessage) tracetracking.ReceivedMessage {
+	receivedMessage := *path[len(path)-1]
+	receivedMessage.OutgoingInternalSentMessages = []*tracetracking.SentMessage{sentMessage}
+	receivedMessage.OutgoingInternalReceivedMessages = make([]*tracetracking.ReceivedMessage, 0)
+	for i := len(path) - 2; i >= 0; i-- {
+		transfer := receivedMessage
+		receivedMessage = *path[i]
+		receivedMessage.OutgoingInternalSentMessages = make([]*tracetracking.SentMessage, 0)
+		receivedMessage.OutgoingInternalReceivedMessages = []*tracetracking.ReceivedMessage{&transfer}
+	}
+	return receivedMessage
+}
+
+// handleExpiredMessage resubmits a transaction whose external message can no longer land,
+// as long as resubmission is enabled, attempts are left and the transaction itself has not expired.
+func (t *Txm) handleExpiredMessage(ctx context.Context, txStore *TxStore, tx *Tx) {
+	if !t.Config.ResubmitExpired || tx.Attempts >= t.Config.MaxResubmitAttempts || !time.Now().Before(tx.Expiration) {
+		t.Logger.Warnw("transaction expired without landing", "id", tx.ID, "attempts", tx.Attempts, "to", tx.To.String())
+		t.markExpired(ctx, txStore, tx.ID, "external message expired without landing")
+		return
+	}
+
+	resubmitted, err := txStore.Resubmit(ctx, tx.ID)
+	if err != nil {
+		t.Logger.Errorw("failed to resubmit expired tx", "id", tx.ID, "err", err)
+		return
+	}
+
+	if t.requeue(ctx, txStore, resubmitted) {
+		t.metrics().broadcastRetries(txStore.accountAddress, retryReasonExpired, 1)
+		t.Logger.Infow("resubmitting transaction after its external message expired", "id", tx.ID, "attempts", resubmitted.Attempts)
+	}
+}
+
+// Advances the trace of every unconfirmed transaction by one step, marking the ones that
+// finalized and expiring the ones that did not finalize in time. Traces are polled concurrently
+// and independently, a trace that makes no progress does not hold back the others.
+func (t *Txm) checkUnconfirmed(ctx context.Context) {
+	allUnconfirmedTxs := t.AccountStore.GetAllUnconfirmed()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(t.Config.MaxConcurrentTracePolls, 1))
+	defer wg.Wait()
+
+	for accountAddress, unconfirmedTxs := range allUnconfirmedTxs {
+		txStore := t.AccountStore.GetTxStore(accountAddress)
+
+		for _, unconfirmedTx := range unconfirmedTxs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				t.pollUnconfirmed(ctx, txStore, unconfirmedTx)
+			}()
+		}
+	}
+}
+
+// pollUnconfirmed advances the trace of an unconfirmed transaction by one step, bounded by TracePollTimeout.
+// The trace is polled on a copy, subscribers may be reading the one in the store meanwhile.
+func (t *Txm) pollUnconfirmed(ctx context.Context, txStore *TxStore, unconfirmedTx *UnconfirmedTx) {
+	tx := unconfirmedTx.Tx
+
+	if uint64(time.Now().UnixMilli()) > unconfirmedTx.ExpirationMs { //nolint:gosec // ignoring G115 overflow conversion
+		t.Logger.Warnw("transaction trace did not finalize before expiration", "id", tx.ID, "LT", unconfirmedTx.LT)
+		t.markExpired(ctx, txStore, tx.ID, "trace did not finalize before expiration")
+		return
+	}
+
+	trace, nodeID, ok := txStore.GetTrace(tx.ID)
+	if !ok {
+		return
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, t.Config.TracePollTimeout)
+	defer cancel()
+	pollCtx, nodeID = t.stickyContext(pollCtx, nodeID)
+
+	finalized, err := trace.PollTrace(pollCtx, &t.Client)
+	if err != nil {
+		t.Logger.Warnw("failed to poll trace", "id", tx.ID, "LT", unconfirmedTx.LT, "error", err, "node", nodeID)
+		// the messages found before the failure are kept
+		txStore.UpdateTrace(tx.ID, trace, t.failover(ctx, nodeID))
+		return
+	}
+	txStore.UpdateTrace(tx.ID, trace, nodeID)
+	if !finalized {
+		return
+	}
+
+	// unlike TraceSucceeded, the classification also catches failed action phases and does not fail
+	// value credited to an account that is not deployed yet
+	failure := classifyFailure(&trace)
+	traceSucceeded := failure == nil
+	exitCode := tvm.ExitCodeSuccess
+	if failure != nil {
+		exitCode = failure.ExitCode
 	}
 
-	status, succeeded, exitCode, totalActionFees, found := txStore.GetTxState(lt)
+	if err := txStore.MarkFinalized(ctx, tx.ID, traceSucceeded, exitCode, failure); err != nil {
+		t.Logger.Errorw("failed to mark tx as finalized in TxStore", "id", tx.ID, "LT", unconfirmedTx.LT, "error", err)
+		return
+	}
+	t.metrics().finalized(txStore.accountAddress, tx, traceSucceeded, exitCode)
+
+	if tx.EstimatedFee.Nano().Sign() > 0 {
+		t.Logger.Infow("attached value spend", "id", tx.ID, "amount", tx.Amount.String(),
+			"estimatedFee", tx.EstimatedFee.String(), "traceFees", tlb.FromNanoTON(traceFees(tx)).String())
+	}
+
+	if traceSucceeded {
+		t.Logger.Infow("transaction confirmed", "LT", unconfirmedTx.LT, "exitCode", exitCode)
+	} else {
+		t.Logger.Warnw("transaction failed", "LT", unconfirmedTx.LT, "exitCode", exitCode, "failure", failure.String())
+	}
+}
+
+// GetTransactionStatus translates internal TON transaction state to chainlink common statuses, by the LT
+// of the wallet transaction that sent the transaction, see TxStore.GetTxState for batches.
+func (t *Txm) GetTransactionStatus(ctx context.Context, lt uint64) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error) {
+	status, succeeded, exitCode, totalActionFees, found := t.AccountStore.GetTxState(ctx, lt)
 	if !found {
-		return commontypes.Unknown, 0, totalActionFees, fmt.Errorf("transaction with id %d not found", lt)
+		return commontypes.Unknown, 0, totalActionFees, fmt.Errorf("transaction with lt %d not found", lt)
 	}
 
 	switch status {
@@ -344,3 +1193,41 @@ func (t *Txm) GetTransactionStatus(ctx context.Context, lt uint64) (commontypes.
 		return commontypes.Unknown, 0, totalActionFees, fmt.Errorf("unexpected transaction state for lt %d: %d", lt, status)
 	}
 }
+
+// GetTransactionStatusByID translates the lifecycle state of a transaction, looked up by the ID
+// returned from Enqueue, to chainlink common statuses.
+func (t *Txm) GetTransactionStatusByID(ctx context.Context, id string) (commontypes.TransactionStatus, tvm.ExitCode, tlb.Coins, error) {
+	state, finalized, err := t.AccountStore.GetTxStateByID(ctx, id)
+	if err != nil {
+		return commontypes.Unknown, 0, tlb.ZeroCoins, fmt.Errorf("transaction with id %s not found: %w", id, err)
+	}
+
+	switch state {
+	case TxStateEnqueued, TxStateBroadcasting, TxStateUnconfirmed, TxStateErrored, TxStateExpired:
+		return transactionStatus(state, false), 0, tlb.ZeroCoins, nil
+	case TxStateFinalized:
+		return transactionStatus(state, finalized.TraceSucceeded), finalized.ExitCode, finalized.TotalActionFees, nil
+	default:
+		return commontypes.Unknown, 0, tlb.ZeroCoins, fmt.Errorf("unexpected transaction state for id %s: %s", id, state)
+	}
+}
+
+// GetTransactionFailure returns where and why the trace of a transaction failed, by the ID returned
+// from Enqueue. It returns nil while the transaction is not finalized and once its trace succeeded.
+func (t *Txm) GetTransactionFailure(ctx context.Context, id string) (*Failure, error) {
+	state, finalized, err := t.AccountStore.GetTxStateByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("transaction with id %s not found: %w", id, err)
+	}
+	if state != TxStateFinalized {
+		return nil, nil
+	}
+	return finalized.Failure, nil
+}
+
+// Subscribe returns a channel receiving the status transitions of a transaction, by the ID returned
+// from Enqueue, starting with its current status. The channel is closed once the transaction reached
+// a terminal state, when the returned func is called, or when the Txm is closed.
+func (t *Txm) Subscribe(ctx context.Context, id string) (<-chan TxStatusUpdate, func(), error) {
+	return t.AccountStore.Subscribe(ctx, id)
+}
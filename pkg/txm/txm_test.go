@@ -0,0 +1,302 @@
+package txm
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+
+	tonconfig "github.com/smartcontractkit/chainlink-ton/pkg/ton/config"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+// fixedBalances reports the same balance for every sender.
+type fixedBalances tlb.Coins
+
+func (b fixedBalances) Balance(string) (tlb.Coins, bool) { return tlb.Coins(b), true }
+
+func TestTxm_EnqueueIdempotencyKey(t *testing.T) {
+	txm := &Txm{
+		Logger:       logger.Test(t),
+		Balances:     fixedBalances(tlb.ZeroCoins),
+		AccountStore: NewAccountStore(),
+		senders:      newSenderPool(nil, 1, SelectRoundRobin),
+	}
+	tx := testTx("key")
+	require.NoError(t, txm.AccountStore.AddEnqueued(t.Context(), tx))
+
+	// a known key is returned before picking a sender, sizing the amount or checking the balance
+	id, err := txm.Enqueue(Request{IdempotencyKey: tx.ID, AutoAmount: true, From: &tx.To})
+	require.NoError(t, err)
+	require.Equal(t, tx.ID, id)
+
+	_, err = txm.Enqueue(Request{IdempotencyKey: "other", From: &tx.To})
+	require.ErrorContains(t, err, "no sender wallet")
+}
+
+func TestTxm_InflightCount(t *testing.T) {
+	var senders []Sender
+	for seed := range byte(2) {
+		key := ed25519.NewKeyFromSeed(append(make([]byte, ed25519.SeedSize-1), seed))
+		w, err := wallet.FromPrivateKey(nil, key, tonconfig.WalletVersion)
+		require.NoError(t, err)
+		senders = append(senders, Sender{Wallet: w})
+	}
+	txm := &Txm{AccountStore: NewAccountStore(), senders: newSenderPool(senders, 4, SelectRoundRobin)}
+
+	depths, inflight := txm.InflightCount()
+	require.Equal(t, QueueDepths{PriorityHigh: 0, PriorityNormal: 0, PriorityLow: 0}, depths)
+	require.Zero(t, depths.Total())
+	require.Zero(t, inflight)
+
+	// lane depths add up across the senders of the pool
+	queue := map[int][]Priority{0: {PriorityHigh, PriorityLow, PriorityLow}, 1: {PriorityLow}}
+	for i, priorities := range queue {
+		for j, p := range priorities {
+			require.NoError(t, txm.senders.senders[i].lanes.push(&Tx{ID: fmt.Sprint(i, "-", j), Priority: p}))
+		}
+	}
+	unconfirmed := testTx("unconfirmed")
+	txm.AccountStore.GetTxStore(senders[1].Wallet.Address().String()).RestoreUnconfirmed(10, 0, unconfirmed)
+
+	depths, inflight = txm.InflightCount()
+	require.Equal(t, QueueDepths{PriorityHigh: 1, PriorityNormal: 0, PriorityLow: 3}, depths)
+	require.Equal(t, 4, depths.Total())
+	require.Equal(t, 1, inflight)
+}
+
+// persistentORM stands in for an ORM that keeps transactions across restarts.
+type persistentORM struct{ nopORM }
+
+func TestTxm_ReportUnfinished(t *testing.T) {
+	testCases := []struct {
+		name    string
+		store   *AccountStore
+		level   zapcore.Level
+		message string
+	}{
+		{name: "in memory", store: NewAccountStore(), level: zapcore.ErrorLevel, message: "not persisted and lost"},
+		{name: "no ORM", store: NewPersistentAccountStore(nopORM{}), level: zapcore.ErrorLevel, message: "not persisted and lost"},
+		{name: "persisted", store: NewPersistentAccountStore(persistentORM{}), level: zapcore.WarnLevel, message: "resumed on next start"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lggr, logs := logger.TestObserved(t, zapcore.WarnLevel)
+			txm := &Txm{Logger: lggr, AccountStore: tc.store}
+			tx := testTx("unfinished")
+			require.NoError(t, txm.AccountStore.AddEnqueued(t.Context(), tx))
+
+			txm.reportUnfinished()
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			require.Equal(t, tc.level, entries[0].Level)
+			require.Contains(t, entries[0].Message, tc.message)
+			require.Equal(t, tx.ID, entries[0].ContextMap()["id"])
+		})
+	}
+}
+
+func TestTxm_EnqueueRetry(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	w, err := wallet.FromPrivateKey(nil, key, tonconfig.WalletVersion)
+	require.NoError(t, err)
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		prior    func(s *TxStore, o *memoryORM) // records the transaction enqueued with the key before
+		enqueued bool                           // whether a transaction is enqueued with the key, anew or as a retry
+	}{
+		{name: "new key", prior: func(*TxStore, *memoryORM) {}, enqueued: true},
+		{
+			name:  "pending",
+			prior: func(s *TxStore, _ *memoryORM) { s.broadcastingTxs["report"] = testTx("report") },
+		},
+		{
+			name: "succeeded",
+			prior: func(s *TxStore, _ *memoryORM) {
+				s.finalizedTxs["report"] = &FinalizedTx{TraceSucceeded: true, FinalizedAt: now}
+				s.ltToIDs[10] = []string{"report"}
+			},
+		},
+		{
+			name: "trace failed",
+			prior: func(s *TxStore, _ *memoryORM) {
+				s.finalizedTxs["report"] = &FinalizedTx{ExitCode: tvm.ExitCodeOutOfGasError, FinalizedAt: now}
+				s.ltToIDs[10] = []string{"other", "report"}
+			},
+			enqueued: true,
+		},
+		{
+			name: "errored",
+			prior: func(s *TxStore, _ *memoryORM) {
+				s.erroredTxs["report"] = &ErroredTx{Reason: "failed to build message", ErroredAt: now}
+			},
+			enqueued: true,
+		},
+		{
+			name:     "expired",
+			prior:    func(s *TxStore, _ *memoryORM) { s.expiredTxs["report"] = &ErroredTx{Reason: "expired", ErroredAt: now} },
+			enqueued: true,
+		},
+		{
+			name: "pruned after its trace failed",
+			prior: func(s *TxStore, o *memoryORM) {
+				o.keepPruned(s.accountAddress, []*PersistedTx{{Tx: &Tx{ID: "report"}, State: TxStateFinalized, LT: 10}}, now, 0, 0)
+			},
+			enqueued: true,
+		},
+		{
+			name: "pruned after it succeeded",
+			prior: func(s *TxStore, o *memoryORM) {
+				o.keepPruned(s.accountAddress, []*PersistedTx{{Tx: &Tx{ID: "report"}, State: TxStateFinalized, TraceSucceeded: true, LT: 10}}, now, 0, 0)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			orm := newMemoryORM()
+			txm := &Txm{
+				Logger:       logger.Test(t),
+				Config:       DefaultConfigSet,
+				AccountStore: NewPersistentAccountStore(orm),
+				senders:      newSenderPool([]Sender{{Wallet: w}}, 4, SelectRoundRobin),
+			}
+			s := txm.AccountStore.GetTxStore(w.Address().String())
+			tc.prior(s, orm)
+			before, _, _ := txm.AccountStore.GetTxStateByID(t.Context(), "report")
+
+			id, err := txm.Enqueue(Request{IdempotencyKey: "report", ContractAddress: *testAddress(2), Amount: tlb.MustFromTON("0.1")})
+			require.NoError(t, err)
+			require.Equal(t, "report", id)
+
+			state, finalized, err := txm.AccountStore.GetTxStateByID(t.Context(), "report")
+			require.NoError(t, err)
+			if !tc.enqueued {
+				require.Equal(t, before, state)
+				require.Zero(t, txm.senders.senders[0].lanes.total())
+				return
+			}
+			require.Equal(t, TxStateEnqueued, state)
+			require.Nil(t, finalized)
+			require.Equal(t, 1, txm.senders.senders[0].lanes.total())
+			// the wallet transaction of the failed attempt no longer leads to the transaction
+			for _, ids := range s.ltToIDs {
+				require.NotContains(t, ids, "report")
+			}
+			require.NotContains(t, orm.byID, "report")
+
+			// the retry is pending, enqueuing the key again does not send a third time
+			_, err = txm.Enqueue(Request{IdempotencyKey: "report", ContractAddress: *testAddress(2), Amount: tlb.MustFromTON("0.1")})
+			require.NoError(t, err)
+			require.Equal(t, 1, txm.senders.senders[0].lanes.total())
+		})
+	}
+}
+
+// walletTransactions serves the transactions of a wallet, the other client methods are not implemented.
+type walletTransactions struct {
+	ton.APIClientWrapped
+	txs    []*tlb.Transaction // oldest first
+	listed []uint64           // LT every ListTransactions call started from
+}
+
+func (c *walletTransactions) CurrentMasterchainInfo(context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{Workchain: -1}, nil
+}
+
+func (c *walletTransactions) WaitForBlock(uint32) ton.APIClientWrapped { return c }
+
+func (c *walletTransactions) GetAccount(context.Context, *ton.BlockIDExt, *address.Address) (*tlb.Account, error) {
+	if len(c.txs) == 0 {
+		return &tlb.Account{}, nil
+	}
+	last := c.txs[len(c.txs)-1]
+	return &tlb.Account{IsActive: true, LastTxLT: last.LT, LastTxHash: last.Hash}, nil
+}
+
+func (c *walletTransactions) ListTransactions(_ context.Context, _ *address.Address, num uint32, lt uint64, _ []byte) ([]*tlb.Transaction, error) {
+	c.listed = append(c.listed, lt)
+	var page []*tlb.Transaction
+	for _, tx := range c.txs {
+		if tx.LT <= lt {
+			page = append(page, tx)
+		}
+	}
+	if len(page) == 0 {
+		return nil, ton.ErrNoTransactionsWereFound
+	}
+	return page[max(0, len(page)-int(num)):], nil
+}
+
+// externalIn returns the wallet transaction at time now, processing an external message with body.
+func externalIn(lt uint64, now uint32, body *cell.Cell) *tlb.Transaction {
+	tx := &tlb.Transaction{LT: lt, Hash: []byte{byte(lt)}, PrevTxLT: lt - 1, PrevTxHash: []byte{byte(lt - 1)}, Now: now}
+	tx.IO.In = &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: &tlb.ExternalMessage{Body: body}}
+	return tx
+}
+
+func TestTxm_FindWalletTransaction(t *testing.T) {
+	const walletTxs, since = 40, 1_000 // wallet transaction i, with LT i+1, was processed at since+i
+	body := cell.BeginCell().MustStoreUInt(7, 8).EndCell()
+
+	testCases := []struct {
+		name      string
+		landed    int // wallet transaction that processed the message, -1 if none
+		createdAt int // wallet transaction processed when the message was created
+		maxScan   int
+		found     bool
+		pages     int // wallet transaction pages listed
+	}{
+		{name: "landed recently", landed: 35, createdAt: 35, maxScan: findTxMaxScan, found: true, pages: 1},
+		{name: "landed beyond the scan limit", landed: 5, createdAt: 5, maxScan: 10, pages: 1},
+		{name: "landed long ago", landed: 5, createdAt: 5, found: true, pages: 3},
+		{name: "not landed", landed: -1, createdAt: 30, pages: 1},
+		{name: "not landed since the wallet was deployed", landed: -1, pages: 3},
+		{name: "landed before the message was created", landed: 5, createdAt: 6, pages: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &walletTransactions{}
+			for i := range walletTxs {
+				other := cell.BeginCell().MustStoreUInt(uint64(i), 16).EndCell() //nolint:gosec // small test index
+				if i == tc.landed {
+					other = body
+				}
+				client.txs = append(client.txs, externalIn(uint64(i+1), uint32(since+i), other)) //nolint:gosec // small test index
+			}
+			txm := &Txm{Client: tracetracking.SignedAPIClient{Client: client}}
+			tx := testTx("tx-1")
+			tx.InMsgHash = body.Hash()
+			tx.MsgExpiration = time.Unix(int64(since+tc.createdAt), 0).Add(time.Duration(tonconfig.WalletVersion.MessageTTL) * time.Second)
+
+			walletTx, err := txm.findWalletTransaction(t.Context(), tx, tc.maxScan)
+			require.NoError(t, err)
+			if tc.found {
+				require.NotNil(t, walletTx)
+				require.Equal(t, uint64(tc.landed+1), walletTx.LT) //nolint:gosec // small test index
+			} else {
+				require.Nil(t, walletTx)
+			}
+			require.Len(t, client.listed, tc.pages)
+		})
+	}
+
+	// an inactive wallet did not process any message
+	txm := &Txm{Client: tracetracking.SignedAPIClient{Client: &walletTransactions{}}}
+	walletTx, err := txm.findWalletTransaction(t.Context(), testTx("tx-1"), 0)
+	require.NoError(t, err)
+	require.Nil(t, walletTx)
+}
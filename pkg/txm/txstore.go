@@ -1,9 +1,14 @@
 package txm
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"slices"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/xssnick/tonutils-go/tlb"
 
@@ -13,6 +18,8 @@ import (
 	"golang.org/x/exp/maps"
 )
 
+var ErrTxAlreadyExists = errors.New("transaction already exists")
+
 type UnconfirmedTx struct {
 	LT           uint64
 	ExpirationMs uint64
@@ -23,65 +30,354 @@ type FinalizedTx struct {
 	ReceivedMessage tracetracking.ReceivedMessage
 	ExitCode        tvm.ExitCode
 	TraceSucceeded  bool
+	TotalActionFees tlb.Coins
+	TraceFees       tlb.Coins // fees paid out of the attached value along the trace
+	Failure         *Failure  // where and why the trace failed, nil when it succeeded
+	FinalizedAt     time.Time // when the trace finalized, retention is counted from it
 }
 
-// TxStore tracks broadcast & unconfirmed txs per account address per chain id
-type TxStore struct {
-	lock sync.RWMutex
+type ErroredTx struct {
+	Tx        *Tx
+	Reason    string
+	ErroredAt time.Time // when the transaction errored or expired, retention is counted from it
+}
 
-	unconfirmedTxs map[uint64]*UnconfirmedTx // broadcasted transactions awaiting trace finalization
-	finalizedTxs   map[uint64]*FinalizedTx   // finalized and errored transactions held onto for status
+// TxStore tracks enqueued, broadcast & unconfirmed txs per account address per chain id.
+// Every state transition is written through to the ORM before the in-memory state is updated,
+// the in-memory maps act as a cache of the transactions known to this process.
+type TxStore struct {
+	lock     sync.RWMutex
+	orm      ORM
+	notifier *statusNotifier // Optional: receives the transitions of the transactions
+
+	accountAddress  string
+	enqueuedTxs     map[string]*Tx            // transactions waiting in the broadcast queue, by ID
+	broadcastingTxs map[string]*Tx            // transactions being sent, by ID
+	unconfirmedTxs  map[string]*UnconfirmedTx // broadcasted transactions awaiting trace finalization, by ID
+	finalizedTxs    map[string]*FinalizedTx   // finalized transactions held onto for status, by ID
+	erroredTxs      map[string]*ErroredTx     // transactions that could not be broadcast, by ID
+	expiredTxs      map[string]*ErroredTx     // transactions that did not land or finalize in time, by ID
+	ltToIDs         map[uint64][]string       // LT of the wallet transaction to the IDs of the transactions it sent, several for a batch
 }
 
-func NewTxStore() *TxStore {
+func NewTxStore(accountAddress string, orm ORM) *TxStore {
 	return &TxStore{
-		unconfirmedTxs: map[uint64]*UnconfirmedTx{},
-		finalizedTxs:   map[uint64]*FinalizedTx{},
+		orm:             orm,
+		accountAddress:  accountAddress,
+		enqueuedTxs:     map[string]*Tx{},
+		broadcastingTxs: map[string]*Tx{},
+		unconfirmedTxs:  map[string]*UnconfirmedTx{},
+		finalizedTxs:    map[string]*FinalizedTx{},
+		erroredTxs:      map[string]*ErroredTx{},
+		expiredTxs:      map[string]*ErroredTx{},
+		ltToIDs:         map[uint64][]string{},
+	}
+}
+
+// AddEnqueued persists a transaction accepted by Enqueue.
+// Returns ErrTxAlreadyExists if a transaction with the same ID is already known.
+func (s *TxStore) AddEnqueued(ctx context.Context, tx *Tx) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, found := s.getTxState(tx.ID); found {
+		return fmt.Errorf("%w: %s", ErrTxAlreadyExists, tx.ID)
+	}
+
+	if err := s.orm.InsertTx(ctx, tx); err != nil {
+		return err
+	}
+
+	s.enqueuedTxs[tx.ID] = tx
+	s.notify(tx.ID)
+	return nil
+}
+
+// AddRetry persists a transaction accepted by Enqueue as the retry of a transaction with the same ID
+// that did not succeed, see AccountStore.AddEnqueued. The transaction replaced is dropped from the
+// store by forget beforehand.
+func (s *TxStore) AddRetry(ctx context.Context, tx *Tx) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.orm.RetryTx(ctx, tx); err != nil {
+		return err
+	}
+
+	s.enqueuedTxs[tx.ID] = tx
+	s.notify(tx.ID)
+	return nil
+}
+
+// forget drops a transaction that reached a terminal state from memory, it is only known to the ORM afterwards.
+func (s *TxStore) forget(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.finalizedTxs, id)
+	delete(s.erroredTxs, id)
+	delete(s.expiredTxs, id)
+	for lt, ids := range s.ltToIDs {
+		if i := slices.Index(ids, id); i != -1 {
+			if ids = slices.Delete(ids, i, i+1); len(ids) == 0 {
+				delete(s.ltToIDs, lt)
+			} else {
+				s.ltToIDs[lt] = ids
+			}
+		}
+	}
+}
+
+// MarkBroadcasting records that the transaction left the queue and is being sent as the
+// signed external message ext, which the wallet accepts until msgExpiration, through the lite server nodeID.
+func (s *TxStore) MarkBroadcasting(ctx context.Context, id string, ext *tlb.ExternalMessage, msgExpiration time.Time, nodeID uint32) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, exists := s.enqueuedTxs[id]
+	if !exists {
+		return fmt.Errorf("no such enqueued tx: %s", id)
+	}
+
+	inMsgHash := ext.Body.Hash()
+	if err := s.orm.MarkBroadcasting(ctx, id, inMsgHash, msgExpiration); err != nil {
+		return err
+	}
+
+	tx.InMsgHash = inMsgHash
+	tx.MsgExpiration = msgExpiration
+	tx.BroadcastAt = time.Now()
+	tx.ExternalMsg = ext
+	tx.LastSentAt = tx.BroadcastAt
+	tx.Rebroadcasts = 0
+	tx.NodeID = nodeID
+	delete(s.enqueuedTxs, id)
+	s.broadcastingTxs[id] = tx
+	s.notify(id)
+	return nil
+}
+
+// MarkRebroadcast records that the external message of a broadcasting transaction was sent again.
+func (s *TxStore) MarkRebroadcast(ctx context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, exists := s.broadcastingTxs[id]
+	if !exists {
+		return fmt.Errorf("no such broadcasting tx: %s", id)
+	}
+
+	if err := s.orm.MarkRebroadcast(ctx, id, tx.Rebroadcasts+1); err != nil {
+		return err
+	}
+
+	tx.LastSentAt = time.Now()
+	tx.Rebroadcasts++
+	return nil
+}
+
+// UpdateNodeID records the lite server the external message of a broadcasting transaction is looked up on.
+func (s *TxStore) UpdateNodeID(id string, nodeID uint32) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if tx, exists := s.broadcastingTxs[id]; exists {
+		tx.NodeID = nodeID
+	}
+}
+
+// Resubmit moves a transaction whose external message expired back to the queue,
+// to be rebuilt with a fresh query ID.
+func (s *TxStore) Resubmit(ctx context.Context, id string) (*Tx, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, exists := s.broadcastingTxs[id]
+	if !exists {
+		return nil, fmt.Errorf("no such broadcasting tx: %s", id)
+	}
+
+	if err := s.orm.MarkResubmitted(ctx, id, tx.Attempts+1); err != nil {
+		return nil, err
+	}
+
+	tx.Attempts++
+	tx.InMsgHash = nil
+	tx.MsgExpiration = time.Time{}
+	delete(s.broadcastingTxs, id)
+	s.enqueuedTxs[id] = tx
+	s.notify(id)
+	return tx, nil
+}
+
+// MarkExpired records that a broadcasting transaction never landed, or that an unconfirmed
+// transaction did not finalize, before expiring.
+func (s *TxStore) MarkExpired(ctx context.Context, id string, reason string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, exists := s.broadcastingTxs[id]
+	if !exists {
+		unconfirmedTx, exists := s.unconfirmedTxs[id]
+		if !exists {
+			return fmt.Errorf("no such broadcasting or unconfirmed tx: %s", id)
+		}
+		tx = unconfirmedTx.Tx
+	}
+
+	if err := s.orm.MarkExpired(ctx, id, reason); err != nil {
+		return err
 	}
+
+	delete(s.broadcastingTxs, id)
+	delete(s.unconfirmedTxs, id)
+	s.expiredTxs[id] = &ErroredTx{Tx: tx, Reason: reason, ErroredAt: time.Now()}
+	s.notify(id)
+	return nil
 }
 
-// AddUnconfirmed adds a new unconfirmed transaction by lamport time.
-func (s *TxStore) AddUnconfirmed(lt uint64, expirationMs uint64, tx *Tx) error {
+// MarkErrored records that a transaction could not be broadcast.
+func (s *TxStore) MarkErrored(ctx context.Context, id string, reason string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	if _, exists := s.unconfirmedTxs[lt]; exists {
-		return fmt.Errorf("tx already exists: %d", lt)
+	tx, exists := s.enqueuedTxs[id]
+	if !exists {
+		// tx is not cached when it is errored while being resumed after a restart
+		tx = s.broadcastingTxs[id]
+	}
+
+	if err := s.orm.MarkErrored(ctx, id, reason); err != nil {
+		return err
 	}
-	if _, exists := s.finalizedTxs[lt]; exists {
-		return fmt.Errorf("tx already exists: %d", lt)
+
+	delete(s.enqueuedTxs, id)
+	delete(s.broadcastingTxs, id)
+	s.erroredTxs[id] = &ErroredTx{Tx: tx, Reason: reason, ErroredAt: time.Now()}
+	s.notify(id)
+	return nil
+}
+
+// AddUnconfirmed adds a new unconfirmed transaction by the lamport time of its outgoing message,
+// included on-chain by the wallet transaction receivedMessage starts with.
+func (s *TxStore) AddUnconfirmed(ctx context.Context, lt uint64, expirationMs uint64, tx *Tx, receivedMessage tracetracking.ReceivedMessage) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, exists := s.unconfirmedTxs[tx.ID]; exists {
+		return fmt.Errorf("tx already unconfirmed: %s", tx.ID)
 	}
 
-	s.unconfirmedTxs[lt] = &UnconfirmedTx{
+	walletLT := receivedMessage.LamportTime
+	if err := s.orm.MarkUnconfirmed(ctx, tx.ID, lt, walletLT, receivedMessage.TxHash); err != nil {
+		return err
+	}
+
+	tx.ReceivedMessage = receivedMessage
+	delete(s.enqueuedTxs, tx.ID)
+	delete(s.broadcastingTxs, tx.ID)
+	s.ltToIDs[walletLT] = append(s.ltToIDs[walletLT], tx.ID)
+	s.unconfirmedTxs[tx.ID] = &UnconfirmedTx{
 		LT:           lt,
 		ExpirationMs: expirationMs,
 		Tx:           tx,
 	}
+	s.notify(tx.ID)
 
 	return nil
 }
 
-// Confirm marks a transaction as confirmed and removes it by LT.
-func (s *TxStore) MarkFinalized(lt uint64, success bool, exitCode tvm.ExitCode) error {
+// RestoreUnconfirmed re-adds an unconfirmed transaction loaded from the ORM without persisting it again.
+func (s *TxStore) RestoreUnconfirmed(lt uint64, expirationMs uint64, tx *Tx) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	walletLT := tx.ReceivedMessage.LamportTime
+	s.ltToIDs[walletLT] = append(s.ltToIDs[walletLT], tx.ID)
+	s.unconfirmedTxs[tx.ID] = &UnconfirmedTx{
+		LT:           lt,
+		ExpirationMs: expirationMs,
+		Tx:           tx,
+	}
+}
+
+// RestoreBroadcasting re-adds a broadcasting transaction loaded from the ORM without persisting it again.
+func (s *TxStore) RestoreBroadcasting(tx *Tx) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.broadcastingTxs[tx.ID] = tx
+}
+
+// RestoreEnqueued re-adds an enqueued transaction loaded from the ORM without persisting it again.
+func (s *TxStore) RestoreEnqueued(tx *Tx) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.enqueuedTxs[tx.ID] = tx
+}
+
+// GetTrace returns a copy of the trace of an unconfirmed transaction, to be advanced outside of the lock
+// and saved back with UpdateTrace, along with the lite server it is polled on.
+func (s *TxStore) GetTrace(id string) (tracetracking.ReceivedMessage, uint32, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	unconfirmedTx, exists := s.unconfirmedTxs[id]
+	if !exists {
+		return tracetracking.ReceivedMessage{}, 0, false
+	}
+	return unconfirmedTx.Tx.ReceivedMessage.Clone(), unconfirmedTx.Tx.NodeID, true
+}
+
+// UpdateTrace saves the trace of an unconfirmed transaction advanced from a copy returned by GetTrace.
+// Stored traces are replaced rather than modified, the status updates already published keep
+// referencing the previous one.
+func (s *TxStore) UpdateTrace(id string, receivedMessage tracetracking.ReceivedMessage, nodeID uint32) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	unconfirmedTx, exists := s.unconfirmedTxs[lt]
+	if unconfirmedTx, exists := s.unconfirmedTxs[id]; exists {
+		unconfirmedTx.Tx.ReceivedMessage = receivedMessage
+		unconfirmedTx.Tx.NodeID = nodeID
+	}
+}
+
+// MarkFinalized records the outcome of the trace of an unconfirmed transaction.
+func (s *TxStore) MarkFinalized(ctx context.Context, id string, success bool, exitCode tvm.ExitCode, failure *Failure) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	unconfirmedTx, exists := s.unconfirmedTxs[id]
 	if !exists {
-		return fmt.Errorf("no such unconfirmed tx: %d", lt)
+		return fmt.Errorf("no such unconfirmed tx: %s", id)
 	}
-	if _, exists := s.finalizedTxs[lt]; exists {
-		return fmt.Errorf("tx already finalized: %d", lt)
+
+	receivedMessage := unconfirmedTx.Tx.ReceivedMessage
+	totalActionFees := tlb.ZeroCoins
+	if receivedMessage.TotalActionFees != nil {
+		totalActionFees = tlb.MustFromNano(receivedMessage.TotalActionFees, 9)
 	}
 
-	delete(s.unconfirmedTxs, lt)
+	fees := tlb.FromNanoTON(traceFees(unconfirmedTx.Tx))
+
+	if err := s.orm.MarkFinalized(ctx, id, success, exitCode, totalActionFees, fees, failure); err != nil {
+		return err
+	}
+
+	delete(s.unconfirmedTxs, id)
 
 	// move transaction to finalized map
-	s.finalizedTxs[lt] = &FinalizedTx{
-		ReceivedMessage: unconfirmedTx.Tx.ReceivedMessage,
+	s.finalizedTxs[id] = &FinalizedTx{
+		ReceivedMessage: receivedMessage,
 		ExitCode:        exitCode,
 		TraceSucceeded:  success,
+		TotalActionFees: totalActionFees,
+		TraceFees:       fees,
+		Failure:         failure,
+		FinalizedAt:     time.Now(),
 	}
+	s.notify(id)
 
 	return nil
 }
@@ -100,46 +396,310 @@ func (s *TxStore) GetUnconfirmed() []*UnconfirmedTx {
 	return unconfirmed
 }
 
+// GetBroadcasting returns all transactions whose external message was sent but not yet seen on-chain.
+func (s *TxStore) GetBroadcasting() []*Tx {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return maps.Values(s.broadcastingTxs)
+}
+
+// PendingAmount returns the number of transactions not included on-chain yet along with the sum of
+// their amounts, which the balance of the wallet does not account for yet.
+func (s *TxStore) PendingAmount() (int, *big.Int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	total := big.NewInt(0)
+	for _, tx := range s.enqueuedTxs {
+		total.Add(total, tx.Amount.Nano())
+	}
+	for _, tx := range s.broadcastingTxs {
+		total.Add(total, tx.Amount.Nano())
+	}
+	return len(s.enqueuedTxs) + len(s.broadcastingTxs), total
+}
+
+// Prune drops the finalized, errored and expired transactions that completed before the retention
+// period, then the oldest ones beyond maxRetained, and returns how many were dropped. A zero retention
+// or maxRetained disables the respective limit. Pruned transactions are only known to the ORM afterwards,
+// an ORM that does not persist them keeps their outcome instead, within the same limits.
+func (s *TxStore) Prune(now time.Time, retention time.Duration, maxRetained uint) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	type completedTx struct {
+		id          string
+		completedAt time.Time
+	}
+	completed := make([]completedTx, 0, s.retainedCount())
+	for id, tx := range s.finalizedTxs {
+		completed = append(completed, completedTx{id, tx.FinalizedAt})
+	}
+	for _, txs := range []map[string]*ErroredTx{s.erroredTxs, s.expiredTxs} {
+		for id, tx := range txs {
+			completed = append(completed, completedTx{id, tx.ErroredAt})
+		}
+	}
+
+	// oldest first
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].completedAt.Before(completed[j].completedAt)
+	})
+
+	pruneCount := 0
+	if retention > 0 {
+		cutoff := now.Add(-retention)
+		for pruneCount < len(completed) && completed[pruneCount].completedAt.Before(cutoff) {
+			pruneCount++
+		}
+	}
+	if maxRetained > 0 && uint(len(completed)-pruneCount) > maxRetained {
+		pruneCount = len(completed) - int(maxRetained) //nolint:gosec // bounded by len(completed)
+	}
+	if pruneCount == 0 {
+		return 0
+	}
+
+	pruned := make(map[string]*PersistedTx, pruneCount)
+	prunedTxs := make([]*PersistedTx, 0, pruneCount) // oldest first
+	for _, tx := range completed[:pruneCount] {
+		pruned[tx.id] = s.prunedTx(tx.id)
+		prunedTxs = append(prunedTxs, pruned[tx.id])
+		delete(s.finalizedTxs, tx.id)
+		delete(s.erroredTxs, tx.id)
+		delete(s.expiredTxs, tx.id)
+	}
+	for lt, ids := range s.ltToIDs {
+		ids = slices.DeleteFunc(ids, func(id string) bool {
+			tx, exists := pruned[id]
+			if exists {
+				tx.LT = lt
+			}
+			return exists
+		})
+		if len(ids) == 0 {
+			delete(s.ltToIDs, lt)
+		} else {
+			s.ltToIDs[lt] = ids
+		}
+	}
+	if keeper, ok := s.orm.(prunedTxKeeper); ok {
+		keeper.keepPruned(s.accountAddress, prunedTxs, now, retention, maxRetained)
+	}
+	return pruneCount
+}
+
+// prunedTx returns the outcome of a completed transaction as the ORM would have persisted it, the lock must be held.
+func (s *TxStore) prunedTx(id string) *PersistedTx {
+	tx := &PersistedTx{Tx: &Tx{ID: id}, TotalActionFees: tlb.ZeroCoins, TraceFees: tlb.ZeroCoins}
+	if finalized, exists := s.finalizedTxs[id]; exists {
+		tx.State = TxStateFinalized
+		tx.ExitCode, tx.TraceSucceeded, tx.Failure = finalized.ExitCode, finalized.TraceSucceeded, finalized.Failure
+		tx.TotalActionFees, tx.TraceFees = finalized.TotalActionFees, finalized.TraceFees
+	}
+	if errored, exists := s.erroredTxs[id]; exists {
+		tx.State, tx.Error = TxStateErrored, errored.Reason
+	}
+	if expired, exists := s.expiredTxs[id]; exists {
+		tx.State, tx.Error = TxStateExpired, expired.Reason
+	}
+	return tx
+}
+
+// RetainedCount returns the number of finalized, errored and expired transactions kept in memory.
+func (s *TxStore) RetainedCount() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.retainedCount()
+}
+
+func (s *TxStore) retainedCount() int {
+	return len(s.finalizedTxs) + len(s.erroredTxs) + len(s.expiredTxs)
+}
+
+// GetUnfinished returns the state of every transaction that did not reach a terminal state, by ID.
+func (s *TxStore) GetUnfinished() map[string]TxState {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	unfinished := make(map[string]TxState, len(s.enqueuedTxs)+len(s.broadcastingTxs)+len(s.unconfirmedTxs))
+	for id := range s.enqueuedTxs {
+		unfinished[id] = TxStateEnqueued
+	}
+	for id := range s.broadcastingTxs {
+		unfinished[id] = TxStateBroadcasting
+	}
+	for id := range s.unconfirmedTxs {
+		unfinished[id] = TxStateUnconfirmed
+	}
+	return unfinished
+}
+
 func (s *TxStore) InflightCount() int {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	return len(s.unconfirmedTxs)
 }
 
-// GetTxState returns the message status, whether the transaction trace succeeded,
-// the exit code, and whether the transaction was found at all.
+// GetTxState returns the message status of the wallet transaction with the given LT, whether the traces
+// of the transactions it sent succeeded, the exit code, and whether the wallet transaction was found at all.
+// A wallet transaction sending a batch is cascading until the trace of every transaction of the batch
+// finalized, and only succeeded when all of them succeeded.
 // - MsgStatus indicates the lifecycle state of the message.
 // - isSucceeded indicates whether the transaction trace execution succeeded.
-// - ExitCode contains the VM result code.
-// - Coins represents the Total Action Fees associated with the transaction.
-// - found tells whether the transaction was present in memory.
-func (s *TxStore) GetTxState(lt uint64) (tracetracking.MsgStatus, bool, tvm.ExitCode, tlb.Coins, bool) {
+// - ExitCode contains the VM result code, of the first failed trace of a batch.
+// - Coins represents the Total Action Fees of the wallet transaction.
+// - found tells whether the transaction was present in memory or in the ORM.
+func (s *TxStore) GetTxState(ctx context.Context, lt uint64) (tracetracking.MsgStatus, bool, tvm.ExitCode, tlb.Coins, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ids := s.ltToIDs[lt]
+	outcomes := make([]txOutcome, 0, len(ids))
+	for _, id := range ids {
+		state, _ := s.getTxState(id)
+		outcome := txOutcome{state: state}
+		if tx, exists := s.finalizedTxs[id]; exists {
+			outcome.succeeded, outcome.exitCode, outcome.totalActionFees = tx.TraceSucceeded, tx.ExitCode, tx.TotalActionFees
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	if len(outcomes) == 0 {
+		// Transactions may have been finalized or expired before a restart
+		txs, err := s.orm.GetTxsByLT(ctx, s.accountAddress, lt)
+		if err != nil {
+			return tracetracking.NotFound, false, 0, tlb.ZeroCoins, false
+		}
+		for _, tx := range txs {
+			outcomes = append(outcomes, txOutcome{state: tx.State, succeeded: tx.TraceSucceeded, exitCode: tx.ExitCode, totalActionFees: tx.TotalActionFees})
+		}
+	}
+	return walletTxStatus(outcomes)
+}
+
+// txOutcome is the state of a transaction sent by a wallet transaction, with the outcome of its trace once finalized.
+type txOutcome struct {
+	state           TxState
+	succeeded       bool
+	exitCode        tvm.ExitCode
+	totalActionFees tlb.Coins
+}
+
+// walletTxStatus combines the outcomes of the transactions sent by a wallet transaction, see TxStore.GetTxState.
+func walletTxStatus(outcomes []txOutcome) (tracetracking.MsgStatus, bool, tvm.ExitCode, tlb.Coins, bool) {
+	if len(outcomes) == 0 {
+		// Transaction not found in any store
+		return tracetracking.NotFound, false, 0, tlb.ZeroCoins, false
+	}
+
+	succeeded, exitCode, totalActionFees := true, tvm.ExitCode(0), tlb.ZeroCoins
+	for i, outcome := range outcomes {
+		switch outcome.state {
+		case TxStateFinalized:
+			if i == 0 || succeeded && !outcome.succeeded {
+				exitCode = outcome.exitCode
+			}
+			succeeded = succeeded && outcome.succeeded
+			totalActionFees = outcome.totalActionFees
+		case TxStateExpired:
+			// Trace did not finalize in time, no longer tracked and reported as failed
+			succeeded = false
+		default:
+			// Transaction is seen but not finalized
+			return tracetracking.Cascading, false, 0, tlb.ZeroCoins, true
+		}
+	}
+	return tracetracking.Finalized, succeeded, exitCode, totalActionFees, true
+}
+
+// GetTxStateByID returns the lifecycle state of a transaction by ID, along with its
+// outcome once finalized, and whether the transaction is known to this store.
+func (s *TxStore) GetTxStateByID(id string) (TxState, *FinalizedTx, bool) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	if _, exists := s.unconfirmedTxs[lt]; exists {
-		// Transaction is seen but not finalized
-		return tracetracking.Cascading, false, 0, tlb.ZeroCoins, true
+	state, found := s.getTxState(id)
+	return state, s.finalizedTxs[id], found
+}
+
+// statusUpdate returns the current state of a transaction as an update for its subscribers.
+func (s *TxStore) statusUpdate(id string) (TxStatusUpdate, bool) {
+	state, found := s.getTxState(id)
+	if !found {
+		return TxStatusUpdate{}, false
 	}
 
-	if tx, exists := s.finalizedTxs[lt]; exists {
-		// Transaction is finalized (success or failure is indicated separately)
-		totalActionFees := tlb.MustFromNano(tx.ReceivedMessage.TotalActionFees, 9)
-		return tracetracking.Finalized, tx.TraceSucceeded, tx.ExitCode, totalActionFees, true
+	update := TxStatusUpdate{ID: id, State: state, Status: transactionStatus(state, false), TotalActionFees: tlb.ZeroCoins}
+	switch state {
+	case TxStateUnconfirmed:
+		receivedMessage := s.unconfirmedTxs[id].Tx.ReceivedMessage
+		update.ReceivedMessage = &receivedMessage
+	case TxStateFinalized:
+		tx := s.finalizedTxs[id]
+		receivedMessage := tx.ReceivedMessage
+		update.Status = transactionStatus(state, tx.TraceSucceeded)
+		update.ExitCode = tx.ExitCode
+		update.TotalActionFees = tx.TotalActionFees
+		update.Failure = tx.Failure
+		update.ReceivedMessage = &receivedMessage
+	case TxStateErrored:
+		update.Reason = s.erroredTxs[id].Reason
+	case TxStateExpired:
+		update.Reason = s.expiredTxs[id].Reason
 	}
+	return update, true
+}
 
-	// Transaction not found in any store
-	return tracetracking.NotFound, false, 0, tlb.ZeroCoins, false
+// notify publishes the current state of a transaction, the lock must be held.
+func (s *TxStore) notify(id string) {
+	if update, found := s.statusUpdate(id); found {
+		s.notifier.publish(update)
+	}
+}
+
+func (s *TxStore) getTxState(id string) (TxState, bool) {
+	if _, exists := s.enqueuedTxs[id]; exists {
+		return TxStateEnqueued, true
+	}
+	if _, exists := s.broadcastingTxs[id]; exists {
+		return TxStateBroadcasting, true
+	}
+	if _, exists := s.unconfirmedTxs[id]; exists {
+		return TxStateUnconfirmed, true
+	}
+	if _, exists := s.finalizedTxs[id]; exists {
+		return TxStateFinalized, true
+	}
+	if _, exists := s.erroredTxs[id]; exists {
+		return TxStateErrored, true
+	}
+	if _, exists := s.expiredTxs[id]; exists {
+		return TxStateExpired, true
+	}
+	return "", false
 }
 
 type AccountStore struct {
-	store map[string]*TxStore // map account address to txstore
-	lock  sync.RWMutex
+	store       map[string]*TxStore // map account address to txstore
+	orm         ORM
+	notifier    *statusNotifier
+	lock        sync.RWMutex
+	enqueueLock sync.Mutex // serializes the ID checks of the transactions enqueued across accounts
 }
 
+// NewAccountStore creates an AccountStore that only keeps transactions in memory.
 func NewAccountStore() *AccountStore {
+	return NewPersistentAccountStore(newMemoryORM())
+}
+
+// NewPersistentAccountStore creates an AccountStore whose TxStores persist every transition through the ORM.
+func NewPersistentAccountStore(orm ORM) *AccountStore {
 	return &AccountStore{
-		store: map[string]*TxStore{},
+		store:    map[string]*TxStore{},
+		orm:      orm,
+		notifier: newStatusNotifier(),
 	}
 }
 
@@ -150,12 +710,171 @@ func (c *AccountStore) GetTxStore(accountAddress string) *TxStore {
 
 	store, exists := c.store[accountAddress]
 	if !exists {
-		store = NewTxStore()
+		store = NewTxStore(accountAddress, c.orm)
+		store.notifier = c.notifier
 		c.store[accountAddress] = store
 	}
 	return store
 }
 
+// AddEnqueued persists a transaction accepted by Enqueue in the TxStore of its sender. IDs are unique
+// across accounts, ErrTxAlreadyExists is returned if a transaction with the same ID is known to any of
+// them, unless it did not succeed: it is then replaced by the new transaction, a retry.
+func (c *AccountStore) AddEnqueued(ctx context.Context, tx *Tx) error {
+	c.enqueueLock.Lock()
+	defer c.enqueueLock.Unlock()
+
+	state, finalized, err := c.GetTxStateByID(ctx, tx.ID)
+	if errors.Is(err, ErrTxNotFound) {
+		return c.GetTxStore(tx.From.String()).AddEnqueued(ctx, tx)
+	}
+	if err != nil {
+		return err
+	}
+	if !retriable(state, finalized) {
+		return fmt.Errorf("%w: %s", ErrTxAlreadyExists, tx.ID)
+	}
+
+	c.lock.RLock()
+	stores := maps.Values(c.store)
+	c.lock.RUnlock()
+	for _, store := range stores {
+		store.forget(tx.ID)
+	}
+	return c.GetTxStore(tx.From.String()).AddRetry(ctx, tx)
+}
+
+// retriable returns whether a transaction in a terminal state did not succeed, so that a transaction
+// with its ID, an idempotency key, may be enqueued again: it errored, expired or its trace failed.
+func retriable(state TxState, finalized *FinalizedTx) bool {
+	switch state {
+	case TxStateErrored, TxStateExpired:
+		return true
+	case TxStateFinalized:
+		return finalized != nil && !finalized.TraceSucceeded
+	default:
+		return false
+	}
+}
+
+// Exists reports whether a transaction with the ID is known to any account, or to the ORM.
+func (c *AccountStore) Exists(ctx context.Context, id string) (bool, error) {
+	c.lock.RLock()
+	stores := maps.Values(c.store)
+	c.lock.RUnlock()
+
+	for _, store := range stores {
+		if _, _, found := store.GetTxStateByID(id); found {
+			return true, nil
+		}
+	}
+
+	_, err := c.orm.GetTxByID(ctx, id)
+	if errors.Is(err, ErrTxNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetPendingTxs returns the persisted transactions that have not reached a terminal state.
+func (c *AccountStore) GetPendingTxs(ctx context.Context) ([]*PersistedTx, error) {
+	return c.orm.GetPendingTxs(ctx)
+}
+
+// GetTxStateByID looks a transaction up by ID across all accounts, falling back to the ORM
+// for transactions that reached a terminal state before a restart.
+func (c *AccountStore) GetTxStateByID(ctx context.Context, id string) (TxState, *FinalizedTx, error) {
+	c.lock.RLock()
+	stores := maps.Values(c.store)
+	c.lock.RUnlock()
+
+	for _, store := range stores {
+		if state, finalized, found := store.GetTxStateByID(id); found {
+			return state, finalized, nil
+		}
+	}
+
+	tx, err := c.orm.GetTxByID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	if tx.State != TxStateFinalized {
+		return tx.State, nil, nil
+	}
+	return tx.State, &FinalizedTx{
+		ExitCode:        tx.ExitCode,
+		TraceSucceeded:  tx.TraceSucceeded,
+		TotalActionFees: tx.TotalActionFees,
+		TraceFees:       tx.TraceFees,
+		Failure:         tx.Failure,
+	}, nil
+}
+
+// Subscribe returns a channel receiving the current state of a transaction followed by its transitions.
+// The channel is closed after a terminal state is delivered, when the returned func is called, or when
+// the subscriptions are closed. Transactions unknown to the store and to the ORM return an error.
+func (c *AccountStore) Subscribe(ctx context.Context, id string) (<-chan TxStatusUpdate, func(), error) {
+	sub, unsubscribe := c.notifier.subscribe(id)
+
+	update, err := c.getStatusUpdate(ctx, id)
+	if err != nil {
+		unsubscribe()
+		return nil, nil, err
+	}
+	c.notifier.snapshot(sub, update)
+	return sub.ch, unsubscribe, nil
+}
+
+// CloseSubscriptions closes the channel of every subscription.
+func (c *AccountStore) CloseSubscriptions() {
+	c.notifier.close()
+}
+
+func (c *AccountStore) getStatusUpdate(ctx context.Context, id string) (TxStatusUpdate, error) {
+	c.lock.RLock()
+	stores := maps.Values(c.store)
+	c.lock.RUnlock()
+
+	for _, store := range stores {
+		store.lock.RLock()
+		update, found := store.statusUpdate(id)
+		store.lock.RUnlock()
+		if found {
+			return update, nil
+		}
+	}
+
+	// transaction reached a terminal state before a restart, its trace is no longer available
+	tx, err := c.orm.GetTxByID(ctx, id)
+	if err != nil {
+		return TxStatusUpdate{}, fmt.Errorf("transaction with id %s not found: %w", id, err)
+	}
+	return TxStatusUpdate{
+		ID:              id,
+		State:           tx.State,
+		Status:          transactionStatus(tx.State, tx.TraceSucceeded),
+		ExitCode:        tx.ExitCode,
+		TotalActionFees: tx.TotalActionFees,
+		Failure:         tx.Failure,
+		Reason:          tx.Error,
+	}, nil
+}
+
+// GetTxState looks a transaction up by the LT of its wallet transaction across all accounts,
+// see TxStore.GetTxState for the returned values.
+func (c *AccountStore) GetTxState(ctx context.Context, lt uint64) (tracetracking.MsgStatus, bool, tvm.ExitCode, tlb.Coins, bool) {
+	c.lock.RLock()
+	stores := maps.Values(c.store)
+	c.lock.RUnlock()
+
+	for _, store := range stores {
+		if status, succeeded, exitCode, totalActionFees, found := store.GetTxState(ctx, lt); found {
+			return status, succeeded, exitCode, totalActionFees, true
+		}
+	}
+	return tracetracking.NotFound, false, 0, tlb.ZeroCoins, false
+}
+
 // GetTotalInflightCount returns the total count of unconfirmed txs across all accounts.
 func (c *AccountStore) GetTotalInflightCount() int {
 	c.lock.RLock()
@@ -168,6 +887,57 @@ func (c *AccountStore) GetTotalInflightCount() int {
 	return count
 }
 
+// Prune drops the transactions past retention from every account, see TxStore.Prune.
+func (c *AccountStore) Prune(now time.Time, retention time.Duration, maxRetained uint) int {
+	c.lock.RLock()
+	stores := maps.Values(c.store)
+	c.lock.RUnlock()
+
+	pruned := 0
+	for _, store := range stores {
+		pruned += store.Prune(now, retention, maxRetained)
+	}
+	return pruned
+}
+
+// GetTotalRetainedCount returns the total count of finalized, errored and expired txs kept in memory across all accounts.
+func (c *AccountStore) GetTotalRetainedCount() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	count := 0
+	for _, store := range c.store {
+		count += store.RetainedCount()
+	}
+	return count
+}
+
+// GetAllUnfinished returns a map from account address to the states of their unfinished transactions, by ID.
+func (c *AccountStore) GetAllUnfinished() map[string]map[string]TxState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	allUnfinished := map[string]map[string]TxState{}
+	for account, store := range c.store {
+		if unfinished := store.GetUnfinished(); len(unfinished) > 0 {
+			allUnfinished[account] = unfinished
+		}
+	}
+	return allUnfinished
+}
+
+// GetAllBroadcasting returns a map from account address to their list of broadcasting transactions.
+func (c *AccountStore) GetAllBroadcasting() map[string][]*Tx {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	allBroadcasting := map[string][]*Tx{}
+	for account, store := range c.store {
+		allBroadcasting[account] = store.GetBroadcasting()
+	}
+	return allBroadcasting
+}
+
 // GetAllUnconfirmed returns a map from account address to their list of unconfirmed transactions.
 func (c *AccountStore) GetAllUnconfirmed() map[string][]*UnconfirmedTx {
 	c.lock.RLock()
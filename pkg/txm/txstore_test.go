@@ -0,0 +1,242 @@
+package txm
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tracetracking"
+	"github.com/smartcontractkit/chainlink-ton/pkg/ton/tvm"
+)
+
+func TestAccountStore_AddEnqueued(t *testing.T) {
+	ctx := t.Context()
+	c := NewAccountStore()
+
+	tx := testTx("key")
+	require.NoError(t, c.AddEnqueued(ctx, tx))
+	exists, err := c.Exists(ctx, tx.ID)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// IDs are unique across the senders of the pool
+	other := testTx("key")
+	other.From = *testAddress(3)
+	require.ErrorIs(t, c.AddEnqueued(ctx, other), ErrTxAlreadyExists)
+	require.Empty(t, c.GetTxStore(other.From.String()).GetUnfinished())
+
+	exists, err = c.Exists(ctx, "unknown")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestWalletTxStatus(t *testing.T) {
+	fees := tlb.MustFromTON("0.01")
+	succeeded := txOutcome{state: TxStateFinalized, succeeded: true, totalActionFees: fees}
+	failed := txOutcome{state: TxStateFinalized, exitCode: tvm.ExitCodeOutOfGasError, totalActionFees: fees}
+
+	testCases := []struct {
+		name      string
+		outcomes  []txOutcome
+		status    tracetracking.MsgStatus
+		succeeded bool
+		exitCode  tvm.ExitCode
+		fees      tlb.Coins
+		found     bool
+	}{
+		{name: "not found", status: tracetracking.NotFound, fees: tlb.ZeroCoins},
+		{name: "unconfirmed", outcomes: []txOutcome{{state: TxStateUnconfirmed}}, status: tracetracking.Cascading, fees: tlb.ZeroCoins, found: true},
+		{name: "succeeded", outcomes: []txOutcome{succeeded}, status: tracetracking.Finalized, succeeded: true, fees: fees, found: true},
+		{name: "failed", outcomes: []txOutcome{failed}, status: tracetracking.Finalized, exitCode: tvm.ExitCodeOutOfGasError, fees: fees, found: true},
+		{name: "expired", outcomes: []txOutcome{{state: TxStateExpired}}, status: tracetracking.Finalized, fees: tlb.ZeroCoins, found: true},
+		{
+			name:     "batch with an unconfirmed transaction",
+			outcomes: []txOutcome{succeeded, {state: TxStateUnconfirmed}},
+			status:   tracetracking.Cascading,
+			fees:     tlb.ZeroCoins,
+			found:    true,
+		},
+		{name: "batch succeeded", outcomes: []txOutcome{succeeded, succeeded}, status: tracetracking.Finalized, succeeded: true, fees: fees, found: true},
+		{
+			name:     "batch with a failed transaction",
+			outcomes: []txOutcome{succeeded, failed, {state: TxStateFinalized, exitCode: tvm.ExitCodeStackUnderflow, totalActionFees: fees}},
+			status:   tracetracking.Finalized,
+			exitCode: tvm.ExitCodeOutOfGasError,
+			fees:     fees,
+			found:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, succeeded, exitCode, fees, found := walletTxStatus(tc.outcomes)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.succeeded, succeeded)
+			require.Equal(t, tc.exitCode, exitCode)
+			require.Equal(t, tc.fees.Nano(), fees.Nano())
+			require.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestTxStore_UpdateTrace(t *testing.T) {
+	ctx := t.Context()
+	s := NewTxStore(testAddress(1).String(), nopORM{})
+	tx := testTx("tx-1")
+	require.NoError(t, s.AddEnqueued(ctx, tx))
+
+	sent := &tracetracking.SentMessage{LamportTime: 11}
+	require.NoError(t, s.AddUnconfirmed(ctx, 11, 0, tx, tracetracking.ReceivedMessage{
+		LamportTime:                  10,
+		OutgoingInternalSentMessages: []*tracetracking.SentMessage{sent},
+	}))
+	published, found := s.statusUpdate(tx.ID)
+	require.True(t, found)
+
+	// the trace is advanced on a copy
+	trace, _, ok := s.GetTrace(tx.ID)
+	require.True(t, ok)
+	trace.OutgoingInternalSentMessages = trace.OutgoingInternalSentMessages[:0]
+	trace.OutgoingInternalReceivedMessages = append(trace.OutgoingInternalReceivedMessages, &tracetracking.ReceivedMessage{LamportTime: 11})
+	stored, _, _ := s.GetTrace(tx.ID)
+	require.Equal(t, []*tracetracking.SentMessage{sent}, stored.OutgoingInternalSentMessages)
+	require.Empty(t, stored.OutgoingInternalReceivedMessages)
+
+	s.UpdateTrace(tx.ID, trace, 2)
+	stored, nodeID, _ := s.GetTrace(tx.ID)
+	require.Empty(t, stored.OutgoingInternalSentMessages)
+	require.Len(t, stored.OutgoingInternalReceivedMessages, 1)
+	require.Equal(t, uint32(2), nodeID)
+	// updates already published keep the trace they were published with
+	require.Equal(t, []*tracetracking.SentMessage{sent}, published.ReceivedMessage.OutgoingInternalSentMessages)
+	require.Empty(t, published.ReceivedMessage.OutgoingInternalReceivedMessages)
+}
+
+func TestTxStore_UpdateNodeID(t *testing.T) {
+	ctx := t.Context()
+	s := NewTxStore(testAddress(1).String(), nopORM{})
+	tx := testTx("tx-1")
+	require.NoError(t, s.AddEnqueued(ctx, tx))
+
+	// only broadcasting transactions are looked up on a lite server
+	s.UpdateNodeID(tx.ID, 1)
+	require.Zero(t, tx.NodeID)
+
+	require.NoError(t, s.MarkBroadcasting(ctx, tx.ID, testExternalMessage(), time.Now().Add(time.Minute), 2))
+	require.Equal(t, uint32(2), tx.NodeID)
+	s.UpdateNodeID(tx.ID, 3)
+	require.Equal(t, uint32(3), tx.NodeID)
+	s.UpdateNodeID("unknown", 4)
+	require.Equal(t, uint32(3), tx.NodeID)
+}
+
+func TestTxStore_MarkRebroadcast(t *testing.T) {
+	ctx := t.Context()
+	s := NewTxStore(testAddress(1).String(), nopORM{})
+	tx := testTx("tx-1")
+	require.NoError(t, s.AddEnqueued(ctx, tx))
+	require.ErrorContains(t, s.MarkRebroadcast(ctx, tx.ID), "no such broadcasting tx")
+
+	ext := testExternalMessage()
+	require.NoError(t, s.MarkBroadcasting(ctx, tx.ID, ext, time.Now().Add(time.Minute), 0))
+	require.Same(t, ext, tx.ExternalMsg)
+	require.Equal(t, ext.Body.Hash(), tx.InMsgHash)
+	sentAt := tx.LastSentAt
+	require.False(t, sentAt.IsZero())
+
+	for range 2 {
+		require.NoError(t, s.MarkRebroadcast(ctx, tx.ID))
+	}
+	require.Equal(t, uint(2), tx.Rebroadcasts)
+	require.False(t, tx.LastSentAt.Before(sentAt))
+
+	// the message rebuilt after a resubmission is sent to the lite servers from the first one again
+	_, err := s.Resubmit(ctx, tx.ID)
+	require.NoError(t, err)
+	require.NoError(t, s.MarkBroadcasting(ctx, tx.ID, testExternalMessage(), time.Now().Add(time.Minute), 0))
+	require.Zero(t, tx.Rebroadcasts)
+}
+
+func testExternalMessage() *tlb.ExternalMessage {
+	return &tlb.ExternalMessage{DstAddr: testAddress(1), Body: cell.BeginCell().MustStoreUInt(1, 8).EndCell()}
+}
+
+func TestAccountStore_Prune(t *testing.T) {
+	testCases := []struct {
+		name        string
+		retention   time.Duration
+		maxRetained uint
+		pruned      []string
+		lost        []string // pruned transactions whose outcome is not kept either
+	}{
+		{name: "no limits"},
+		{name: "nothing past retention", retention: 4 * time.Hour},
+		{name: "past retention", retention: time.Hour, pruned: []string{"succeeded", "failed", "errored"}},
+		{
+			name:        "beyond max retained",
+			maxRetained: 1,
+			pruned:      []string{"succeeded", "failed", "errored"},
+			lost:        []string{"succeeded", "failed"},
+		},
+		{name: "beyond max retained after retention", retention: 150 * time.Minute, maxRetained: 2, pruned: []string{"succeeded", "failed"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			now := time.Now()
+			c := NewAccountStore()
+			s := c.GetTxStore(testAddress(1).String())
+			fees := tlb.MustFromTON("0.01")
+			failure := &Failure{Hop: 1, Phase: FailurePhaseCompute, ExitCode: tvm.ExitCodeOutOfGasError}
+			// a batch sent by the wallet transaction with LT 10, then two transactions that did not land
+			s.finalizedTxs["succeeded"] = &FinalizedTx{TraceSucceeded: true, TotalActionFees: fees, FinalizedAt: now.Add(-3 * time.Hour)}
+			s.finalizedTxs["failed"] = &FinalizedTx{ExitCode: tvm.ExitCodeOutOfGasError, TotalActionFees: fees, Failure: failure, FinalizedAt: now.Add(-2 * time.Hour)}
+			s.ltToIDs[10] = []string{"succeeded", "failed"}
+			s.erroredTxs["errored"] = &ErroredTx{Reason: "failed to build message", ErroredAt: now.Add(-90 * time.Minute)}
+			s.expiredTxs["expired"] = &ErroredTx{Reason: "external message expired without landing", ErroredAt: now.Add(-10 * time.Minute)}
+			before := make(map[string]TxState)
+			for _, id := range []string{"succeeded", "failed", "errored", "expired"} {
+				before[id], _ = s.getTxState(id)
+			}
+
+			require.Equal(t, len(tc.pruned), c.Prune(now, tc.retention, tc.maxRetained))
+			require.Equal(t, len(before)-len(tc.pruned), c.GetTotalRetainedCount())
+
+			// without an ORM, pruned transactions keep their outcome and their ID within the same limits
+			for id, state := range before {
+				_, _, inMemory := s.GetTxStateByID(id)
+				require.Equal(t, !slices.Contains(tc.pruned, id), inMemory, id)
+
+				exists, err := c.Exists(ctx, id)
+				require.NoError(t, err)
+				if slices.Contains(tc.lost, id) {
+					require.False(t, exists, id)
+					_, _, err = c.GetTxStateByID(ctx, id)
+					require.ErrorIs(t, err, ErrTxNotFound)
+					continue
+				}
+				require.True(t, exists, id)
+				found, finalized, err := c.GetTxStateByID(ctx, id)
+				require.NoError(t, err)
+				require.Equal(t, state, found, id)
+				if id == "failed" {
+					require.Equal(t, tvm.ExitCodeOutOfGasError, finalized.ExitCode)
+					require.Equal(t, failure, finalized.Failure)
+				}
+			}
+			status, succeeded, exitCode, totalActionFees, found := c.GetTxState(ctx, 10)
+			if slices.Contains(tc.lost, "failed") {
+				require.False(t, found)
+				return
+			}
+			require.True(t, found)
+			require.Equal(t, tracetracking.Finalized, status)
+			require.False(t, succeeded)
+			require.Equal(t, tvm.ExitCodeOutOfGasError, exitCode)
+			require.Equal(t, fees.Nano(), totalActionFees.Nano())
+		})
+	}
+}